@@ -0,0 +1,95 @@
+// adminauth.go — protection for operational routes (/admin/*, /debug/*):
+//   • HTTP Basic auth (ADMIN_USER / ADMIN_PASSWORD) and/or
+//     a shared secret header (X-Admin-Token = ADMIN_TOKEN)
+//   • failures are recorded on the span; credentials are never logged
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errAdminUnauthorized = errors.New("admin credentials required")
+
+// adminPrefixes are guarded wherever they are mounted.
+var adminPrefixes = []string{"/admin", "/debug"}
+
+func isAdminPath(path string) bool {
+	path = routePath(path)
+	for _, p := range adminPrefixes {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// adminCredentialsSet reports whether adminAuth has anything to check.
+func adminCredentialsSet() bool {
+	return (envString("ADMIN_USER", "") != "" && envString("ADMIN_PASSWORD", "") != "") || envString("ADMIN_TOKEN", "") != ""
+}
+
+// callerAuthExempt reports the routes API key and JWT auth let through:
+// probes always, the admin routes only while adminAuth guards them, so
+// turning on API_KEYS or JWT alone still closes them.
+func callerAuthExempt(path string) bool {
+	return isProbePath(path) || (isAdminPath(path) && adminCredentialsSet())
+}
+
+func adminAuth(l *slog.Logger) gin.HandlerFunc {
+	user, pass, token := envString("ADMIN_USER", ""), envString("ADMIN_PASSWORD", ""), envString("ADMIN_TOKEN", "")
+	if !adminCredentialsSet() {
+		l.Warn("admin/debug routes are unprotected; set ADMIN_USER+ADMIN_PASSWORD or ADMIN_TOKEN")
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		if !isAdminPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		reason := "missing_credentials"
+		if t := c.GetHeader("X-Admin-Token"); t != "" {
+			if token != "" && secureEqual(t, token) {
+				c.Next()
+				return
+			}
+			reason = "invalid_token"
+		} else if u, p, ok := c.Request.BasicAuth(); ok {
+			if user != "" && secureEqual(u, user) && secureEqual(p, pass) {
+				setEndUser(c, u)
+				c.Next()
+				return
+			}
+			reason = "invalid_credentials"
+		}
+
+		span := traceSpan(c.Request.Context())
+		span.SetAttributes(attribute.String("auth.failure", reason))
+		span.AddEvent("auth.failed", trace.WithAttributes(
+			attribute.String("auth.scheme", "admin"),
+			attribute.String("auth.failure", reason),
+		))
+		if user != "" {
+			c.Header("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+		}
+		respondError(c, errAdminUnauthorized, http.StatusUnauthorized)
+		c.Abort()
+	}
+}
+
+// secureEqual compares digests so neither content nor length leaks via timing.
+func secureEqual(a, b string) bool {
+	ha, hb := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
@@ -0,0 +1,126 @@
+// aggregate.go — GET /aggregate?n=5&fail_rate=0.2&mode=partial fans out n
+//   concurrent calls to the echo service and merges the answers, so traces
+//   show fan-out/fan-in under one parent:
+//   • every call has its own aggregate.call span (sibling client spans)
+//   • mode=partial (default) keeps what succeeded and reports the rest;
+//     mode=strict cancels the siblings at the first failure
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+)
+
+const maxFanOut = 50
+
+type aggregatePart struct {
+	Index  int             `json:"index"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+func aggregateHandler(c *gin.Context) {
+	n, err1 := strconv.Atoi(c.DefaultQuery("n", "5"))
+	failRate, err2 := strconv.ParseFloat(c.DefaultQuery("fail_rate", "0"), 64)
+	mode := c.DefaultQuery("mode", "partial")
+	if err1 != nil || err2 != nil || n < 1 || n > maxFanOut || failRate < 0 || failRate > 1 ||
+		(mode != "partial" && mode != "strict") {
+		respondError(c, fmt.Errorf("want n 1-%d, fail_rate in [0,1], mode partial|strict", maxFanOut), http.StatusBadRequest)
+		return
+	}
+	if !echoEnabled() {
+		respondError(c, errors.New("echo service disabled"), http.StatusNotFound)
+		return
+	}
+
+	parts := make([]aggregatePart, n)
+	g, gctx := errgroup.WithContext(c.Request.Context())
+	var (
+		mu     sync.Mutex
+		failed int
+	)
+	for i := range n {
+		g.Go(func() error {
+			raw, err := aggregateCall(gctx, c.Request, i, rand.Float64() < failRate)
+			parts[i] = aggregatePart{Index: i, Result: raw}
+			if err == nil {
+				return nil
+			}
+			parts[i].Error = err.Error()
+			mu.Lock()
+			failed++
+			mu.Unlock()
+			if mode == "strict" {
+				return fmt.Errorf("call %d: %w", i, err)
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+
+	traceSpan(c.Request.Context()).SetAttributes(
+		attribute.Int("aggregate.calls", n),
+		attribute.Int("aggregate.failed", failed),
+		attribute.String("aggregate.mode", mode),
+	)
+	if err != nil {
+		respondError(c, err, http.StatusBadGateway)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"calls":   n,
+		"failed":  failed,
+		"partial": failed > 0,
+		"parts":   parts,
+	})
+}
+
+// aggregateCall asks the echo service for one part; fail requests a 500.
+func aggregateCall(ctx context.Context, in *http.Request, i int, fail bool) (json.RawMessage, error) {
+	ctx, span := tracer.Start(ctx, "aggregate.call", trace.WithAttributes(
+		attribute.Int("aggregate.index", i),
+		attribute.Bool("aggregate.fail_requested", fail),
+	))
+	defer span.End()
+
+	status := http.StatusOK
+	if fail {
+		status = http.StatusInternalServerError
+	}
+	url := fmt.Sprintf("%s/echo?part=%d&delay=%dms&status=%d", echoURL(), i, 10+rand.IntN(90), status)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	forwardAuth(req, in)
+
+	var raw json.RawMessage
+	resp, err := outboundClient.Do(req)
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			err = fmt.Errorf("echo returned %d", resp.StatusCode)
+		} else {
+			err = json.NewDecoder(resp.Body).Decode(&raw)
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	return raw, nil
+}
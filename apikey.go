@@ -0,0 +1,123 @@
+// apikey.go — optional X-API-Key authentication:
+//   • keys from API_KEYS ("name:key,…") and/or API_KEYS_FILE (one per line)
+//   • caller name recorded as enduser.id on the span and in logs
+//   • 401 for a missing key, 403 for an unknown one (via respondError)
+//   • probes (health, readiness, version, metrics) need no key; nor do the
+//     admin routes once admin credentials are set, as admin auth guards them
+
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ctxEndUser is the gin context key holding the authenticated caller.
+const ctxEndUser = "enduser.id"
+
+var (
+	errMissingAPIKey = errors.New("missing API key")
+	errInvalidAPIKey = errors.New("invalid API key")
+)
+
+/* -------------------------------------------------------------------------- */
+/* Key loading                                                                */
+/* -------------------------------------------------------------------------- */
+
+// loadAPIKeys returns key → caller name; an empty map disables the check.
+func loadAPIKeys() (map[string]string, error) {
+	keys := make(map[string]string)
+	add := func(entry string) error {
+		name, key, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || name == "" || key == "" {
+			return fmt.Errorf("malformed API key entry %q (want name:key)", entry)
+		}
+		keys[key] = name
+		return nil
+	}
+
+	for _, e := range envList("API_KEYS") {
+		if err := add(e); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := envString("API_KEYS_FILE", ""); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if err := add(line); err != nil {
+				return nil, err
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* Middleware                                                                 */
+/* -------------------------------------------------------------------------- */
+
+// apiKeyAuth skips callerAuthExempt routes: probes and scrapers carry no
+// key, and admins have their own credentials.
+func apiKeyAuth(keys map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if callerAuthExempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		presented := c.GetHeader("X-API-Key")
+		if presented == "" {
+			respondError(c, errMissingAPIKey, http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		name := lookupAPIKey(keys, presented)
+		if name == "" {
+			respondError(c, errInvalidAPIKey, http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
+		setEndUser(c, name)
+		c.Next()
+	}
+}
+
+// lookupAPIKey compares against every key in constant time.
+func lookupAPIKey(keys map[string]string, presented string) string {
+	var found string
+	for k, name := range keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(presented)) == 1 {
+			found = name
+		}
+	}
+	return found
+}
+
+// setEndUser tags the span and request context with the caller identity.
+func setEndUser(c *gin.Context, id string) {
+	c.Set(ctxEndUser, id)
+	traceSpan(c.Request.Context()).SetAttributes(attribute.String("enduser.id", id))
+}
@@ -0,0 +1,60 @@
+// basepath.go — BASE_PATH (e.g. "/api") mounts the main router's routes
+//   under a prefix, for ingresses that forward it instead of stripping it:
+//   • ops routes move along unless ADMIN_LISTEN serves them separately
+//   • admin/ops checks compare paths without the prefix, so /api/admin/*
+//     is guarded like /admin/*; so do chaos rules, flag targeting and the
+//     SLO route keys, whose config names routes as /items/:id
+//   • handlers that route on the raw URL themselves (grpc-gateway, Twirp,
+//     pprof) see it unprefixed
+//   • SELF_URL's default follows it, so self-calls keep working
+
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// basePath is BASE_PATH as serve mounted it: "" or "/segment[/…]".
+var basePath string
+
+// cleanBasePath normalizes "api/", "/api" and "/api/" to "/api".
+func cleanBasePath(p string) string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
+// routePath is p relative to basePath; paths outside it are returned as is.
+func routePath(p string) string {
+	if basePath == "" {
+		return p
+	}
+	if p == basePath {
+		return "/"
+	}
+	if rest, ok := strings.CutPrefix(p, basePath+"/"); ok {
+		return "/" + rest
+	}
+	return p
+}
+
+// unprefixed serves h with basePath removed from the request path.
+func unprefixed(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := routePath(r.URL.Path)
+		if p == r.URL.Path {
+			h.ServeHTTP(w, r)
+			return
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path, r2.URL.RawPath = p, ""
+		h.ServeHTTP(w, r2)
+	})
+}
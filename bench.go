@@ -0,0 +1,158 @@
+// bench.go — `app bench json` measures the JSON codec the binary was built
+//   with (jsoncodec.go) against encoding/json on what the CRUD endpoints
+//   do: encoding one item, streaming a list of --items of them and
+//   decoding a create body
+//   • runs in process, no server or telemetry needed; each case loops
+//     until it has run about a second per codec, timed here rather than by
+//     testing.Benchmark so package testing stays out of the binary
+//   • built without a codec tag both columns are encoding/json, which is
+//     the "before"; build again with a tag for the "after"
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type jsonBenchCodec struct {
+	name      string
+	marshal   func(any) ([]byte, error)
+	unmarshal func([]byte, any) error
+}
+
+// jsonBenchCase runs one operation n times with a codec.
+type jsonBenchCase struct {
+	name string
+	run  func(n int, c jsonBenchCodec) error
+}
+
+// benchResult is the per-operation cost of the final, long enough run.
+type benchResult struct {
+	nsPerOp, allocsPerOp int64
+}
+
+// benchTime is how long one case runs per codec.
+const benchTime = time.Second
+
+// measure calls run with a growing n, as testing.Benchmark does, until one
+// call takes benchTime.
+func measure(run func(n int) error) (benchResult, error) {
+	var ms runtime.MemStats
+	for n := 1; ; {
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		mallocs := ms.Mallocs
+		start := time.Now()
+		if err := run(n); err != nil {
+			return benchResult{}, err
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&ms)
+		if elapsed >= benchTime || n >= 1e9 {
+			return benchResult{
+				nsPerOp:     elapsed.Nanoseconds() / int64(n),
+				allocsPerOp: int64(ms.Mallocs-mallocs) / int64(n),
+			}, nil
+		}
+		// predict the n that fills benchTime, overshoot a little and grow
+		// at most 100x per step
+		next := int64(n) * int64(benchTime) / max(elapsed.Nanoseconds(), 1)
+		n = int(min(max(next+next/5, int64(n)+1), 100*int64(n), 1e9))
+	}
+}
+
+func newBenchCommand() *cobra.Command {
+	bench := &cobra.Command{
+		Use:   "bench",
+		Short: "Microbenchmarks of hot paths, run in process",
+	}
+	var items int
+	jsonCmd := &cobra.Command{
+		Use:   "json",
+		Short: "Compare the built-in JSON codec with encoding/json",
+		Long: `Encodes and decodes items the way the CRUD endpoints do, once with
+encoding/json and once with the codec chosen at build time (-tags jsoniter,
+go_json or "sonic avx").`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			w := cmd.OutOrStdout()
+			if jsonCodec == "encoding/json" {
+				fmt.Fprintln(w, "# built without a codec tag: both columns are encoding/json")
+			}
+			return runJSONBench(w, items)
+		},
+	}
+	jsonCmd.Flags().IntVar(&items, "items", 10000, "items in the list case")
+	bench.AddCommand(jsonCmd)
+	return bench
+}
+
+func runJSONBench(w io.Writer, items int) error {
+	list := make([]Item, items)
+	for i := range list {
+		list[i] = Item{ID: i + 1, Name: "item-" + strconv.Itoa(i+1)}
+	}
+	body := []byte(`{"name":"fountain pen"}`)
+	cases := []jsonBenchCase{
+		{"encode item", func(n int, c jsonBenchCodec) error {
+			for range n {
+				if _, err := c.marshal(Item{ID: 42, Name: "fountain pen"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{fmt.Sprintf("stream list (%d)", items), func(n int, c jsonBenchCodec) error {
+			bw := bufio.NewWriterSize(io.Discard, streamBufferSize)
+			for range n {
+				// what streamItems does per item
+				for _, item := range list {
+					p, err := c.marshal(item)
+					if err != nil {
+						return err
+					}
+					bw.Write(p)
+				}
+				bw.Flush()
+			}
+			return nil
+		}},
+		{"decode create", func(n int, c jsonBenchCodec) error {
+			for range n {
+				var in struct{ Name string }
+				if err := c.unmarshal(body, &in); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+	}
+	std := jsonBenchCodec{"encoding/json", json.Marshal, json.Unmarshal}
+	built := jsonBenchCodec{jsonCodec, jsonMarshal, jsonUnmarshal}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "case\t%s ns/op\tallocs/op\t%s ns/op\tallocs/op\tspeedup\t\n", std.name, built.name)
+	for _, bc := range cases {
+		before, err := measure(func(n int) error { return bc.run(n, std) })
+		if err != nil {
+			return fmt.Errorf("%s with %s: %w", bc.name, std.name, err)
+		}
+		after, err := measure(func(n int) error { return bc.run(n, built) })
+		if err != nil {
+			return fmt.Errorf("%s with %s: %w", bc.name, built.name, err)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.2fx\t\n", bc.name,
+			before.nsPerOp, before.allocsPerOp, after.nsPerOp, after.allocsPerOp,
+			float64(before.nsPerOp)/float64(max(after.nsPerOp, 1)))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,57 @@
+// bodylimit.go — MAX_BODY (1 MiB) caps request bodies as sent:
+//   • a Content-Length above it is refused with 413 before anything is read
+//   • any other body is cut off at the limit, so a chunked upload fails
+//     there rather than being read in full, and binding it answers 413
+//     instead of a 400 from deep in the JSON decoder
+//   • both add a request.body_too_large event to the server span
+//   • compressed bodies count as sent; MAX_DECOMPRESSED_BODY caps them
+//     inflated (decompress.go)
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func limitBody(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > maxBytes {
+			bodyTooLarge(c, maxBytes, c.Request.ContentLength)
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+// bodyTooLarge answers 413; size is -1 when only the limit is known.
+func bodyTooLarge(c *gin.Context, limit, size int64) {
+	attrs := []attribute.KeyValue{attribute.Int64("http.request.body.limit", limit)}
+	if size >= 0 {
+		attrs = append(attrs, attribute.Int64("http.request.body.size", size))
+	}
+	traceSpan(c.Request.Context()).AddEvent("request.body_too_large", trace.WithAttributes(attrs...))
+	respondError(c, fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, limit), http.StatusRequestEntityTooLarge)
+}
+
+// respondBindError answers a failed ShouldBindJSON: 413 once the body ran
+// into MAX_BODY, 400 otherwise.
+func respondBindError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		bodyTooLarge(c, tooLarge.Limit, -1)
+		return
+	}
+	respondError(c, err, http.StatusBadRequest)
+}
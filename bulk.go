@@ -0,0 +1,109 @@
+// bulk.go — POST /items/bulk creates many items in one request, one
+//   item.create child span per element. ?fail_rate=0.2 (or BULK_FAIL_RATE)
+//   injects per-element failures, so a single trace shows mixed
+//   success/failure children under one parent: 201 if all succeeded,
+//   207 Multi-Status otherwise.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type bulkResult struct {
+	Index int    `json:"index"`
+	Item  *Item  `json:"item,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func bulkCreateItems(c *gin.Context) {
+	names, failRate, ok := bindBulk(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	results, failed := createBulk(ctx, names, failRate)
+
+	traceSpan(ctx).SetAttributes(
+		attribute.Int("bulk.total", len(names)),
+		attribute.Int("bulk.failed", failed),
+	)
+	status := http.StatusCreated
+	if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{"created": len(names) - failed, "failed": failed, "results": results})
+}
+
+// bindBulk reads the item names and fail rate, responding 400 itself when
+// either is invalid.
+func bindBulk(c *gin.Context) (names []string, failRate float64, ok bool) {
+	var in []struct{ Name string }
+	if err := c.ShouldBindJSON(&in); err != nil {
+		respondBindError(c, err)
+		return nil, 0, false
+	}
+	if limit := envInt("BULK_MAX_ITEMS", 1000); len(in) == 0 || len(in) > limit {
+		respondError(c, fmt.Errorf("bulk request must contain 1-%d items", limit), http.StatusBadRequest)
+		return nil, 0, false
+	}
+
+	failRate = envFloat("BULK_FAIL_RATE", 0)
+	if q := c.Query("fail_rate"); q != "" {
+		v, err := strconv.ParseFloat(q, 64)
+		if err != nil || v < 0 || v > 1 {
+			respondError(c, errors.New("fail_rate must be within [0,1]"), http.StatusBadRequest)
+			return nil, 0, false
+		}
+		failRate = v
+	}
+
+	names = make([]string, len(in))
+	for i, el := range in {
+		names[i] = el.Name
+	}
+	return names, failRate, true
+}
+
+// createBulk creates one item per name, each under its own item.create span.
+func createBulk(ctx context.Context, names []string, failRate float64) (results []bulkResult, failed int) {
+	results = make([]bulkResult, len(names))
+	for i, name := range names {
+		spanCtx, span := tracer.Start(ctx, "item.create", trace.WithAttributes(attribute.Int("bulk.index", i)))
+
+		if failRate > 0 && rand.Float64() < failRate {
+			err := errors.New("injected element failure")
+			span.SetAttributes(attribute.Bool("injected", true))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			results[i] = bulkResult{Index: i, Error: err.Error()}
+			failed++
+			continue
+		}
+
+		item, err := repo.Create(spanCtx, name)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			results[i] = bulkResult{Index: i, Error: err.Error()}
+			failed++
+			continue
+		}
+		span.SetAttributes(attribute.Int("item.id", item.ID))
+		span.End()
+		results[i] = bulkResult{Index: i, Item: &item}
+	}
+	return results, failed
+}
@@ -0,0 +1,123 @@
+// canary.go — synthetic self-probe: every CANARY_INTERVAL the app runs a
+//   short create → get → list → delete flow against its own API under a
+//   canary.probe root span, recording canary.probes and canary.probe.duration
+//   (tagged canary=true) so availability can be measured without external
+//   synthetics.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var canarySteps = []scenarioStep{
+	{"create", http.MethodPost, "/items", `{"name":"{name}"}`},
+	{"get", http.MethodGet, "/items/{id}", ""},
+	{"list", http.MethodGet, "/items", ""},
+	{"delete", http.MethodDelete, "/items/{id}", ""},
+}
+
+var (
+	canaryProbes, _ = meter.Int64Counter("canary.probes",
+		metric.WithDescription("Canary probe checks by endpoint and result"))
+	canaryDuration, _ = meter.Float64Histogram("canary.probe.duration",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Latency of each canary probe check"))
+)
+
+// startCanary probes every CANARY_INTERVAL (0, the default, disables it);
+// the returned func stops the loop and waits for a running probe.
+func startCanary(l *slog.Logger) (func(), error) {
+	interval := envDuration("CANARY_INTERVAL", 0)
+	if interval <= 0 {
+		return func() {}, nil
+	}
+	target, err := selfURL()
+	if err != nil {
+		return nil, err
+	}
+	auth := staticAuth(envString("CANARY_API_KEY", ""), envString("CANARY_BEARER_TOKEN", ""))
+	l.Info("canary probe started", "interval", interval, "target", target)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := runCanary(context.WithoutCancel(ctx), target, auth); err != nil {
+					l.Warn("canary probe failed", "err", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
+
+// runCanary runs every check even after a failure so each endpoint gets a
+// result; steps depending on a failed create are skipped.
+func runCanary(ctx context.Context, target string, auth func(*http.Request)) error {
+	ctx, span := tracer.Start(ctx, "canary.probe", trace.WithAttributes(attribute.Bool("canary", true)))
+	defer span.End()
+
+	var (
+		itemID string
+		failed error
+		label  = fmt.Sprintf("canary-%d", time.Now().UnixNano())
+	)
+	for i, st := range canarySteps {
+		endpoint := st.method + " " + st.path
+		if st.path != "/items" && itemID == "" {
+			canaryProbes.Add(ctx, 1, metric.WithAttributes(canaryAttrs(endpoint, "skipped")...))
+			continue
+		}
+
+		start := time.Now()
+		res, id := runScenarioStep(ctx, target, i, st, itemID, label, auth)
+		elapsed := float64(time.Since(start).Microseconds()) / 1000
+		if id != "" {
+			itemID = id
+		}
+
+		result := "success"
+		if res.Error != "" {
+			result = "failure"
+			if failed == nil {
+				failed = fmt.Errorf("%s: %s", endpoint, res.Error)
+			}
+		}
+		canaryProbes.Add(ctx, 1, metric.WithAttributes(canaryAttrs(endpoint, result)...))
+		canaryDuration.Record(ctx, elapsed, metric.WithAttributes(canaryAttrs(endpoint, result)...))
+	}
+
+	if failed != nil {
+		span.RecordError(failed)
+		span.SetStatus(codes.Error, failed.Error())
+	}
+	return failed
+}
+
+func canaryAttrs(endpoint, result string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Bool("canary", true),
+		attribute.String("endpoint", endpoint),
+		attribute.String("result", result),
+	}
+}
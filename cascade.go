@@ -0,0 +1,59 @@
+// cascade.go — GET /scenario/cascade?depth=N calls itself N levels deep;
+//   the last hop fails and every level above turns that into a 502, so one
+//   trace shows the failure propagating back up the chain.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const maxCascadeDepth = 10
+
+func cascadeHandler(c *gin.Context) {
+	depth, err := strconv.Atoi(c.DefaultQuery("depth", "3"))
+	if err != nil || depth < 0 || depth > maxCascadeDepth {
+		respondError(c, fmt.Errorf("depth must be an integer in [0,%d]", maxCascadeDepth), http.StatusBadRequest)
+		return
+	}
+	span := traceSpan(c.Request.Context())
+	span.SetAttributes(attribute.Int("scenario.cascade.depth", depth))
+
+	if depth == 0 {
+		span.SetAttributes(attribute.Bool("scenario.cascade.origin", true))
+		respondError(c, errors.New("cascade origin: downstream dependency failed"), http.StatusInternalServerError)
+		return
+	}
+
+	self, err := selfURL()
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	url := fmt.Sprintf("%s/scenario/cascade?depth=%d", self, depth-1)
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	forwardAuth(req, c.Request)
+
+	resp, err := outboundOnce.Do(req)
+	if err != nil {
+		respondError(c, fmt.Errorf("hop %d: %w", depth, err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respondError(c, fmt.Errorf("hop %d: downstream returned %d", depth, resp.StatusCode), http.StatusBadGateway)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"depth": depth})
+}
@@ -0,0 +1,124 @@
+// changefeed.go — in-process feed of item changes for streaming clients:
+//   • every published item event gets a sequence number and is kept in a
+//     window of the last changeFeedHistory, so clients can resume after
+//     the last one they saw (SSE Last-Event-ID)
+//   • subscribers get a buffered channel; one that falls behind is cut off
+//     (channel closed) instead of slowing down the writers, and is expected
+//     to reconnect and resume
+//   • the producing span travels with each entry for linking
+
+package main
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	changeFeedHistory = 1000
+	changeSubBuffer   = 64
+)
+
+type changeEntry struct {
+	Seq   int64     `json:"seq"`
+	Event itemEvent `json:"event"`
+
+	producer trace.SpanContext
+}
+
+type changeSub struct {
+	ch chan changeEntry
+}
+
+// changeFeed is an eventPublisher fanning changes out to subscribers.
+type changeFeed struct {
+	mu     sync.Mutex
+	seq    int64
+	recent []changeEntry // oldest first
+	subs   map[*changeSub]struct{}
+	closed bool
+}
+
+var (
+	changes = &changeFeed{subs: map[*changeSub]struct{}{}}
+
+	changeSubsCut, _ = meter.Int64Counter("changefeed.subscribers.cut",
+		metric.WithDescription("Change feed subscribers disconnected for falling behind"))
+)
+
+func (f *changeFeed) Publish(ctx context.Context, ev itemEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	e := changeEntry{Seq: f.seq, Event: ev, producer: trace.SpanContextFromContext(ctx)}
+	f.recent = append(f.recent, e)
+	if n := len(f.recent) - changeFeedHistory; n > 0 {
+		f.recent = f.recent[n:]
+	}
+	for s := range f.subs {
+		select {
+		case s.ch <- e:
+		default:
+			f.drop(s)
+			changeSubsCut.Add(ctx, 1)
+		}
+	}
+	return nil
+}
+
+// Close ends every subscription; call it when the server starts shutting
+// down so streaming requests do not hold up the drain.
+func (f *changeFeed) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	for s := range f.subs {
+		f.drop(s)
+	}
+	return nil
+}
+
+// drop removes s; callers hold f.mu.
+func (f *changeFeed) drop(s *changeSub) {
+	delete(f.subs, s)
+	close(s.ch)
+}
+
+// subscribe returns the retained entries after since (complete is false if
+// some were already evicted) and a subscription for everything later. The
+// returned func unsubscribes.
+func (f *changeFeed) subscribe(since int64) (s *changeSub, backlog []changeEntry, complete bool, cancel func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	backlog, complete = f.after(since)
+	s = &changeSub{ch: make(chan changeEntry, changeSubBuffer)}
+	if f.closed {
+		close(s.ch)
+		return s, backlog, complete, func() {}
+	}
+	f.subs[s] = struct{}{}
+	return s, backlog, complete, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subs[s]; ok {
+			f.drop(s)
+		}
+	}
+}
+
+// after returns retained entries with Seq > since; callers hold f.mu.
+func (f *changeFeed) after(since int64) ([]changeEntry, bool) {
+	if since >= f.seq {
+		return nil, true
+	}
+	complete := len(f.recent) > 0 && f.recent[0].Seq <= since+1
+	for i, e := range f.recent {
+		if e.Seq > since {
+			return append([]changeEntry(nil), f.recent[i:]...), complete
+		}
+	}
+	return nil, complete
+}
@@ -0,0 +1,414 @@
+// chaos.go — per-route fault injection, switchable at runtime:
+//   • rules keyed by "METHOD /route/:template" (or "*" for every route)
+//     with injected latency (fixed, uniform or lognormal), an error rate
+//     and a panic probability
+//   • X-Inject-Latency: <spec> shapes a single request; LATENCY_HEADER
+//     decides when it is honored (chaos = while chaos is on, on, off) and
+//     LATENCY_HEADER_MAX caps it
+//   • ERROR_INJECT_RATE / _ROUTES / _STATUS seed an error-only mode at
+//     startup for alerting and SLO burn-rate demos
+//   • managed through /admin/chaos or the `chaos:` block of RUNTIME_CONFIG
+//   • every injected fault is tagged injected=true on the span
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errInjectedFault = errors.New("injected fault")
+
+// duration is a time.Duration that (un)marshals as "250ms" in JSON & YAML.
+type duration time.Duration
+
+func (d duration) MarshalText() ([]byte, error) { return []byte(time.Duration(d).String()), nil }
+
+func (d *duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	*d = duration(v)
+	return err
+}
+
+type chaosRule struct {
+	latencySpec `yaml:",inline"`
+	ErrorRate   float64 `json:"error_rate,omitempty"   yaml:"error_rate,omitempty"`
+	ErrorStatus int     `json:"error_status,omitempty" yaml:"error_status,omitempty"` // 500 (default) or 503
+	PanicRate   float64 `json:"panic_rate,omitempty"   yaml:"panic_rate,omitempty"`
+}
+
+func (r chaosRule) validate() error {
+	if err := r.latencySpec.validate(); err != nil {
+		return err
+	}
+	if r.ErrorRate < 0 || r.ErrorRate > 1 || r.PanicRate < 0 || r.PanicRate > 1 {
+		return errors.New("rates must be within [0,1]")
+	}
+	switch r.ErrorStatus {
+	case 0, http.StatusInternalServerError, http.StatusServiceUnavailable:
+	default:
+		return fmt.Errorf("error_status %d not supported (want 500 or 503)", r.ErrorStatus)
+	}
+	return nil
+}
+
+// errorInjectionFromEnv builds the startup error-injection mode; ok is false
+// when ERROR_INJECT_RATE is unset or zero.
+func errorInjectionFromEnv() (cfg chaosConfig, ok bool, err error) {
+	rate := envFloat("ERROR_INJECT_RATE", 0)
+	if rate == 0 {
+		return chaosConfig{}, false, nil
+	}
+	rule := chaosRule{ErrorRate: rate, ErrorStatus: envInt("ERROR_INJECT_STATUS", http.StatusInternalServerError)}
+	if err := rule.validate(); err != nil {
+		return chaosConfig{}, false, fmt.Errorf("ERROR_INJECT_*: %w", err)
+	}
+
+	routes := envList("ERROR_INJECT_ROUTES") // e.g. "GET /items/:id,POST /items"
+	if len(routes) == 0 {
+		routes = []string{"*"}
+	}
+	cfg = chaosConfig{Enabled: true, Rules: make(map[string]chaosRule, len(routes))}
+	for _, r := range routes {
+		cfg.Rules[r] = rule
+	}
+	return cfg, true, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* Latency distributions                                                      */
+/* -------------------------------------------------------------------------- */
+
+// latencySpec describes injected delay:
+//   - fixed:     always Latency
+//   - uniform:   uniformly between Latency and LatencyMax
+//   - lognormal: median Latency, spread LatencySigma, capped at LatencyMax
+type latencySpec struct {
+	Latency      duration `json:"latency,omitempty"              yaml:"latency,omitempty"`
+	Distribution string   `json:"latency_distribution,omitempty" yaml:"latency_distribution,omitempty"`
+	LatencyMax   duration `json:"latency_max,omitempty"          yaml:"latency_max,omitempty"`
+	LatencySigma float64  `json:"latency_sigma,omitempty"        yaml:"latency_sigma,omitempty"`
+}
+
+func (l latencySpec) dist() string {
+	if l.Distribution == "" {
+		return "fixed"
+	}
+	return l.Distribution
+}
+
+func (l latencySpec) validate() error {
+	if l.Latency < 0 || l.LatencyMax < 0 || l.LatencySigma < 0 {
+		return errors.New("latency values must be ≥ 0")
+	}
+	switch l.dist() {
+	case "fixed", "lognormal":
+	case "uniform":
+		if l.LatencyMax < l.Latency {
+			return errors.New("uniform latency needs latency_max ≥ latency")
+		}
+	default:
+		return fmt.Errorf("unknown latency distribution %q", l.Distribution)
+	}
+	return nil
+}
+
+func (l latencySpec) sample() time.Duration {
+	base := time.Duration(l.Latency)
+	switch l.dist() {
+	case "uniform":
+		return base + time.Duration(rand.Int64N(int64(l.LatencyMax-l.Latency)+1))
+	case "lognormal":
+		sigma := l.LatencySigma
+		if sigma == 0 {
+			sigma = 0.5
+		}
+		d := time.Duration(float64(base) * math.Exp(sigma*rand.NormFloat64()))
+		if l.LatencyMax > 0 {
+			d = min(d, time.Duration(l.LatencyMax))
+		}
+		return d
+	}
+	return base
+}
+
+// parseLatencySpec reads the X-Inject-Latency header:
+//
+//	250ms | fixed:250ms | uniform:100ms-400ms | lognormal:200ms,0.8
+func parseLatencySpec(v string) (latencySpec, error) {
+	dist, arg, ok := strings.Cut(strings.TrimSpace(v), ":")
+	if !ok {
+		dist, arg = "fixed", dist
+	}
+	var (
+		spec latencySpec
+		err  error
+	)
+	spec.Distribution = dist
+	switch dist {
+	case "fixed":
+		err = spec.Latency.UnmarshalText([]byte(arg))
+	case "uniform":
+		lo, hi, _ := strings.Cut(arg, "-")
+		if err = spec.Latency.UnmarshalText([]byte(lo)); err == nil {
+			err = spec.LatencyMax.UnmarshalText([]byte(hi))
+		}
+	case "lognormal":
+		median, sigma, hasSigma := strings.Cut(arg, ",")
+		err = spec.Latency.UnmarshalText([]byte(median))
+		if err == nil && hasSigma {
+			spec.LatencySigma, err = strconv.ParseFloat(sigma, 64)
+		}
+	default:
+		err = fmt.Errorf("unknown latency distribution %q", dist)
+	}
+	if err != nil {
+		return latencySpec{}, err
+	}
+	return spec, spec.validate()
+}
+
+type chaosConfig struct {
+	Enabled bool                 `json:"enabled"         yaml:"enabled"`
+	Rules   map[string]chaosRule `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+func (c chaosConfig) validate() error {
+	for route, r := range c.Rules {
+		if err := r.validate(); err != nil {
+			return errors.New("chaos rule " + route + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* Live state                                                                 */
+/* -------------------------------------------------------------------------- */
+
+type chaosState struct {
+	mu  sync.RWMutex
+	cfg chaosConfig
+}
+
+var chaos chaosState
+
+func (s *chaosState) snapshot() chaosConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rules := make(map[string]chaosRule, len(s.cfg.Rules))
+	for k, v := range s.cfg.Rules {
+		rules[k] = v
+	}
+	return chaosConfig{Enabled: s.cfg.Enabled, Rules: rules}
+}
+
+func (s *chaosState) set(cfg chaosConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+func (s *chaosState) update(fn func(*chaosConfig)) chaosConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg.Rules == nil {
+		s.cfg.Rules = make(map[string]chaosRule)
+	}
+	fn(&s.cfg)
+	return s.cfg
+}
+
+func (s *chaosState) enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Enabled
+}
+
+// ruleFor prefers an exact "METHOD /path" match over the "*" wildcard.
+func (s *chaosState) ruleFor(method, route string) (chaosRule, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.cfg.Enabled {
+		return chaosRule{}, false
+	}
+	if r, ok := s.cfg.Rules[method+" "+route]; ok {
+		return r, true
+	}
+	r, ok := s.cfg.Rules["*"]
+	return r, ok
+}
+
+/* -------------------------------------------------------------------------- */
+/* Middleware                                                                 */
+/* -------------------------------------------------------------------------- */
+
+// latencyHeaderPolicy decides whether X-Inject-Latency is honored.
+type latencyHeaderPolicy struct {
+	mode string // chaos | on | off
+	max  time.Duration
+}
+
+// latencyHeader is fixed at startup (rebuilt by Config.install).
+var latencyHeader = newLatencyHeaderPolicy()
+
+func newLatencyHeaderPolicy() latencyHeaderPolicy {
+	return latencyHeaderPolicy{
+		mode: strings.ToLower(envString("LATENCY_HEADER", "chaos")),
+		max:  envDuration("LATENCY_HEADER_MAX", 10*time.Second),
+	}
+}
+
+func (p latencyHeaderPolicy) honored() bool {
+	switch p.mode {
+	case "on", "true":
+		return true
+	case "chaos":
+		return chaos.enabled()
+	}
+	return false
+}
+
+func chaosInjector() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isAdminPath(c.Request.URL.Path) {
+			c.Next() // never sabotage the controls themselves
+			return
+		}
+		span := traceSpan(c.Request.Context())
+
+		if h := c.GetHeader("X-Inject-Latency"); h != "" && latencyHeader.honored() {
+			spec, err := parseLatencySpec(h)
+			if err != nil {
+				respondError(c, fmt.Errorf("X-Inject-Latency: %w", err), http.StatusBadRequest)
+				c.Abort()
+				return
+			}
+			d := spec.sample()
+			if d > latencyHeader.max {
+				d = latencyHeader.max
+				span.SetAttributes(attribute.Bool("chaos.latency_capped", true))
+			}
+			injectDelay(c.Request.Context(), span, d, spec.dist()+"+header")
+		}
+
+		rule, ok := chaos.ruleFor(c.Request.Method, routePath(c.FullPath()))
+		if !ok {
+			c.Next()
+			return
+		}
+		if d := rule.sample(); d > 0 {
+			injectDelay(c.Request.Context(), span, d, rule.dist())
+		}
+		if rule.PanicRate > 0 && rand.Float64() < rule.PanicRate {
+			span.SetAttributes(attribute.Bool("injected", true), attribute.String("chaos.fault", "panic"))
+			panic("chaos: injected panic")
+		}
+		if rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+			status := rule.ErrorStatus
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			span.SetAttributes(attribute.Bool("injected", true), attribute.String("chaos.fault", "error"))
+			if status == http.StatusServiceUnavailable {
+				c.Header("Retry-After", "1")
+			}
+			respondError(c, errInjectedFault, status)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// injectDelay sleeps for d (or until the request is cancelled) and records
+// the delay so synthetic latency is identifiable in traces.
+func injectDelay(ctx context.Context, span trace.Span, d time.Duration, dist string) {
+	span.SetAttributes(
+		attribute.Bool("injected", true),
+		attribute.Int64("chaos.latency_ms", d.Milliseconds()),
+		attribute.String("chaos.latency_distribution", dist),
+	)
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/* Admin API                                                                  */
+/* -------------------------------------------------------------------------- */
+
+func registerChaosAdmin(r gin.IRouter) {
+	g := r.Group("/admin/chaos")
+
+	g.GET("", func(c *gin.Context) {
+		c.JSON(http.StatusOK, chaos.snapshot())
+	})
+
+	// replace the whole configuration
+	g.PUT("", func(c *gin.Context) {
+		var cfg chaosConfig
+		if err := c.ShouldBindJSON(&cfg); err != nil {
+			respondBindError(c, err)
+			return
+		}
+		if err := cfg.validate(); err != nil {
+			respondError(c, err, http.StatusBadRequest)
+			return
+		}
+		chaos.set(cfg)
+		c.JSON(http.StatusOK, chaos.snapshot())
+	})
+
+	g.POST("/enable", func(c *gin.Context) {
+		chaos.update(func(cfg *chaosConfig) { cfg.Enabled = true })
+		c.JSON(http.StatusOK, chaos.snapshot())
+	})
+	g.POST("/disable", func(c *gin.Context) {
+		chaos.update(func(cfg *chaosConfig) { cfg.Enabled = false })
+		c.JSON(http.StatusOK, chaos.snapshot())
+	})
+
+	// upsert one rule: {"route":"GET /items/:id","latency":"200ms","error_rate":0.1}
+	g.PUT("/rules", func(c *gin.Context) {
+		var in struct {
+			Route string `json:"route" binding:"required"`
+			chaosRule
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			respondBindError(c, err)
+			return
+		}
+		if err := in.chaosRule.validate(); err != nil {
+			respondError(c, err, http.StatusBadRequest)
+			return
+		}
+		chaos.update(func(cfg *chaosConfig) { cfg.Rules[strings.TrimSpace(in.Route)] = in.chaosRule })
+		c.JSON(http.StatusOK, chaos.snapshot())
+	})
+
+	g.DELETE("/rules", func(c *gin.Context) {
+		route := c.Query("route")
+		if route == "" {
+			respondError(c, errors.New("route query parameter required"), http.StatusBadRequest)
+			return
+		}
+		chaos.update(func(cfg *chaosConfig) { delete(cfg.Rules, route) })
+		c.JSON(http.StatusOK, chaos.snapshot())
+	})
+}
@@ -0,0 +1,51 @@
+// chaos_contend.go — GET /chaos/contend?hold=100ms serialises callers on one
+//   mutex; lock.wait / lock.held child spans and a wait-time histogram make
+//   the queueing visible when several requests arrive together.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	contendMu   sync.Mutex
+	lockWait, _ = meter.Float64Histogram("chaos.lock.wait",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Time spent waiting for the /chaos/contend mutex"))
+)
+
+func chaosContendHandler(c *gin.Context) {
+	hold, err := time.ParseDuration(c.DefaultQuery("hold", "100ms"))
+	if err != nil || hold < 0 || hold > time.Duration(maxSlowMillis)*time.Millisecond {
+		respondError(c, fmt.Errorf("hold must be a duration in [0,%dms]", maxSlowMillis), http.StatusBadRequest)
+		return
+	}
+	ctx := c.Request.Context()
+
+	_, waitSpan := tracer.Start(ctx, "lock.wait")
+	start := time.Now()
+	contendMu.Lock()
+	waited := time.Since(start)
+	waitSpan.SetAttributes(attribute.Int64("lock.wait_ms", waited.Milliseconds()))
+	waitSpan.End()
+	lockWait.Record(ctx, float64(waited.Microseconds())/1000)
+
+	_, heldSpan := tracer.Start(ctx, "lock.held")
+	time.Sleep(hold)
+	contendMu.Unlock()
+	heldSpan.End()
+
+	traceSpan(ctx).SetAttributes(
+		attribute.Bool("injected", true),
+		attribute.Int64("chaos.lock_wait_ms", waited.Milliseconds()),
+	)
+	c.JSON(http.StatusOK, gin.H{"waited_ms": waited.Milliseconds(), "held_ms": hold.Milliseconds()})
+}
@@ -0,0 +1,80 @@
+// chaos_cpu.go — GET /chaos/cpu?ms=200&goroutines=4 spins CPU-bound work on
+//   several goroutines, each in its own child span, to show profiling and
+//   the latency impact on concurrently traced requests.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxBurnGoroutines = 64
+
+func chaosCPUHandler(c *gin.Context) {
+	ms, err := strconv.Atoi(c.DefaultQuery("ms", "200"))
+	if err != nil || ms <= 0 || ms > maxSlowMillis {
+		respondError(c, fmt.Errorf("ms must be an integer in (0,%d]", maxSlowMillis), http.StatusBadRequest)
+		return
+	}
+	n, err := strconv.Atoi(c.DefaultQuery("goroutines", "4"))
+	if err != nil || n <= 0 || n > maxBurnGoroutines {
+		respondError(c, fmt.Errorf("goroutines must be an integer in (0,%d]", maxBurnGoroutines), http.StatusBadRequest)
+		return
+	}
+
+	ctx := c.Request.Context()
+	traceSpan(ctx).SetAttributes(
+		attribute.Bool("injected", true),
+		attribute.Int("chaos.cpu_ms", ms),
+		attribute.Int("chaos.cpu_goroutines", n),
+	)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total uint64
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, span := tracer.Start(ctx, "cpu.burn", trace.WithAttributes(attribute.Int("burn.worker", i)))
+			rounds := burnCPU(ctx, time.Duration(ms)*time.Millisecond)
+			span.SetAttributes(attribute.Int64("burn.rounds", int64(rounds)))
+			span.End()
+
+			mu.Lock()
+			total += rounds
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	c.JSON(http.StatusOK, gin.H{"ms": ms, "goroutines": n, "rounds": total})
+}
+
+// burnCPU hashes in a tight loop until d elapses or ctx is done.
+func burnCPU(ctx context.Context, d time.Duration) uint64 {
+	deadline := time.Now().Add(d)
+	var (
+		sum    [32]byte
+		rounds uint64
+	)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		for j := 0; j < 1000; j++ {
+			sum = sha256.Sum256(sum[:])
+		}
+		rounds++
+	}
+	return rounds
+}
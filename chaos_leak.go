@@ -0,0 +1,99 @@
+// chaos_leak.go — goroutine leak simulation:
+//   • GET    /chaos/leak?n=10   spawns n goroutines that block forever
+//   • GET    /chaos/leak/count  reports leaked vs total goroutines
+//   • DELETE /chaos/leak        releases them again (for repeat demos)
+//   plus a chaos.goroutines.leaked gauge for leak-detection alerts.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type goroutineLeak struct {
+	mu      sync.Mutex
+	release chan struct{}
+	count   int64
+}
+
+var leaks = func() *goroutineLeak {
+	l := &goroutineLeak{release: make(chan struct{})}
+	_, _ = meter.Int64ObservableGauge("chaos.goroutines.leaked",
+		metric.WithDescription("Goroutines deliberately leaked by /chaos/leak"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(l.leaked())
+			return nil
+		}))
+	return l
+}()
+
+func (l *goroutineLeak) leaked() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+func (l *goroutineLeak) spawn(n int, limit int64) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if int64(n) > limit-l.count { // l.count+n could overflow
+		return l.count, fmt.Errorf("would exceed CHAOS_LEAK_MAX (%d leaked)", l.count)
+	}
+	release := l.release
+	for i := 0; i < n; i++ {
+		go func() { <-release }()
+	}
+	l.count += int64(n)
+	return l.count, nil
+}
+
+func (l *goroutineLeak) releaseAll() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	close(l.release)
+	l.release = make(chan struct{})
+	n := l.count
+	l.count = 0
+	return n
+}
+
+func registerLeakRoutes(r gin.IRouter) {
+	r.GET("/chaos/leak", func(c *gin.Context) {
+		n, err := strconv.Atoi(c.DefaultQuery("n", "10"))
+		if err != nil || n <= 0 {
+			respondError(c, fmt.Errorf("n must be a positive integer"), http.StatusBadRequest)
+			return
+		}
+		total, err := leaks.spawn(n, int64(envInt("CHAOS_LEAK_MAX", 100_000)))
+		if err != nil {
+			respondError(c, err, http.StatusTooManyRequests)
+			return
+		}
+		traceSpan(c.Request.Context()).SetAttributes(
+			attribute.Bool("injected", true),
+			attribute.Int("chaos.leak_spawned", n),
+			attribute.Int64("chaos.leak_total", total),
+		)
+		c.JSON(http.StatusOK, gin.H{"spawned": n, "leaked": total})
+	})
+
+	r.GET("/chaos/leak/count", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"leaked":     leaks.leaked(),
+			"goroutines": runtime.NumGoroutine(),
+		})
+	})
+
+	r.DELETE("/chaos/leak", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"released": leaks.releaseAll()})
+	})
+}
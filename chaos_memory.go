@@ -0,0 +1,118 @@
+// chaos_memory.go — GET /chaos/memory?mb=100&hold=30s allocates and retains
+//   memory for a while so GC / OOM behaviour can be watched next to traces
+//   and the Go runtime metrics. Capped by CHAOS_MEMORY_MAX_MB in total.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const maxMemoryHold = 10 * time.Minute
+
+type memoryBallast struct {
+	mu    sync.Mutex
+	held  map[int][]byte
+	next  int
+	bytes int64
+}
+
+var ballast = func() *memoryBallast {
+	b := &memoryBallast{held: make(map[int][]byte)}
+	_, _ = meter.Int64ObservableGauge("chaos.memory.retained",
+		metric.WithUnit("By"),
+		metric.WithDescription("Bytes currently held by /chaos/memory"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			o.Observe(b.bytes)
+			return nil
+		}))
+	return b
+}()
+
+// retain allocates mb MiB, touching every page so it is actually resident,
+// and releases it after hold.
+func (b *memoryBallast) retain(mb int, hold time.Duration, limit int64) (int64, error) {
+	if int64(mb) > limit>>20 {
+		return 0, fmt.Errorf("%d MiB exceeds CHAOS_MEMORY_MAX_MB", mb)
+	}
+	size := int64(mb) << 20
+	overLimit := func() error {
+		return fmt.Errorf("would exceed CHAOS_MEMORY_MAX_MB (%d MiB held)", b.bytes>>20)
+	}
+
+	b.mu.Lock()
+	if b.bytes+size > limit {
+		defer b.mu.Unlock()
+		return 0, overLimit()
+	}
+	b.mu.Unlock()
+
+	buf := make([]byte, size)
+	for i := 0; i < len(buf); i += 4096 {
+		buf[i] = 1
+	}
+
+	// counted only once allocated; checked again, as others may have been
+	// allocating meanwhile
+	b.mu.Lock()
+	if b.bytes+size > limit {
+		defer b.mu.Unlock()
+		return 0, overLimit()
+	}
+	b.bytes += size
+	id := b.next
+	b.next++
+	b.held[id] = buf
+	total := b.bytes
+	b.mu.Unlock()
+
+	time.AfterFunc(hold, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.held, id)
+		b.bytes -= size
+	})
+	return total, nil
+}
+
+func chaosMemoryHandler(c *gin.Context) {
+	mb, err := strconv.Atoi(c.DefaultQuery("mb", "100"))
+	if err != nil || mb <= 0 {
+		respondError(c, fmt.Errorf("mb must be a positive integer"), http.StatusBadRequest)
+		return
+	}
+	hold, err := time.ParseDuration(c.DefaultQuery("hold", "30s"))
+	if err != nil || hold <= 0 || hold > maxMemoryHold {
+		respondError(c, fmt.Errorf("hold must be a duration in (0,%s]", maxMemoryHold), http.StatusBadRequest)
+		return
+	}
+
+	total, err := ballast.retain(mb, hold, int64(envInt("CHAOS_MEMORY_MAX_MB", 1024))<<20)
+	if err != nil {
+		respondError(c, err, http.StatusTooManyRequests)
+		return
+	}
+
+	traceSpan(c.Request.Context()).SetAttributes(
+		attribute.Bool("injected", true),
+		attribute.Int("chaos.memory_mb", mb),
+		attribute.String("chaos.memory_hold", hold.String()),
+		attribute.Int64("chaos.memory_retained_mb", total>>20),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"allocated_mb": mb,
+		"retained_mb":  total >> 20,
+		"release_at":   time.Now().Add(hold).UTC(),
+	})
+}
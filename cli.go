@@ -0,0 +1,349 @@
+// cli.go — the binary is a small demo toolkit (cobra):
+//   • app serve runs the server; it is also what a bare `app` does, so
+//     `go run .` and the container keep working
+//   • app seed --file items.json creates items on a running server via
+//     POST /items/bulk, --batch at a time
+//   • app load --rps 20 --duration 1m drives the load generator's traffic
+//     at a server for a fixed time, then prints a latency/error report with
+//     links to sampled traces (loadreport.go)
+//   • app items get|list|create call the API one request at a time
+//     (cli_items.go)
+//   • app bench json compares the built-in JSON codec with encoding/json
+//     (bench.go)
+//   • --config FILE and --set KEY=VALUE feed every command's settings
+//     (config.go); app config shows the result
+//   • client commands share the server's tracer setup but report as
+//     CLI_SERVICE_NAME (otel-crud-cli), so their spans open the traces the
+//     server's spans continue; --target (SELF_URL), --api-key and
+//     --bearer-token say where and as whom
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func main() {
+	if err := newCLI().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// cliClient is what client commands know about the server they talk to.
+type cliClient struct {
+	target, apiKey, bearerToken string
+}
+
+func (cc *cliClient) auth() func(*http.Request) { return staticAuth(cc.apiKey, cc.bearerToken) }
+
+func newCLI() *cobra.Command {
+	var (
+		configFile string
+		sets       []string
+	)
+	root := &cobra.Command{
+		Use:               "app",
+		Short:             "OpenTelemetry CRUD demo: server and client tools",
+		Args:              cobra.NoArgs,
+		SilenceUsage:      true,
+		RunE:              func(*cobra.Command, []string) error { return serve() },
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			sets := slices.Clone(sets)
+			cmd.Flags().Visit(func(f *pflag.Flag) {
+				if key := f.Annotations[configAnnotation]; len(key) == 1 {
+					sets = append(sets, key[0]+"="+f.Value.String())
+				}
+			})
+			c, err := loadConfig(configFile, sets)
+			if err != nil {
+				return err
+			}
+			c.install()
+			return nil
+		},
+	}
+	root.PersistentFlags().StringVar(&configFile, "config", "", "YAML or TOML config file (default: CONFIG_FILE)")
+	root.PersistentFlags().StringArrayVar(&sets, "set", nil, "override any setting, e.g. --set LOADGEN_RPS=20 (repeatable)")
+	configFlag(root.PersistentFlags(), "env", "APP_ENV", "preset of defaults: dev, staging or prod")
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the API server (the default)",
+		Args:  cobra.NoArgs,
+		RunE:  func(*cobra.Command, []string) error { return serve() },
+	}
+	configFlag(serveCmd.Flags(), "listen", "LISTEN", "address to serve on (default HOST:PORT)")
+	configFlag(serveCmd.Flags(), "host", "HOST", "interface to bind when --listen is unset (default all)")
+	configFlag(serveCmd.Flags(), "port", "PORT", "port to bind when --listen is unset (default 8080)")
+	configFlag(serveCmd.Flags(), "base-path", "BASE_PATH", "serve every route under this prefix, e.g. /api")
+	configFlag(serveCmd.Flags(), "sampling-ratio", "SAMPLING_RATIO", "fraction of new traces to sample (default 1)")
+
+	cc := &cliClient{}
+	root.AddCommand(serveCmd, newConfigCommand(), newVersionCommand(), newBenchCommand(),
+		cc.command(newSeedCommand(cc)), cc.command(newLoadCommand(cc)), newItemsCommand(cc))
+	return root
+}
+
+// configAnnotation marks a flag as a shortcut for --set KEY=value.
+const configAnnotation = "config-key"
+
+func configFlag(f *pflag.FlagSet, name, key, usage string) {
+	f.String(name, "", usage+"; shortcut for --set "+key+"=…")
+	_ = f.SetAnnotation(name, configAnnotation, []string{key})
+}
+
+func newConfigCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "config [KEY...]",
+		Short: "Show settings and the layer (flag, env, file) each comes from",
+		Long: `Without arguments, lists what the APP_ENV preset, the config file and --set
+provide (with any environment override applied); otherwise shows the named
+settings.`,
+		RunE: func(cmd *cobra.Command, keys []string) error {
+			if len(keys) == 0 {
+				keys = config.keys()
+			}
+			w := cmd.OutOrStdout()
+			if config.File != "" {
+				fmt.Fprintf(w, "# file: %s\n", config.File)
+			}
+			if config.env != "" {
+				fmt.Fprintf(w, "# preset: %s\n", config.env)
+			}
+			for _, k := range keys {
+				k = configKey(k)
+				if v, source := config.lookup(k); source != "" {
+					fmt.Fprintf(w, "%s=%s\t# %s\n", k, v, source)
+				} else {
+					fmt.Fprintf(w, "%s\t# unset: built-in default\n", k)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the build metadata GET /version serves",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			b := buildInfo()
+			fmt.Fprintf(cmd.OutOrStdout(), "%s commit=%s built=%s %s\n", b.Version, b.Commit, b.BuildDate, b.GoVersion)
+			return nil
+		},
+	}
+}
+
+// command adds the connection flags to a client command and runs it with
+// telemetry set up.
+func (cc *cliClient) command(cmd *cobra.Command) *cobra.Command {
+	f := cmd.Flags()
+	f.StringVar(&cc.target, "target", "", "base URL of the server (default: SELF_URL, else derived from LISTEN)")
+	f.StringVar(&cc.apiKey, "api-key", "", "X-API-Key to send")
+	f.StringVar(&cc.bearerToken, "bearer-token", "", "bearer token to send")
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if cc.target == "" {
+			target, err := selfURL() // only now is the config loaded
+			if err != nil {
+				return fmt.Errorf("--target: %w", err)
+			}
+			cc.target = target
+		}
+		defer initOpenTelemetry(namedResource(envString("CLI_SERVICE_NAME", "otel-crud-cli")))()
+		return run(cmd, args)
+	}
+	return cmd
+}
+
+// cliLogger logs to stderr so stdout stays for command output.
+func cliLogger() *slog.Logger {
+	return newLogger(os.Stderr, false)
+}
+
+/* -------------------------------------------------------------------------- */
+/* seed                                                                       */
+/* -------------------------------------------------------------------------- */
+
+func newSeedCommand(cc *cliClient) *cobra.Command {
+	var (
+		file  string
+		batch int
+	)
+	cmd := &cobra.Command{
+		Use:   "seed --file items.json",
+		Short: "Create items from a JSON file on a running server",
+		Long: `Reads a JSON array of names (["pen","ink"]) or items ([{"name":"pen"}])
+from --file ("-" for stdin) and creates them through POST /items/bulk.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			names, err := readSeedFile(file)
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return seed(ctx, cmd.OutOrStdout(), cc, names, batch)
+		},
+	}
+	cmd.Flags().StringVar(&file, "file", "", `JSON file with the items ("-" for stdin)`)
+	cmd.Flags().IntVar(&batch, "batch", 100, "items per bulk request")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func readSeedFile(path string) ([]string, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if path == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if json.Unmarshal(raw, &names) == nil {
+		return names, nil
+	}
+	var items []struct{ Name string }
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("%s: want a JSON array of names or of {\"name\":…} objects: %w", path, err)
+	}
+	names = make([]string, len(items)) // drop what the first attempt half-decoded
+	for i, it := range items {
+		names[i] = it.Name
+	}
+	return names, nil
+}
+
+// seed creates names in batches under one cli.seed span.
+func seed(ctx context.Context, out io.Writer, cc *cliClient, names []string, batch int) error {
+	if len(names) == 0 {
+		return errors.New("nothing to seed")
+	}
+	batch = max(batch, 1)
+	ctx, span := tracer.Start(ctx, "cli.seed", trace.WithAttributes(
+		attribute.Int("seed.items", len(names)), attribute.Int("seed.batch", batch)))
+	defer span.End()
+
+	created := 0
+	for start := 0; start < len(names); start += batch {
+		chunk := names[start:min(start+batch, len(names))]
+		n, err := seedBatch(ctx, cc, chunk)
+		created += n
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			fmt.Fprintf(out, "seeded %d of %d items before failing (trace %s)\n", created, len(names), span.SpanContext().TraceID())
+			return err
+		}
+	}
+	span.SetAttributes(attribute.Int("seed.created", created))
+	fmt.Fprintf(out, "seeded %d items (trace %s)\n", created, span.SpanContext().TraceID())
+	return nil
+}
+
+// seedBatch posts one bulk request and returns how many items it created.
+func seedBatch(ctx context.Context, cc *cliClient, names []string) (int, error) {
+	body := make([]map[string]string, len(names))
+	for i, n := range names {
+		body[i] = map[string]string{"name": n}
+	}
+	raw, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.target+"/items/bulk", bytes.NewReader(raw))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	cc.auth()(req)
+
+	resp, err := outboundClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Created int
+		Error   string
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	switch {
+	case resp.StatusCode >= 400 && result.Error != "":
+		return 0, fmt.Errorf("POST /items/bulk: %d: %s", resp.StatusCode, result.Error)
+	case resp.StatusCode >= 400:
+		return 0, fmt.Errorf("POST /items/bulk: %s", resp.Status)
+	}
+	return result.Created, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* load                                                                       */
+/* -------------------------------------------------------------------------- */
+
+func newLoadCommand(cc *cliClient) *cobra.Command {
+	g := &loadGenerator{}
+	var (
+		duration    time.Duration
+		concurrency int
+		traceURL    string
+	)
+	cmd := &cobra.Command{
+		Use:   "load --rps 20 --duration 1m",
+		Short: "Send generated traffic to a running server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			g.target, g.auth = cc.target, cc.auth()
+			g.concurrency = make(chan struct{}, max(1, concurrency))
+			if err := g.validate(); err != nil {
+				return err
+			}
+			if duration <= 0 {
+				return errors.New("--duration must be positive")
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			ctx, cancel := context.WithTimeout(ctx, duration)
+			defer cancel()
+			if traceURL == "" {
+				traceURL = envString("TRACE_URL", "")
+			}
+			g.report = newLoadReport()
+			g.run(ctx, cliLogger())
+			g.report.print(cmd.OutOrStdout(), time.Since(g.start), traceURL)
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.Float64Var(&g.rps, "rps", 5, "peak requests per second")
+	f.DurationVar(&duration, "duration", time.Minute, "how long to run")
+	f.StringVar(&g.profile, "profile", "steady", "steady|spike|ramp|diurnal")
+	f.DurationVar(&g.period, "period", time.Minute, "profile cycle length")
+	f.IntVar(&concurrency, "concurrency", 32, "maximum requests in flight")
+	f.StringVar(&traceURL, "trace-url", "", "link template for sampled traces, with {trace_id} (default: TRACE_URL)")
+	return cmd
+}
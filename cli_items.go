@@ -0,0 +1,128 @@
+// cli_items.go — app items get|list|create, one API call per run through
+//   the Go client (client/):
+//   • each run is a cli.items.<op> span; its trace ID goes to stderr, the
+//     JSON answer to stdout
+//   • --traceparent makes that span a child of an existing trace (say, the
+//     one in a ticket), so the reproduction shows up next to the original
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/micro-company/http-trace-example/client"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newItemsCommand(cc *cliClient) *cobra.Command {
+	var traceparent, tracestate string
+	cmd := &cobra.Command{
+		Use:   "items",
+		Short: "Call the items API on a running server",
+	}
+	cmd.PersistentFlags().StringVar(&traceparent, "traceparent", "", "W3C traceparent to continue instead of starting a trace")
+	cmd.PersistentFlags().StringVar(&tracestate, "tracestate", "", "W3C tracestate to go with --traceparent")
+
+	// run calls fn under a cli.items.<op> span and prints its result.
+	run := func(cmd *cobra.Command, op string, fn func(context.Context, *client.ItemsClient) (any, error)) error {
+		ctx, err := remoteParent(cmd.Context(), traceparent, tracestate)
+		if err != nil {
+			return err
+		}
+		c, err := client.New(cc.target, client.Options{APIKey: cc.apiKey, BearerToken: cc.bearerToken})
+		if err != nil {
+			return err
+		}
+		ctx, span := tracer.Start(ctx, "cli.items."+op, trace.WithAttributes(attribute.String("cli.target", cc.target)))
+		defer span.End()
+		defer fmt.Fprintf(cmd.ErrOrStderr(), "trace %s\n", span.SpanContext().TraceID())
+
+		out, err := fn(ctx, c)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	get := &cobra.Command{
+		Use:   "get ID",
+		Short: "Fetch one item",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("item ID %q: %w", args[0], err)
+			}
+			return run(cmd, "get", func(ctx context.Context, c *client.ItemsClient) (any, error) {
+				trace.SpanFromContext(ctx).SetAttributes(attribute.Int("item.id", id))
+				return c.Get(ctx, id)
+			})
+		},
+	}
+
+	var page client.PageOptions
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List items, or one page of them with --limit/--offset",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			paged := cmd.Flags().Changed("limit") || cmd.Flags().Changed("offset")
+			return run(cmd, "list", func(ctx context.Context, c *client.ItemsClient) (any, error) {
+				if !paged {
+					return c.List(ctx)
+				}
+				p, err := c.ListPage(ctx, page)
+				return p.Items, err
+			})
+		},
+	}
+	list.Flags().IntVar(&page.Limit, "limit", 0, "page size")
+	list.Flags().IntVar(&page.Offset, "offset", 0, "items to skip")
+
+	create := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create an item",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, "create", func(ctx context.Context, c *client.ItemsClient) (any, error) {
+				return c.Create(ctx, args[0])
+			})
+		},
+	}
+
+	cmd.AddCommand(cc.command(get), cc.command(list), cc.command(create))
+	return cmd
+}
+
+// remoteParent puts the span context described by traceparent/tracestate
+// into ctx; an empty traceparent leaves ctx alone.
+func remoteParent(ctx context.Context, traceparent, tracestate string) (context.Context, error) {
+	if traceparent == "" {
+		return ctx, nil
+	}
+	ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{
+		"traceparent": traceparent,
+		"tracestate":  tracestate,
+	})
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ctx, fmt.Errorf("--traceparent %q is not a valid W3C traceparent", traceparent)
+	}
+	if !sc.IsSampled() {
+		// the sampler is parent-based: nothing from this run would be exported
+		fmt.Fprintln(os.Stderr, "warning: --traceparent is not sampled (flags 00); no spans will be exported")
+	}
+	return ctx, nil
+}
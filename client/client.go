@@ -0,0 +1,237 @@
+// Package client is a Go SDK for the example's /items API:
+//   - every call is an ItemsClient.<Op> span; below it the shared retrying
+//     client adds http.client.call and otelhttp one client span per attempt,
+//     with traceparent injected, so SDK calls join the server's traces
+//   - transient failures (network errors, 429, 502, 503, 504) are retried
+//     with backoff, honoring Retry-After, except for Create: a POST is sent
+//     once, since a failed attempt may still have created the item
+//   - non-2xx answers become *APIError, which matches ErrNotFound and
+//     friends with errors.Is and carries the server's request ID
+//   - ListPage and All walk GET /items page by page (see pages.go)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micro-company/http-trace-example/internal/httpclient"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const scope = "github.com/micro-company/http-trace-example/client"
+
+// Item mirrors the server's item.
+type Item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Options configure an ItemsClient; zero values pick the defaults noted.
+type Options struct {
+	Transport      http.RoundTripper    // http.DefaultTransport, wrapped by otelhttp
+	TracerProvider trace.TracerProvider // the global one
+	Timeout        time.Duration        // per attempt; 0 = none
+	MaxAttempts    int                  // 1 disables retries; default 3
+	APIKey         string               // sent as X-API-Key
+	BearerToken    string               // sent as Authorization: Bearer
+}
+
+// ItemsClient calls the /items API. It is safe for concurrent use.
+type ItemsClient struct {
+	base   *url.URL
+	http   *httpclient.Client
+	opts   Options
+	tracer trace.Tracer
+}
+
+// New returns a client for the server at baseURL (e.g.
+// "http://localhost:8080").
+func New(baseURL string, opts Options) (*ItemsClient, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("client: base URL %q must be http or https", baseURL)
+	}
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+	return &ItemsClient{
+		base: base,
+		http: httpclient.New(httpclient.Options{
+			Transport:   otelhttp.NewTransport(opts.Transport, otelhttp.WithTracerProvider(opts.TracerProvider)),
+			Timeout:     opts.Timeout,
+			MaxAttempts: opts.MaxAttempts,
+		}),
+		opts:   opts,
+		tracer: opts.TracerProvider.Tracer(scope),
+	}, nil
+}
+
+// Create adds an item named name.
+func (c *ItemsClient) Create(ctx context.Context, name string) (Item, error) {
+	var item Item
+	err := c.call(ctx, "Create", http.MethodPost, "/items", nil, map[string]string{"name": name}, &item)
+	return item, err
+}
+
+// Get fetches one item; a missing one is an error matching ErrNotFound.
+func (c *ItemsClient) Get(ctx context.Context, id int) (Item, error) {
+	var item Item
+	err := c.call(ctx, "Get", http.MethodGet, "/items/"+strconv.Itoa(id), nil, nil, &item)
+	return item, err
+}
+
+// List fetches every item, a page of DefaultPageSize at a time (a bare
+// GET /items is only the server's first page); All streams them instead.
+func (c *ItemsClient) List(ctx context.Context) ([]Item, error) {
+	items := []Item{}
+	for it, err := range c.All(ctx, 0) {
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// Update renames an item.
+func (c *ItemsClient) Update(ctx context.Context, id int, name string) (Item, error) {
+	var item Item
+	err := c.call(ctx, "Update", http.MethodPut, "/items/"+strconv.Itoa(id), nil, map[string]string{"name": name}, &item)
+	return item, err
+}
+
+// Delete removes an item.
+func (c *ItemsClient) Delete(ctx context.Context, id int) error {
+	return c.call(ctx, "Delete", http.MethodDelete, "/items/"+strconv.Itoa(id), nil, nil, nil)
+}
+
+// call runs one operation under an ItemsClient.<op> span, decoding a 2xx
+// body into out (if non-nil) and anything else into an *APIError.
+func (c *ItemsClient) call(ctx context.Context, op, method, path string, query url.Values, in, out any) error {
+	ctx, span := c.tracer.Start(ctx, "ItemsClient."+op, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", method), attribute.String("url.path", path)))
+	defer span.End()
+
+	_, err := c.do(ctx, method, path, query, in, out)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// do sends the request and returns the response headers.
+func (c *ItemsClient) do(ctx context.Context, method, path string, query url.Values, in, out any) (http.Header, error) {
+	u := *c.base
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b) // replayable: NewRequest sets GetBody
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.opts.APIKey != "" {
+		req.Header.Set("X-API-Key", c.opts.APIKey)
+	}
+	if c.opts.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.BearerToken)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp.Header, newAPIError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.Header, fmt.Errorf("client: decoding %s %s: %w", method, path, err)
+	}
+	return resp.Header, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* Errors                                                                     */
+/* -------------------------------------------------------------------------- */
+
+// Errors an *APIError matches with errors.Is, by status.
+var (
+	ErrNotFound     = errors.New("not found")           // 404
+	ErrUnauthorized = errors.New("unauthorized")        // 401, 403
+	ErrRateLimited  = errors.New("rate limited")        // 429
+	ErrUnavailable  = errors.New("service unavailable") // 503
+)
+
+// APIError is a non-2xx answer from the server.
+type APIError struct {
+	StatusCode int
+	Message    string // the server's "error" field, or the status text
+	RequestID  string // X-Request-ID, for finding the server-side trace
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("client: server returned %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body) == nil && body.Error != "" {
+		e.Message = body.Error
+	} else {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
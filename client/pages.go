@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultPageSize is the page size used when PageOptions.Limit is zero.
+const DefaultPageSize = 100
+
+// PageOptions select a page of GET /items (ordered by id).
+type PageOptions struct {
+	Limit  int // DefaultPageSize when zero
+	Offset int
+}
+
+// Page is one page of items.
+type Page struct {
+	Items []Item
+	Total int          // items in the whole list (X-Total-Count)
+	Next  *PageOptions // the following page; nil on the last one
+}
+
+// ListPage fetches one page.
+func (c *ItemsClient) ListPage(ctx context.Context, opts PageOptions) (Page, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultPageSize
+	}
+	ctx, span := c.tracer.Start(ctx, "ItemsClient.ListPage", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("pagination.limit", opts.Limit), attribute.Int("pagination.offset", opts.Offset)))
+	defer span.End()
+
+	var page Page
+	q := url.Values{"limit": {strconv.Itoa(opts.Limit)}, "offset": {strconv.Itoa(opts.Offset)}}
+	h, err := c.do(ctx, http.MethodGet, "/items", q, nil, &page.Items)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return Page{}, err
+	}
+	page.Total, _ = strconv.Atoi(h.Get("X-Total-Count"))
+	page.Next = nextPage(h.Get("Link"))
+	span.SetAttributes(attribute.Int("pagination.items", len(page.Items)), attribute.Bool("pagination.last", page.Next == nil))
+	return page, nil
+}
+
+// All yields every item, fetching pageSize (DefaultPageSize when zero) at a
+// time; iteration stops after the first error, which is yielded.
+func (c *ItemsClient) All(ctx context.Context, pageSize int) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		next := &PageOptions{Limit: pageSize}
+		for next != nil {
+			page, err := c.ListPage(ctx, *next)
+			if err != nil {
+				yield(Item{}, err)
+				return
+			}
+			for _, it := range page.Items {
+				if !yield(it, nil) {
+					return
+				}
+			}
+			next = page.Next
+		}
+	}
+}
+
+// nextPage reads the rel="next" target of a Link header.
+func nextPage(link string) *PageOptions {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return nil
+		}
+		limit, err1 := strconv.Atoi(u.Query().Get("limit"))
+		offset, err2 := strconv.Atoi(u.Query().Get("offset"))
+		if err1 != nil || err2 != nil {
+			return nil
+		}
+		return &PageOptions{Limit: limit, Offset: offset}
+	}
+	return nil
+}
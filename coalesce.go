@@ -0,0 +1,110 @@
+// coalesce.go — concurrent reads of one item share a single store call
+//   (singleflight), so a hot item doesn't send a herd of identical queries
+//   to the database; COALESCE_READS=false turns it off:
+//   • the first Get for an ID runs the query; the others arriving while it
+//     is in flight wait for its answer, error included
+//   • waiters get coalesced=true on their span and a link to the span of
+//     the request that ran the query, whose trace holds the db.query span
+//   • the query runs detached from the first caller's cancellation, so a
+//     client going away doesn't fail the others; each caller still stops
+//     waiting when its own context ends
+//   • a write forgets the flight for its item: a read that starts after
+//     the write never gets a value read before it; that holds for creates
+//     (a flight that found no item yet) and for the outbox's rollbacks,
+//     which write around the store chain and call forgetRead's func
+//   • items.reads.coalesced counts the reads that waited
+
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+var itemReadsCoalesced, _ = meter.Int64Counter("items.reads.coalesced",
+	metric.WithDescription("Item reads served by another request's store call"))
+
+type coalescingStore struct {
+	itemStore
+	reads *singleflight.Group
+}
+
+// coalescedRead is what a flight hands to everyone waiting on it.
+type coalescedRead struct {
+	item   Item
+	leader trace.SpanContext
+}
+
+// newCoalescingStore wraps next unless COALESCE_READS is false.
+func newCoalescingStore(next itemStore) itemStore {
+	if !envBool("COALESCE_READS", true) {
+		return next
+	}
+	return coalescingStore{itemStore: next, reads: &singleflight.Group{}}
+}
+
+func (s coalescingStore) Get(ctx context.Context, id int) (Item, error) {
+	ran := false // set by the flight only if this call started it
+	ch := s.reads.DoChan(strconv.Itoa(id), func() (any, error) {
+		ran = true
+		item, err := s.itemStore.Get(context.WithoutCancel(ctx), id)
+		return coalescedRead{item, trace.SpanContextFromContext(ctx)}, err
+	})
+	select {
+	case <-ctx.Done():
+		return Item{}, ctx.Err()
+	case res := <-ch:
+		if !ran {
+			read := res.Val.(coalescedRead)
+			span := trace.SpanFromContext(ctx)
+			span.SetAttributes(attribute.Bool("coalesced", true))
+			span.AddLink(trace.Link{SpanContext: read.leader})
+			itemReadsCoalesced.Add(context.WithoutCancel(ctx), 1)
+		}
+		return res.Val.(coalescedRead).item, res.Err
+	}
+}
+
+func (s coalescingStore) Create(ctx context.Context, name string) (Item, error) {
+	item, err := s.itemStore.Create(ctx, name)
+	if err == nil {
+		s.forget(item.ID)
+	}
+	return item, err
+}
+
+func (s coalescingStore) Update(ctx context.Context, id int, fn func(Item) (Item, error)) (Item, error) {
+	item, err := s.itemStore.Update(ctx, id, fn)
+	s.forget(id)
+	return item, err
+}
+
+func (s coalescingStore) Delete(ctx context.Context, id int) error {
+	err := s.itemStore.Delete(ctx, id)
+	s.forget(id)
+	return err
+}
+
+func (s coalescingStore) forget(id int) { s.reads.Forget(strconv.Itoa(id)) }
+
+// forgetRead returns what makes store's layers drop what they remember of
+// an item written past them: the Redis read cache (which broadcasts it)
+// and the coalesced flight.
+func forgetRead(store itemStore) func(ctx context.Context, id int) {
+	switch s := store.(type) {
+	case cachingStore:
+		inner := forgetRead(s.itemStore)
+		return func(ctx context.Context, id int) {
+			inner(ctx, id)
+			s.reverted(ctx, id)
+		}
+	case coalescingStore:
+		return func(_ context.Context, id int) { s.forget(id) }
+	}
+	return func(context.Context, int) {}
+}
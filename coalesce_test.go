@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// gatedStore holds its first Get, after the read, until release is closed.
+type gatedStore struct {
+	itemStore
+	read    chan struct{} // closed once the first Get has its value
+	release chan struct{}
+	gets    atomic.Int32
+}
+
+func (s *gatedStore) Get(ctx context.Context, id int) (Item, error) {
+	item, err := s.itemStore.Get(ctx, id)
+	if s.gets.Add(1) == 1 {
+		close(s.read)
+		<-s.release
+	}
+	return item, err
+}
+
+type readResult struct {
+	item Item
+	err  error
+}
+
+// A read that starts after a write must not be handed what a flight begun
+// before the write read.
+func TestCoalescingStoreForget(t *testing.T) {
+	tests := []struct {
+		name  string
+		seed  bool // item 1 exists beforehand, named "v1"
+		write func(ctx context.Context, s coalescingStore, raw *memoryStore) error
+		want  string // what a read after the write sees; "" means not found
+	}{
+		{
+			name: "update",
+			seed: true,
+			write: func(ctx context.Context, s coalescingStore, _ *memoryStore) error {
+				_, err := s.Update(ctx, 1, func(it Item) (Item, error) { it.Name = "v2"; return it, nil })
+				return err
+			},
+			want: "v2",
+		},
+		{
+			name:  "delete",
+			seed:  true,
+			write: func(ctx context.Context, s coalescingStore, _ *memoryStore) error { return s.Delete(ctx, 1) },
+		},
+		{
+			name: "create after a read found nothing",
+			write: func(ctx context.Context, s coalescingStore, _ *memoryStore) error {
+				_, err := s.Create(ctx, "v2")
+				return err
+			},
+			want: "v2",
+		},
+		{
+			name: "write past the store, then forgetRead",
+			seed: true,
+			write: func(ctx context.Context, s coalescingStore, raw *memoryStore) error {
+				err := raw.Put(ctx, Item{ID: 1, Name: "v2"})
+				forgetRead(s)(ctx, 1)
+				return err
+			},
+			want: "v2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			raw := newMemoryStore()
+			if tt.seed {
+				_, _ = raw.Create(ctx, "v1")
+			}
+			gate := &gatedStore{itemStore: raw, read: make(chan struct{}), release: make(chan struct{})}
+			s := coalescingStore{itemStore: gate, reads: &singleflight.Group{}}
+
+			first := make(chan readResult, 1)
+			go func() {
+				item, err := s.Get(ctx, 1)
+				first <- readResult{item, err}
+			}()
+			<-gate.read
+			if err := tt.write(ctx, s, raw); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			second := make(chan readResult, 1)
+			go func() {
+				item, err := s.Get(ctx, 1)
+				second <- readResult{item, err}
+			}()
+			select {
+			case got := <-second:
+				if tt.want == "" && !errors.Is(got.err, errNotFound) {
+					t.Errorf("read after the write = %+v, %v; want not found", got.item, got.err)
+				}
+				if tt.want != "" && (got.err != nil || got.item.Name != tt.want) {
+					t.Errorf("read after the write = %+v, %v; want %q", got.item, got.err, tt.want)
+				}
+			case <-time.After(2 * time.Second):
+				t.Error("read after the write joined the flight started before it")
+			}
+			close(gate.release)
+			<-first
+		})
+	}
+}
+
+// Without a write in between, a second read waits for the first's answer.
+func TestCoalescingStoreJoinsFlight(t *testing.T) {
+	ctx := context.Background()
+	raw := newMemoryStore()
+	_, _ = raw.Create(ctx, "v1")
+	gate := &gatedStore{itemStore: raw, read: make(chan struct{}), release: make(chan struct{})}
+	s := coalescingStore{itemStore: gate, reads: &singleflight.Group{}}
+
+	first := make(chan readResult, 1)
+	go func() {
+		item, err := s.Get(ctx, 1)
+		first <- readResult{item, err}
+	}()
+	<-gate.read
+	second := make(chan readResult, 1)
+	go func() {
+		item, err := s.Get(ctx, 1)
+		second <- readResult{item, err}
+	}()
+	select {
+	case got := <-second:
+		t.Fatalf("second read returned %+v, %v while the first was in flight", got.item, got.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(gate.release)
+	for _, ch := range []chan readResult{first, second} {
+		if got := <-ch; got.err != nil || got.item.Name != "v1" {
+			t.Errorf("read = %+v, %v; want v1", got.item, got.err)
+		}
+	}
+	if n := gate.gets.Load(); n != 1 {
+		t.Errorf("store saw %d Gets, want 1", n)
+	}
+}
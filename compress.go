@@ -0,0 +1,214 @@
+// compress.go — gzip/deflate response compression:
+//   • negotiated from Accept-Encoding (gzip preferred)
+//   • COMPRESS_LEVEL (flate levels, 0 disables) & COMPRESS_MIN_SIZE
+//   • already-compressed content types and event streams pass through
+//     untouched
+//   • compression ratio recorded on the server span
+
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+/* -------------------------------------------------------------------------- */
+/* Settings                                                                   */
+/* -------------------------------------------------------------------------- */
+
+// incompressibleTypes are media-type prefixes that are already compressed.
+var incompressibleTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/zstd", "application/x-bzip2", "application/x-7z-compressed",
+	"application/pdf", "application/wasm",
+	"text/event-stream", // must reach the client event by event
+}
+
+func compressible(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	if strings.HasPrefix(ct, "image/svg") {
+		return true
+	}
+	for _, p := range incompressibleTypes {
+		if strings.HasPrefix(ct, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// negotiateEncoding picks gzip or deflate from Accept-Encoding ("" = none).
+func negotiateEncoding(accept string) string {
+	var deflate bool
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip", "*":
+			return "gzip"
+		case "deflate":
+			deflate = true
+		}
+	}
+	if deflate {
+		return "deflate"
+	}
+	return ""
+}
+
+/* -------------------------------------------------------------------------- */
+/* Middleware                                                                 */
+/* -------------------------------------------------------------------------- */
+
+func compression(level, minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enc := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if enc == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		orig := c.Writer
+		cw := &compressWriter{ResponseWriter: orig, encoding: enc, level: level, minSize: minSize}
+		c.Writer = cw
+		defer func() { c.Writer = orig }()
+
+		c.Next()
+		cw.finish()
+
+		if cw.zw != nil && cw.rawBytes > 0 {
+			span := traceSpan(c.Request.Context())
+			span.SetAttributes(
+				attribute.String("http.response.content_encoding", enc),
+				attribute.Int64("http.response.uncompressed_size", cw.rawBytes),
+				attribute.Int64("http.response.compressed_size", cw.out.n),
+				attribute.Float64("http.response.compression_ratio",
+					float64(cw.out.n)/float64(cw.rawBytes)),
+			)
+		}
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/* Response writer                                                            */
+/* -------------------------------------------------------------------------- */
+
+// compressWriter buffers up to minSize bytes before deciding whether the
+// response is worth compressing; small or incompressible bodies go out as-is.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	level    int
+	minSize  int
+
+	buf      bytes.Buffer
+	zw       io.WriteCloser // nil until compression starts
+	out      countingWriter
+	bypass   bool
+	rawBytes int64
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	w.rawBytes += int64(len(p))
+	switch {
+	case w.bypass:
+		return w.ResponseWriter.Write(p)
+	case w.zw != nil:
+		return w.zw.Write(p)
+	}
+
+	w.buf.Write(p)
+	if w.buf.Len() < w.minSize {
+		return len(p), nil
+	}
+	if err := w.start(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// start decides between compressing and passing through, then drains buf.
+func (w *compressWriter) start() error {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || !compressible(h.Get("Content-Type")) {
+		w.bypass = true
+	} else {
+		w.out.w = w.ResponseWriter
+		if w.encoding == "gzip" {
+			zw, err := gzip.NewWriterLevel(&w.out, w.level)
+			if err != nil {
+				return err
+			}
+			w.zw = zw
+		} else {
+			zw, err := flate.NewWriter(&w.out, w.level)
+			if err != nil {
+				return err
+			}
+			w.zw = zw
+		}
+		h.Set("Content-Encoding", w.encoding)
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+	}
+
+	data := w.buf.Bytes()
+	w.buf = bytes.Buffer{}
+	if w.bypass {
+		_, err := w.ResponseWriter.Write(data)
+		return err
+	}
+	_, err := w.zw.Write(data)
+	return err
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (w *compressWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// finish flushes whatever is still buffered and closes the compressor.
+func (w *compressWriter) finish() {
+	switch {
+	case w.zw != nil:
+		_ = w.zw.Close()
+	case !w.bypass && w.buf.Len() > 0:
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
+
+func (w *compressWriter) Flush() {
+	if w.zw == nil && !w.bypass && w.buf.Len() > 0 {
+		_ = w.start()
+	}
+	if f, ok := w.zw.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
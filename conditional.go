@@ -0,0 +1,48 @@
+// conditional.go — timestamp preconditions on single items:
+//   • GET, POST and PUT answers carry Last-Modified, the item's updated_at
+//   • PUT /items/:id with If-Unmodified-Since gets 412 Precondition Failed
+//     when the item changed after that time; the check runs inside the
+//     store's Update, against the value being replaced; HTTP dates have whole
+//     seconds, so updated_at is compared truncated to the second, and an
+//     unparsable date is ignored as RFC 9110 asks, as is an item without
+//     updated_at
+//   • a refusal counts as outcome=precondition_failed in items.store.duration
+//   • a refusal adds a precondition.failed event to the server span
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errPreconditionFailed = errors.New("item was modified since If-Unmodified-Since")
+
+func setLastModified(c *gin.Context, item Item) {
+	if item.UpdatedAt != nil {
+		c.Header("Last-Modified", item.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// unmodifiedSince checks If-Unmodified-Since against item, returning
+// errPreconditionFailed if it changed since.
+func unmodifiedSince(c *gin.Context, item Item) error {
+	header := c.GetHeader("If-Unmodified-Since")
+	if header == "" || item.UpdatedAt == nil {
+		return nil
+	}
+	since, err := http.ParseTime(header)
+	if err != nil || !item.UpdatedAt.Truncate(time.Second).After(since) {
+		return nil
+	}
+	traceSpan(c.Request.Context()).AddEvent("precondition.failed", trace.WithAttributes(
+		attribute.String("http.request.header.if_unmodified_since", header),
+		attribute.String("item.updated_at", item.UpdatedAt.Format(time.RFC3339Nano)),
+	))
+	return errPreconditionFailed
+}
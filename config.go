@@ -0,0 +1,191 @@
+// config.go — one place every setting is read from, lowest precedence first:
+//   1. the default in code (the Configuration table in the README), or the
+//      APP_ENV preset's (presets.go)
+//   2. the config file: --config or CONFIG_FILE, YAML (.yaml/.yml) or TOML
+//      (.toml); keys are the variable names in any case, nested tables are
+//      joined with "_" (`loadgen: {rps: 20}` sets LOADGEN_RPS) and lists
+//      become comma-separated values
+//   3. environment variables
+//   4. flags: --set KEY=VALUE (repeatable) and shortcuts such as
+//      `serve --listen`
+//   an empty value counts as unset at every layer. The env* helpers read
+//   through config, so sampling, chaos, store and every other knob can come
+//   from any layer; SIGHUP re-reads the file along with RUNTIME_CONFIG.
+//   `app config KEY…` shows where each value comes from.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the file and flag layers over the environment.
+type Config struct {
+	File string // the config file, "" for none
+
+	mu     sync.RWMutex
+	file   map[string]string // keyed by variable name
+	flags  map[string]string
+	env    string // APP_ENV, "" for no preset
+	preset map[string]string
+}
+
+// config is what the env* helpers consult; until the CLI has loaded one it
+// holds no layers, leaving just the environment and defaults.
+var config = &Config{}
+
+// loadConfig reads path (CONFIG_FILE when empty) and the --set pairs.
+func loadConfig(path string, sets []string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	c := &Config{File: path, flags: map[string]string{}}
+	for _, kv := range sets {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("--set %q: want KEY=VALUE", kv)
+		}
+		c.flags[configKey(k)] = v
+	}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// reload re-reads the config file and picks the APP_ENV preset, keeping
+// the old values on error.
+func (c *Config) reload() error {
+	file, err := c.readFile()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	env := c.flags["APP_ENV"]
+	if env == "" {
+		env = os.Getenv("APP_ENV")
+	}
+	if env == "" {
+		env = file["APP_ENV"]
+	}
+	p, err := preset(env)
+	if err != nil {
+		return err
+	}
+	c.file, c.env, c.preset = file, env, p
+	return nil
+}
+
+func (c *Config) readFile() (map[string]string, error) {
+	if c.File == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(c.File)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(c.File)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &doc)
+	case ".toml":
+		err = toml.Unmarshal(raw, &doc)
+	default:
+		return nil, fmt.Errorf("config file %s: want .yaml, .yml or .toml", c.File)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", c.File, err)
+	}
+	file := map[string]string{}
+	flattenConfig("", doc, file)
+	return file, nil
+}
+
+// flattenConfig turns nested tables into KEY_SUBKEY entries.
+func flattenConfig(prefix string, v any, out map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			if prefix != "" {
+				k = prefix + "_" + k
+			}
+			flattenConfig(k, x, out)
+		}
+	case []any:
+		parts := make([]string, len(v))
+		for i, x := range v {
+			parts[i] = fmt.Sprint(x)
+		}
+		out[configKey(prefix)] = strings.Join(parts, ",")
+	case nil:
+	default:
+		out[configKey(prefix)] = fmt.Sprint(v)
+	}
+}
+
+// configKey normalizes "loadgen.rps" or "loadgen-rps" to LOADGEN_RPS.
+func configKey(k string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(strings.TrimSpace(k)))
+}
+
+// lookup returns key's value and the layer it came from.
+func (c *Config) lookup(key string) (value, source string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if v := c.flags[key]; v != "" {
+		return v, "flag"
+	}
+	if v := os.Getenv(key); v != "" {
+		return v, "env"
+	}
+	if v := c.file[key]; v != "" {
+		return v, "file"
+	}
+	if v := c.preset[key]; v != "" {
+		return v, "preset:" + c.env
+	}
+	return "", ""
+}
+
+func (c *Config) get(key string) string {
+	v, _ := c.lookup(key)
+	return v
+}
+
+// keys lists the variables the preset, file and flags set.
+func (c *Config) keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var out []string
+	for k := range c.file {
+		out = append(out, k)
+	}
+	for k := range c.flags {
+		out = append(out, k)
+	}
+	for k := range c.preset {
+		out = append(out, k)
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// install makes c current and rebuilds what captured settings while the
+// package was initialized.
+func (c *Config) install() {
+	config = c
+	initOutbound()
+	webhooks = newWebhookRegistry()
+	latencyHeader = newLatencyHeaderPolicy()
+	initMaintenance()
+	slo = newSLOTracker()
+}
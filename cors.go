@@ -0,0 +1,71 @@
+// cors.go — CORS for browser clients, off unless CORS=true:
+//   • CORS_ORIGINS (default "*") lists the allowed origins
+//   • preflights are answered directly (204) with CORS_MAX_AGE
+//   • traceparent/tracestate are allowed in, so a browser-side OTel SDK
+//     can continue its trace here, and X-Request-ID and the paging
+//     headers are exposed back
+
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	corsAllowHeaders = []string{
+		"Content-Type", "Authorization", "X-API-Key",
+		headerRequestID, "traceparent", "tracestate",
+	}
+	corsExposeHeaders = []string{
+		headerRequestID, "Retry-After",
+		"X-Total-Count", "Link", headerPageMaxLimit, headerPageDefaultLimit,
+	}
+)
+
+func cors() gin.HandlerFunc {
+	origins := envList("CORS_ORIGINS")
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+	anyOrigin := slices.Contains(origins, "*")
+	maxAge := strconv.Itoa(int(envDuration("CORS_MAX_AGE", 10*time.Minute).Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		allowed := anyOrigin || slices.Contains(origins, origin)
+		traceSpan(c.Request.Context()).SetAttributes(
+			attribute.String("http.request.header.origin", origin),
+			attribute.Bool("cors.allowed", allowed),
+		)
+		if !allowed {
+			// no CORS headers: the browser refuses to hand over the response
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", strings.Join(corsExposeHeaders, ", "))
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", strings.Join(corsAllowHeaders, ", "))
+			h.Set("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
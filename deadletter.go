@@ -0,0 +1,180 @@
+// deadletter.go — where async work goes once its retries are used up:
+//   • webhook deliveries that still fail, and consumed item events that
+//     cannot be decoded or keep failing (EVENTS_MAX_ATTEMPTS), become dead
+//     letters; the newest DEADLETTER_MAX (1000) are kept in memory
+//   • GET /admin/deadletters[?source=] lists them, GET …/:id shows one,
+//     POST …/:id/replay runs the work again and DELETE …/:id discards it
+//   • a replay runs under a deadletter.replay span linked to the span that
+//     gave up, and the letter records which trace did so
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type deadLetter struct {
+	ID         string          `json:"id"`
+	Source     string          `json:"source"` // webhook|kafka|amqp
+	Reason     string          `json:"reason"`
+	Attempts   int             `json:"attempts"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	TraceID    string          `json:"trace_id"`
+	FailedAt   time.Time       `json:"failed_at"`
+	Replays    int             `json:"replays,omitempty"`
+	LastReplay *time.Time      `json:"last_replay,omitempty"`
+
+	failed trace.SpanContext
+	replay func(ctx context.Context) error
+}
+
+var (
+	deadLettersMu sync.Mutex
+	deadLetters   []*deadLetter // oldest first
+
+	deadLettered, _ = meter.Int64Counter("deadletters",
+		metric.WithDescription("Work moved to the dead-letter store, by source"))
+	deadLetterReplays, _ = meter.Int64Counter("deadletter.replays",
+		metric.WithDescription("Dead-letter replays by source and result"))
+)
+
+// addDeadLetter stores failed work; replay redoes it. ctx is the span that
+// gave up, which gets a deadletter event.
+func addDeadLetter(ctx context.Context, source string, cause error, attempts int, payload []byte, replay func(ctx context.Context) error) {
+	sc := trace.SpanContextFromContext(ctx)
+	d := &deadLetter{
+		ID:       uuid.NewString(),
+		Source:   source,
+		Reason:   cause.Error(),
+		Attempts: attempts,
+		TraceID:  sc.TraceID().String(),
+		FailedAt: time.Now().UTC(),
+		failed:   sc,
+		replay:   replay,
+	}
+	if json.Valid(payload) {
+		d.Payload = payload
+	} else if payload != nil {
+		d.Payload, _ = json.Marshal(string(payload))
+	}
+
+	deadLettersMu.Lock()
+	deadLetters = append(deadLetters, d)
+	if n := len(deadLetters) - envInt("DEADLETTER_MAX", 1000); n > 0 {
+		deadLetters = deadLetters[n:]
+	}
+	deadLettersMu.Unlock()
+
+	trace.SpanFromContext(ctx).AddEvent("deadletter", trace.WithAttributes(attribute.String("deadletter.id", d.ID)))
+	deadLettered.Add(ctx, 1, metric.WithAttributes(attribute.String("source", source)))
+}
+
+func findDeadLetter(id string) (*deadLetter, int) {
+	i := slices.IndexFunc(deadLetters, func(d *deadLetter) bool { return d.ID == id })
+	if i < 0 {
+		return nil, -1
+	}
+	return deadLetters[i], i
+}
+
+func registerDeadLetterAdmin(r gin.IRouter) {
+	r.GET("/admin/deadletters", func(c *gin.Context) {
+		source := c.Query("source")
+		deadLettersMu.Lock()
+		out := make([]deadLetter, 0, len(deadLetters))
+		for _, d := range slices.Backward(deadLetters) {
+			if source == "" || d.Source == source {
+				out = append(out, *d)
+			}
+		}
+		deadLettersMu.Unlock()
+		c.JSON(http.StatusOK, out)
+	})
+	r.GET("/admin/deadletters/:id", func(c *gin.Context) {
+		deadLettersMu.Lock()
+		d, _ := findDeadLetter(c.Param("id"))
+		var out deadLetter
+		if d != nil {
+			out = *d
+		}
+		deadLettersMu.Unlock()
+		if d == nil {
+			respondError(c, errNotFound, http.StatusNotFound)
+			return
+		}
+		c.JSON(http.StatusOK, out)
+	})
+	r.DELETE("/admin/deadletters/:id", func(c *gin.Context) {
+		deadLettersMu.Lock()
+		_, i := findDeadLetter(c.Param("id"))
+		if i >= 0 {
+			deadLetters = slices.Delete(deadLetters, i, i+1)
+		}
+		deadLettersMu.Unlock()
+		if i < 0 {
+			respondError(c, errNotFound, http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/admin/deadletters/:id/replay", replayDeadLetter)
+}
+
+// replayDeadLetter redoes the work once; success removes the letter, a
+// failure keeps it (502).
+func replayDeadLetter(c *gin.Context) {
+	deadLettersMu.Lock()
+	d, _ := findDeadLetter(c.Param("id"))
+	deadLettersMu.Unlock()
+	if d == nil {
+		respondError(c, errNotFound, http.StatusNotFound)
+		return
+	}
+
+	ctx, span := tracer.Start(c.Request.Context(), "deadletter.replay",
+		trace.WithLinks(trace.Link{SpanContext: d.failed}),
+		trace.WithAttributes(
+			attribute.String("deadletter.id", d.ID),
+			attribute.String("deadletter.source", d.Source),
+		))
+	defer span.End()
+
+	err := d.replay(ctx)
+	now := time.Now().UTC()
+	result := "success"
+	deadLettersMu.Lock()
+	d.Replays++
+	d.LastReplay = &now
+	if err == nil {
+		if _, i := findDeadLetter(d.ID); i >= 0 {
+			deadLetters = slices.Delete(deadLetters, i, i+1)
+		}
+	} else {
+		result = "failure"
+		d.Reason = err.Error()
+	}
+	deadLettersMu.Unlock()
+	deadLetterReplays.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("source", d.Source), attribute.String("result", result)))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		respondError(c, fmt.Errorf("replay failed: %w", err), http.StatusBadGateway)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": d.ID, "replayed": true})
+}
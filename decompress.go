@@ -0,0 +1,112 @@
+// decompress.go — transparent request body decompression:
+//   • Content-Encoding: gzip / deflate on POST & PUT
+//   • decompressed size capped by MAX_DECOMPRESSED_BODY (zip-bomb guard)
+//   • decompression time recorded as a span event
+
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	errBodyTooLarge        = errors.New("request body too large")
+	errUnsupportedEncoding = errors.New("unsupported content encoding")
+)
+
+func decompressRequest(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m := c.Request.Method
+		enc := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+		if (m != http.MethodPost && m != http.MethodPut) || enc == "" || enc == "identity" {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		body, compressed, err := inflate(c.Request.Body, enc, maxBytes)
+		if err != nil {
+			status := http.StatusBadRequest
+			var sentTooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &sentTooLarge):
+				bodyTooLarge(c, sentTooLarge.Limit, -1)
+				c.Abort()
+				return
+			case errors.Is(err, errBodyTooLarge):
+				status = http.StatusRequestEntityTooLarge
+			case errors.Is(err, errUnsupportedEncoding):
+				status = http.StatusUnsupportedMediaType
+			}
+			respondError(c, err, status)
+			c.Abort()
+			return
+		}
+
+		traceSpan(c.Request.Context()).AddEvent("request.decompressed", trace.WithAttributes(
+			attribute.String("http.request.content_encoding", enc),
+			attribute.Int64("http.request.compressed_size", compressed),
+			attribute.Int("http.request.decompressed_size", len(body)),
+			attribute.Int64("decompress.duration_us", time.Since(start).Microseconds()),
+		))
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Next()
+	}
+}
+
+// inflate fully decodes r, failing once more than maxBytes are produced.
+func inflate(r io.ReadCloser, enc string, maxBytes int64) ([]byte, int64, error) {
+	defer r.Close()
+	in := &countingReader{r: r}
+
+	var zr io.ReadCloser
+	switch enc {
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		zr = gz
+	case "deflate":
+		zr = flate.NewReader(in)
+	default:
+		return nil, 0, fmt.Errorf("%w: %q", errUnsupportedEncoding, enc)
+	}
+	defer zr.Close()
+
+	body, err := io.ReadAll(io.LimitReader(zr, maxBytes+1))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid %s body: %w", enc, err)
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, 0, fmt.Errorf("%w: decompressed size exceeds %d bytes", errBodyTooLarge, maxBytes)
+	}
+	return body, in.n, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
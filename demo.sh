@@ -12,6 +12,11 @@ curl -i -X POST http://localhost:8080/items \
      -H 'Content-Type: application/json' \
      -d '{"name":"gadget"}'
 
+# 1b. Bulk create with 30% injected per-element failures (207 Multi-Status)
+curl -i -X POST 'http://localhost:8080/items/bulk?fail_rate=0.3' \
+     -H 'Content-Type: application/json' \
+     -d '[{"name":"a"},{"name":"b"},{"name":"c"},{"name":"d"},{"name":"e"}]'
+
 # 2. List all items
 curl -i http://localhost:8080/items
 
@@ -37,4 +42,94 @@ curl -i http://localhost:8080/fail
 
 # panic captured by recoveryWithOtel
 curl -i http://localhost:8080/panic
+
+# multi-stage waterfall (db → cache → render child spans)
+curl -i 'http://localhost:8080/slow?ms=600&stages=3'
+
+# handler outlives its 2s route deadline → 504 with cancellation events
+curl -i http://localhost:8080/timeout
+
+# hold 100 MiB for 30s — watch process_runtime_go_mem_* on /metrics
+curl -i 'http://localhost:8080/chaos/memory?mb=100&hold=30s'
+
+# burn 4 cores for 200ms (one cpu.burn span per goroutine)
+curl -i 'http://localhost:8080/chaos/cpu?ms=200&goroutines=4'
+
+# leak 50 goroutines, inspect, then release them
+curl -i 'http://localhost:8080/chaos/leak?n=50'
+curl -i http://localhost:8080/chaos/leak/count
+curl -i -X DELETE http://localhost:8080/chaos/leak
+
+# five callers queue on one mutex; later ones show long lock.wait spans
+for i in 1 2 3 4 5; do curl -s 'http://localhost:8080/chaos/contend?hold=200ms' & done; wait; echo
+
+# 4-hop self-call chain whose last hop fails → one deep error trace
+curl -i 'http://localhost:8080/scenario/cascade?depth=4'
+
+# retry storm vs. the same load under a shared retry budget
+curl -i 'http://localhost:8080/scenario/retry-storm?callers=5&retries=5'
+curl -i 'http://localhost:8080/scenario/retry-storm?callers=5&retries=5&mode=budget'
+
+# scripted create → get → update → search → delete flow under one trace
+curl -i 'http://localhost:8080/scenario/run?name=checkout'
+
+# two-service trace: the API calls the embedded echo service (echo-service)
+curl -i 'http://localhost:8080/scenario/echo?delay=50ms'
+
+# fan-out/fan-in: 8 concurrent echo calls, ~25% failing (partial vs strict)
+curl -i 'http://localhost:8080/aggregate?n=8&fail_rate=0.25'
+curl -i 'http://localhost:8080/aggregate?n=8&fail_rate=0.25&mode=strict'
+
+# hedged request: a second attempt after 100ms, the slower one is cancelled
+curl -i 'http://localhost:8080/scenario/hedge?after=100ms&slow_rate=0.5'
+
+# live change stream (Server-Sent Events), watched for 5s
+curl -N -m 5 http://localhost:8080/items/events
+
+# a page of items: X-Total-Count and a Link to the next page
+curl -si 'http://localhost:8080/items?limit=2&offset=0' | grep -iE '^(x-total-count|link):'
+
+# the same store over gRPC (needs grpcurl; reflection is on)
+grpcurl -plaintext -d '{"name":"grpc"}' localhost:9090 items.v1.ItemService/CreateItem || true
+
+# ... and as REST through grpc-gateway: HTTP → gRPC client → gRPC server in one trace
+curl -s -X POST http://localhost:8080/v1/items -d '{"name":"gateway"}'
+curl -s http://localhost:8080/v1/items
+
+# ... and as Twirp: a plain JSON POST per method
+curl -s -H 'Content-Type: application/json' \
+  http://localhost:8080/twirp/items.v1.ItemService/ListItems -d '{}'
+
+# GraphQL: Item.related resolves per item, an N+1 you can see as a row of spans
+curl -s http://localhost:8080/graphql -H 'Content-Type: application/json' \
+  -d '{"query":"{ items { id name related { name } } }"}'
+
+# JSON-RPC 2.0 batch: one span per call, named after the method
+curl -s http://localhost:8080/rpc \
+  -d '[{"jsonrpc":"2.0","method":"items.list","id":1},{"jsonrpc":"2.0","method":"items.get","params":{"id":1},"id":2}]'
+
+# long poll: returns as soon as something changes after seq 0, or after 3s
+curl -s 'http://localhost:8080/items/changes?since=0&wait=3s'
+
+# the same changes over WebSocket (needs websocat): subscribe, then create
+printf '%s\n' '{"id":"1","op":"subscribe"}' '{"id":"2","op":"create","item":{"name":"ws"}}' |
+  timeout 3 websocat ws://localhost:8080/ws || true
+
+# async import: 202 + Location: /jobs/<id>; the job runs in a linked trace
+curl -i -X POST http://localhost:8080/items/import-async \
+     -H 'Content-Type: application/json' \
+     -d '[{"name":"a"},{"name":"b"},{"name":"c"}]'
+
+# webhook: item changes POSTed to the echo service, traceparent included
+curl -i -X POST http://localhost:8080/webhooks \
+     -H 'Content-Type: application/json' \
+     -d '{"url":"http://127.0.0.1:8081/echo"}'
+curl -i http://localhost:8080/webhooks            # then /webhooks/<id>/deliveries
+
+# saga: reserve → charge → confirm; a failed charge is compensated (release)
+curl -i 'http://localhost:8080/scenario/saga'
+curl -i 'http://localhost:8080/scenario/saga?fail_at=confirm'
+
+# availability, latency percentiles and error budget from the calls above
+curl -i 'http://localhost:8080/admin/slo?objective=0.99'
 # -----------------------------------------------------------------------
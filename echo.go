@@ -0,0 +1,144 @@
+// echo.go — a tiny downstream service embedded in the same binary:
+//   • listens on ECHO_LISTEN (127.0.0.1:8081; "off" disables it)
+//   • has its own tracer provider, reporting as ECHO_SERVICE_NAME
+//     (echo-service), so one container yields two-service traces
+//   • GET|POST /echo?delay=50ms&status=200 reflects the request back
+//   • the main app calls it from GET /scenario/echo (via ECHO_URL)
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// echoAddr is the embedded service's listen address ("" when disabled).
+func echoAddr() string {
+	addr := envString("ECHO_LISTEN", "127.0.0.1:8081")
+	if strings.EqualFold(addr, "off") {
+		return ""
+	}
+	return addr
+}
+
+// startEcho serves the echo service in the background. The returned server
+// (nil if disabled or unbound) is drained with the main one; flush exports
+// its remaining spans.
+func startEcho(l *slog.Logger) (srv *http.Server, flush func()) {
+	addr := echoAddr()
+	if addr == "" {
+		return nil, func() {}
+	}
+	name := envString("ECHO_SERVICE_NAME", "echo-service")
+	tp := newTracerProvider(namedResource(name))
+	l = l.With("service", name)
+
+	r := gin.New()
+	r.Use(otelgin.Middleware(name, otelgin.WithTracerProvider(tp)))
+	r.Use(requestID())
+	r.Use(recoveryWithOtel(l))
+	r.Use(slogWithTrace(l))
+
+	echoTracer := tp.Tracer(name)
+	echo := func(c *gin.Context) {
+		_, span := echoTracer.Start(c.Request.Context(), "echo.process")
+		defer span.End()
+
+		delay, _ := time.ParseDuration(c.Query("delay"))
+		delay = min(max(delay, 0), time.Duration(maxSlowMillis)*time.Millisecond)
+		if err := sleepCtx(c.Request.Context(), delay); err != nil {
+			respondError(c, err, contextErrorStatus(err))
+			return
+		}
+		status, err := strconv.Atoi(c.DefaultQuery("status", "200"))
+		if err != nil || status < 200 || status > 599 {
+			respondError(c, errors.New("status must be an HTTP status code"), http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+		span.SetAttributes(attribute.Int64("echo.delay_ms", delay.Milliseconds()), attribute.Int("echo.body_bytes", len(body)))
+
+		if status >= 400 {
+			respondError(c, fmt.Errorf("echo: requested status %d", status), status)
+			return
+		}
+		c.JSON(status, gin.H{
+			"service":     name,
+			"method":      c.Request.Method,
+			"query":       c.Request.URL.Query(),
+			"traceparent": c.GetHeader("traceparent"),
+			"request_id":  requestIDFromContext(c.Request.Context()),
+			"body":        string(body),
+		})
+	}
+	r.GET("/echo", echo)
+	r.POST("/echo", echo)
+
+	ln, err := listen(addr)
+	if err != nil {
+		l.Error("echo listen", "addr", addr, "err", err)
+		return nil, shutdownTracerProvider(tp)
+	}
+	srv = newHTTPServer(r, nil)
+	go func() {
+		l.Info("Echo service …", "addr", addr)
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			l.Error("echo server error", "err", err)
+		}
+	}()
+	return srv, shutdownTracerProvider(tp)
+}
+
+// echoURL is where the main app reaches the echo service.
+func echoURL() string {
+	addr := echoAddr()
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	return envString("ECHO_URL", "http://"+addr)
+}
+
+// echoEnabled reports whether there is an echo service to call.
+func echoEnabled() bool {
+	return echoAddr() != "" || envString("ECHO_URL", "") != ""
+}
+
+// scenarioEchoHandler calls the echo service, passing delay/status through.
+func scenarioEchoHandler(c *gin.Context) {
+	if !echoEnabled() {
+		respondError(c, errors.New("echo service disabled"), http.StatusNotFound)
+		return
+	}
+	ctx, span := tracer.Start(c.Request.Context(), "echo.call", trace.WithAttributes(attribute.String("peer.service", "echo")))
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, echoURL()+"/echo?"+c.Request.URL.RawQuery, nil)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	forwardAuth(req, c.Request)
+	resp, err := outboundClient.Do(req)
+	if err != nil {
+		respondError(c, fmt.Errorf("echo: %w", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respondError(c, fmt.Errorf("echo returned %d", resp.StatusCode), http.StatusBadGateway)
+		return
+	}
+	c.DataFromReader(resp.StatusCode, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, nil)
+}
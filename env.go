@@ -0,0 +1,57 @@
+// env.go — typed helpers for reading optional settings: flags, environment
+//   and config file, in that order (config.go). Every helper falls back to
+//   def when the setting is unset or malformed.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+func envString(key, def string) string {
+	if v := config.get(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(config.get(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+func envFloat(key string, def float64) float64 {
+	if v, err := strconv.ParseFloat(config.get(key), 64); err == nil {
+		return v
+	}
+	return def
+}
+
+func envBool(key string, def bool) bool {
+	if v, err := strconv.ParseBool(config.get(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(config.get(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// envList splits a comma-separated variable, trimming blanks.
+func envList(key string) []string {
+	var out []string
+	for _, s := range strings.Split(config.get(key), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
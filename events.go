@@ -0,0 +1,207 @@
+// events.go — item change events:
+//   • every successful create/update/delete (single or bulk) is published
+//     as a CloudEvent (com.example.item.created|updated|deleted)
+//   • the broker is pluggable (eventPublisher); KAFKA_BROKERS selects Kafka,
+//     AMQP_URL RabbitMQ
+//   • trace context travels in message headers, injected by the publisher
+//   • publishing never fails the request; errors land on the producer span
+//   • consumers continue (or link to) the producing trace, see
+//     consumeSpanOptions, and dead-letter events that keep failing
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const eventSource = "/otel-crud-example/items"
+
+// itemEvent is a CloudEvents 1.0 envelope around the item.
+type itemEvent struct {
+	SpecVersion string          `json:"specversion"`
+	ID          string          `json:"id"`
+	Source      string          `json:"source"`
+	Type        string          `json:"type"`
+	Subject     string          `json:"subject"`
+	Time        time.Time       `json:"time"`
+	ContentType string          `json:"datacontenttype"`
+	Data        json.RawMessage `json:"data"`
+}
+
+func newItemEvent(kind string, item Item) itemEvent {
+	data, _ := json.Marshal(item)
+	return itemEvent{
+		SpecVersion: "1.0",
+		ID:          uuid.NewString(),
+		Source:      eventSource,
+		Type:        "com.example.item." + kind,
+		Subject:     "items/" + strconv.Itoa(item.ID),
+		Time:        time.Now().UTC(),
+		ContentType: "application/json",
+		Data:        data,
+	}
+}
+
+type eventPublisher interface {
+	// Publish sends ev; ctx carries the trace to propagate.
+	Publish(ctx context.Context, ev itemEvent) error
+	Close() error
+}
+
+// newEventPublisher returns nil when no broker is configured. async lets
+// the Kafka publisher return before the broker confirms the write.
+func newEventPublisher(async bool) (eventPublisher, error) {
+	brokers, amqpURL := envList("KAFKA_BROKERS"), envString("AMQP_URL", "")
+	switch {
+	case len(brokers) > 0 && amqpURL != "":
+		return nil, errors.New("KAFKA_BROKERS and AMQP_URL are mutually exclusive")
+	case len(brokers) > 0:
+		return newKafkaPublisher(brokers, envString("KAFKA_TOPIC", "item-events"), async), nil
+	case amqpURL != "":
+		return newAMQPPublisher(amqpURL, envString("AMQP_EXCHANGE", "item-events")), nil
+	}
+	return nil, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* Store decorator                                                            */
+/* -------------------------------------------------------------------------- */
+
+// publishingStore emits an event after each successful mutation.
+type publishingStore struct {
+	itemStore
+	pub eventPublisher
+	log *slog.Logger
+}
+
+func (s publishingStore) publish(ctx context.Context, kind string, item Item) {
+	if err := s.pub.Publish(ctx, newItemEvent(kind, item)); err != nil {
+		s.log.WarnContext(ctx, "publishing item event", "type", kind, "item_id", item.ID, "err", err)
+	}
+}
+
+func (s publishingStore) Create(ctx context.Context, name string) (Item, error) {
+	item, err := s.itemStore.Create(ctx, name)
+	if err == nil {
+		s.publish(ctx, "created", item)
+	}
+	return item, err
+}
+
+func (s publishingStore) Update(ctx context.Context, id int, fn func(Item) (Item, error)) (Item, error) {
+	item, err := s.itemStore.Update(ctx, id, fn)
+	if err == nil {
+		s.publish(ctx, "updated", item)
+	}
+	return item, err
+}
+
+func (s publishingStore) Delete(ctx context.Context, id int) error {
+	err := s.itemStore.Delete(ctx, id)
+	if err == nil {
+		s.publish(ctx, "deleted", Item{ID: id})
+	}
+	return err
+}
+
+/* -------------------------------------------------------------------------- */
+/* Consumer side                                                              */
+/* -------------------------------------------------------------------------- */
+
+var (
+	eventsProcessed, _ = meter.Int64Counter("events.processed",
+		metric.WithDescription("Item events handled by the consumer worker"))
+	eventLag, _ = meter.Float64Histogram("events.lag",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Time from event creation to processing"))
+)
+
+// consumeSpanOptions makes the process span continue the producer's trace
+// (EVENTS_TRACE_MODE=child, default) or start a new trace linked to it
+// (link), the usual choice when one batch mixes many producers.
+func consumeSpanOptions(producer context.Context, attrs ...attribute.KeyValue) (context.Context, []trace.SpanStartOption) {
+	opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attrs...)}
+	if sc := trace.SpanContextFromContext(producer); sc.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: sc}))
+	}
+	if envString("EVENTS_TRACE_MODE", "child") == "link" {
+		return context.Background(), append(opts, trace.WithNewRoot())
+	}
+	return producer, opts
+}
+
+// handleItemEvent decodes and processes raw inside the caller's process
+// span, retrying failures up to EVENTS_MAX_ATTEMPTS (3) times; undecodable
+// or still failing events are dead-lettered under source.
+func handleItemEvent(ctx context.Context, l *slog.Logger, source string, raw []byte) {
+	span := trace.SpanFromContext(ctx)
+	replay := func(ctx context.Context) error { return decodeItemEvent(ctx, l, raw) }
+
+	var ev itemEvent
+	if err := json.Unmarshal(raw, &ev); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "undecodable event")
+		l.WarnContext(ctx, "dead-lettering undecodable event", "err", err)
+		addDeadLetter(ctx, source, err, 1, raw, replay)
+		return
+	}
+
+	attempts := max(envInt("EVENTS_MAX_ATTEMPTS", 3), 1)
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = processItemEvent(ctx, l, ev); err == nil {
+			return
+		}
+		if attempt == attempts || sleepCtx(ctx, time.Duration(attempt)*100*time.Millisecond) != nil {
+			break
+		}
+		span.AddEvent("events.retry", trace.WithAttributes(attribute.Int("events.attempt", attempt+1)))
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	l.WarnContext(ctx, "dead-lettering item event", "type", ev.Type, "attempts", attempts, "err", err)
+	addDeadLetter(ctx, source, err, attempts, raw, replay)
+}
+
+func decodeItemEvent(ctx context.Context, l *slog.Logger, raw []byte) error {
+	var ev itemEvent
+	if err := json.Unmarshal(raw, &ev); err != nil {
+		return err
+	}
+	return processItemEvent(ctx, l, ev)
+}
+
+// processItemEvent handles one decoded event inside the caller's span: it
+// records lag and logs the change. EVENTS_FAIL_RATE injects failures.
+func processItemEvent(ctx context.Context, l *slog.Logger, ev itemEvent) error {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		attribute.String("messaging.message.id", ev.ID),
+		attribute.String("cloudevents.event_type", ev.Type),
+		attribute.String("cloudevents.event_subject", ev.Subject),
+	)
+	if rate := envFloat("EVENTS_FAIL_RATE", 0); rate > 0 && rand.Float64() < rate {
+		span.SetAttributes(attribute.Bool("injected", true))
+		eventsProcessed.Add(ctx, 1, metric.WithAttributes(attribute.String("type", ev.Type), attribute.String("result", "failure")))
+		return errors.New("simulated processing failure")
+	}
+
+	lag := float64(time.Since(ev.Time).Microseconds()) / 1000
+	eventLag.Record(ctx, lag, metric.WithAttributes(attribute.String("type", ev.Type)))
+	eventsProcessed.Add(ctx, 1, metric.WithAttributes(attribute.String("type", ev.Type), attribute.String("result", "success")))
+	l.InfoContext(ctx, "item event processed", "type", ev.Type, "subject", ev.Subject, "lag_ms", lag,
+		"trace_id", span.SpanContext().TraceID().String())
+	return nil
+}
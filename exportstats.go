@@ -0,0 +1,85 @@
+// exportstats.go — span export bookkeeping for introspection:
+//   a probe processor counts sampled spans as they end, and a wrapping
+//   exporter counts what the batcher actually shipped (or failed to).
+//   ended − exported − failed ≈ spans still waiting in the batch queue.
+//   Traces can also be watched to learn when (and whether) they shipped.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type exportStats struct {
+	ended    atomic.Int64
+	exported atomic.Int64
+	failed   atomic.Int64
+	lastErr  atomic.Pointer[error] // outcome of the latest batch; nil before the first
+	watches  sync.Map              // trace.TraceID → chan error
+}
+
+var spanStats exportStats
+
+func (s *exportStats) pending() int64 {
+	return max(s.ended.Load()-s.exported.Load()-s.failed.Load(), 0)
+}
+
+// healthy reports the latest batch's export error (readyz.go); no batch yet
+// counts as healthy.
+func (s *exportStats) healthy(context.Context) error {
+	if err := s.lastErr.Load(); err != nil && *err != nil {
+		return fmt.Errorf("last span export failed: %w", *err)
+	}
+	return nil
+}
+
+// watch returns a channel receiving the export result of the first batch
+// containing a span of id; cancel stops watching.
+func (s *exportStats) watch(id trace.TraceID) (result <-chan error, cancel func()) {
+	ch := make(chan error, 1)
+	s.watches.Store(id, ch)
+	return ch, func() { s.watches.Delete(id) }
+}
+
+func (s *exportStats) notify(spans []sdktrace.ReadOnlySpan, err error) {
+	for _, sp := range spans {
+		if ch, ok := s.watches.LoadAndDelete(sp.SpanContext().TraceID()); ok {
+			ch.(chan error) <- err
+		}
+	}
+}
+
+// endProbe is registered before the batcher and only observes OnEnd.
+type endProbe struct{ stats *exportStats }
+
+func (endProbe) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+func (p endProbe) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.stats.ended.Add(1)
+	}
+}
+func (endProbe) Shutdown(context.Context) error   { return nil }
+func (endProbe) ForceFlush(context.Context) error { return nil }
+
+type countingExporter struct {
+	sdktrace.SpanExporter
+	stats *exportStats
+}
+
+func (e countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	if err != nil {
+		e.stats.failed.Add(int64(len(spans)))
+	} else {
+		e.stats.exported.Add(int64(len(spans)))
+	}
+	e.stats.lastErr.Store(&err)
+	e.stats.notify(spans, err)
+	return err
+}
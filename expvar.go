@@ -0,0 +1,92 @@
+// expvar.go — /debug/vars on the ops router: store size, in-flight
+//   requests, span export counters and build info for ad-hoc inspection.
+//   The in-flight count is also the http.server.active_requests metric.
+
+package main
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var inflight atomic.Int64
+
+var activeRequests, _ = meter.Int64UpDownCounter("http.server.active_requests",
+	metric.WithUnit("{request}"),
+	metric.WithDescription("Requests currently inside the handler chain"))
+
+// countInflight tracks requests currently inside the handler chain, by
+// method and route, so saturation shows before the latency histograms move.
+func countInflight() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+			http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		default:
+			method = "_OTHER" // as semconv: clients choose the method, not us
+		}
+		attrs := metric.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("http.route", route),
+		)
+		ctx := context.WithoutCancel(c.Request.Context())
+		inflight.Add(1)
+		activeRequests.Add(ctx, 1, attrs)
+		defer func() {
+			inflight.Add(-1)
+			activeRequests.Add(ctx, -1, attrs)
+		}()
+		c.Next()
+	}
+}
+
+var publishVars = sync.OnceFunc(func() {
+	expvar.Publish("store_size", expvar.Func(func() any {
+		// count the backing store directly: no span, no injected latency
+		n, _ := memStore.Count(context.Background())
+		return n
+	}))
+	expvar.Publish("inflight_requests", expvar.Func(func() any { return inflight.Load() }))
+	expvar.Publish("span_export", expvar.Func(func() any {
+		return map[string]int64{
+			"ended":    spanStats.ended.Load(),
+			"exported": spanStats.exported.Load(),
+			"failed":   spanStats.failed.Load(),
+			"queued":   spanStats.pending(),
+		}
+	}))
+	expvar.Publish("build", expvar.Func(func() any {
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return nil
+		}
+		settings := make(map[string]string, len(bi.Settings))
+		for _, s := range bi.Settings {
+			settings[s.Key] = s.Value
+		}
+		return map[string]any{
+			"go_version": bi.GoVersion,
+			"path":       bi.Path,
+			"version":    bi.Main.Version,
+			"settings":   settings,
+		}
+	}))
+})
+
+func registerExpvar(r gin.IRouter) {
+	publishVars()
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+}
@@ -0,0 +1,151 @@
+// fakedb.go — tracedStore makes the in-memory store look like a database:
+//   every call runs in a db.query client span with SQL-ish attributes,
+//   configurable latency (DB_LATENCY, same syntax as X-Inject-Latency)
+//   and occasional failures (DB_ERROR_RATE), so traces resemble a real
+//   service without needing Postgres. Queries made for a tenant carry its
+//   tenant.id.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errDBUnavailable = errors.New("db: connection reset by peer (simulated)")
+
+type tracedStore struct {
+	next      itemStore
+	system    string
+	latency   latencySpec
+	errorRate float64
+}
+
+// newTracedStore reads DB_LATENCY (default "lognormal:2ms,0.6") and
+// DB_ERROR_RATE (default 0).
+func newTracedStore(next itemStore) (*tracedStore, error) {
+	spec, err := parseLatencySpec(envString("DB_LATENCY", "lognormal:2ms,0.6"))
+	if err != nil {
+		return nil, fmt.Errorf("DB_LATENCY: %w", err)
+	}
+	rate := envFloat("DB_ERROR_RATE", 0)
+	if rate < 0 || rate > 1 {
+		return nil, fmt.Errorf("DB_ERROR_RATE %v outside [0,1]", rate)
+	}
+	return &tracedStore{next: next, system: "memory", latency: spec, errorRate: rate}, nil
+}
+
+// query wraps one store call in a db.query span.
+func (s *tracedStore) query(ctx context.Context, op, stmt string, fn func(context.Context) error) error {
+	return s.queryTable(ctx, "items", op, stmt, fn)
+}
+
+// queryTable is query against another table (see outbox.go).
+func (s *tracedStore) queryTable(ctx context.Context, table, op, stmt string, fn func(context.Context) error) error {
+	ctx, span := tracer.Start(ctx, "db.query "+op, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", s.system),
+			attribute.String("db.collection.name", table),
+			attribute.String("db.operation.name", op),
+			attribute.String("db.query.text", stmt),
+		))
+	defer span.End()
+	span.SetAttributes(tenantAttrs(ctx)...)
+
+	if err := sleepCtx(ctx, s.latency.sample()); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	err := errDBUnavailable
+	if s.errorRate == 0 || rand.Float64() >= s.errorRate {
+		err = fn(ctx)
+	} else {
+		span.SetAttributes(attribute.Bool("injected", true))
+	}
+
+	// a missing row, an unmet precondition or a refused name is an answer,
+	// not a failure
+	if err != nil && !errors.Is(err, errNotFound) && !errors.Is(err, errPreconditionFailed) &&
+		!errors.Is(err, errInvalidName) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// ping is the readiness check: a trivial query, subject to the same
+// latency and failures as the real ones.
+func (s *tracedStore) ping(ctx context.Context) error {
+	return s.query(ctx, "SELECT", "SELECT 1", func(context.Context) error { return nil })
+}
+
+func (s *tracedStore) Get(ctx context.Context, id int) (item Item, err error) {
+	err = s.query(ctx, "SELECT", "SELECT id, name FROM items WHERE id = ?", func(ctx context.Context) error {
+		item, err = s.next.Get(ctx, id)
+		return err
+	})
+	return item, err
+}
+
+func (s *tracedStore) List(ctx context.Context) (items []Item, err error) {
+	err = s.query(ctx, "SELECT", "SELECT id, name FROM items", func(ctx context.Context) error {
+		items, err = s.next.List(ctx)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int("db.response.returned_rows", len(items)))
+		return err
+	})
+	return items, err
+}
+
+// Each holds its span open until the last item is handed over, like a
+// cursor would.
+func (s *tracedStore) Each(ctx context.Context, fn func(Item) error) error {
+	return s.query(ctx, "SELECT", "SELECT id, name FROM items", func(ctx context.Context) error {
+		n := 0
+		err := s.next.Each(ctx, func(item Item) error {
+			n++
+			return fn(item)
+		})
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int("db.response.returned_rows", n))
+		return err
+	})
+}
+
+func (s *tracedStore) Create(ctx context.Context, name string) (item Item, err error) {
+	err = s.query(ctx, "INSERT", "INSERT INTO items (name) VALUES (?)", func(ctx context.Context) error {
+		item, err = s.next.Create(ctx, name)
+		return err
+	})
+	return item, err
+}
+
+// Update is one query however many times the swap is retried; the
+// conflicts are events on its span.
+func (s *tracedStore) Update(ctx context.Context, id int, fn func(Item) (Item, error)) (item Item, err error) {
+	err = s.query(ctx, "UPDATE", "UPDATE items SET name = ? WHERE id = ? AND updated_at = ?", func(ctx context.Context) error {
+		item, err = s.next.Update(ctx, id, fn)
+		return err
+	})
+	return item, err
+}
+
+func (s *tracedStore) Delete(ctx context.Context, id int) error {
+	return s.query(ctx, "DELETE", "DELETE FROM items WHERE id = ?", func(ctx context.Context) error {
+		return s.next.Delete(ctx, id)
+	})
+}
+
+func (s *tracedStore) Count(ctx context.Context) (n int, err error) {
+	err = s.query(ctx, "SELECT", "SELECT COUNT(*) FROM items", func(ctx context.Context) error {
+		n, err = s.next.Count(ctx)
+		return err
+	})
+	return n, err
+}
@@ -0,0 +1,372 @@
+// features.go — feature flags through OpenFeature:
+//   • FEATURE_FLAGS_PROVIDER=env (default) reads FLAG_<KEY> settings, e.g.
+//     FLAG_STRICT_ITEM_NAMES=true; a bool flag may be a rollout like "25%",
+//     bucketed by targeting key (the caller, else the client IP)
+//   • FEATURE_FLAGS_PROVIDER=flagd asks flagd (or any OFREP service) at
+//     FLAGD_URL over HTTP, so each lookup is a client span of its own
+//   • every evaluation becomes a feature_flag.evaluation event, with key,
+//     variant/value, reason and provider, on the active span, so a trace shows
+//     which branch a request took and why
+//   • strict-item-names gates the stricter name rules on create/update;
+//     max-item-name-length tunes them; nameRulesStore applies them under
+//     repo, so every API and the bulk import see them, and a refusal is a
+//     nameError (400, INVALID_ARGUMENT, JSON-RPC invalid params)
+//   • HTTP lookups target what flagTargeting put in the request context;
+//     gRPC ones, and anything without it, the peer
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/micro-company/http-trace-example/internal/httpclient"
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/open-feature/go-sdk/openfeature/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/peer"
+)
+
+var features = openfeature.NewClient("otel-crud-example")
+
+// initFeatureFlags installs the configured provider and the tracing hook;
+// the returned func shuts the provider down.
+func initFeatureFlags() (func(), error) {
+	var src flagSource
+	switch name := envString("FEATURE_FLAGS_PROVIDER", "env"); name {
+	case "env":
+		src = envFlags{}
+	case "flagd":
+		src = ofrepFlags{
+			base:   strings.TrimRight(envString("FLAGD_URL", "http://127.0.0.1:8016"), "/"),
+			client: newOutboundClient(envDuration("FLAGD_TIMEOUT", 500*time.Millisecond)).WithMaxAttempts(1),
+		}
+	default:
+		return nil, fmt.Errorf("FEATURE_FLAGS_PROVIDER %q: want env or flagd", name)
+	}
+	openfeature.AddHooks(flagTracing{})
+	if err := openfeature.SetProviderAndWait(featureProvider{src}); err != nil {
+		return nil, err
+	}
+	return openfeature.Shutdown, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* Gated behavior                                                             */
+/* -------------------------------------------------------------------------- */
+
+var errInvalidName = errors.New("invalid item name")
+
+// nameError is a name the strict-item-names rules refuse.
+type nameError struct{ msg string }
+
+func (e *nameError) Error() string { return e.msg }
+
+func (e *nameError) Is(target error) bool { return target == errInvalidName }
+
+// nameRules returns the check strict-item-names turns on for ctx's caller,
+// nil when it is off. The flags are read up front so that Update doesn't
+// evaluate them while the store holds its lock.
+func nameRules(ctx context.Context) func(name string) error {
+	evalCtx := requestFlagContext(ctx)
+	if strict, _ := features.BooleanValue(ctx, "strict-item-names", false, evalCtx); !strict {
+		return nil
+	}
+	limit, _ := features.IntValue(ctx, "max-item-name-length", 64, evalCtx)
+	return func(name string) error {
+		switch {
+		case strings.TrimSpace(name) == "":
+			return &nameError{"name must not be blank"}
+		case int64(utf8.RuneCountInString(name)) > limit:
+			return &nameError{fmt.Sprintf("name must be at most %d characters", limit)}
+		case strings.IndexFunc(name, unicode.IsControl) >= 0:
+			return &nameError{"name must not contain control characters"}
+		}
+		return nil
+	}
+}
+
+// nameRulesStore refuses the names nameRules does, on creates and on what
+// an update would store.
+type nameRulesStore struct{ itemStore }
+
+func (s nameRulesStore) Create(ctx context.Context, name string) (Item, error) {
+	if check := nameRules(ctx); check != nil {
+		if err := check(name); err != nil {
+			return Item{}, err
+		}
+	}
+	return s.itemStore.Create(ctx, name)
+}
+
+func (s nameRulesStore) Update(ctx context.Context, id int, fn func(Item) (Item, error)) (Item, error) {
+	check := nameRules(ctx)
+	if check == nil {
+		return s.itemStore.Update(ctx, id, fn)
+	}
+	return s.itemStore.Update(ctx, id, func(item Item) (Item, error) {
+		item, err := fn(item)
+		if err == nil {
+			err = check(item.Name)
+		}
+		return item, err
+	})
+}
+
+type flagContextKey struct{}
+
+func withFlagContext(ctx context.Context, evalCtx openfeature.EvaluationContext) context.Context {
+	return context.WithValue(ctx, flagContextKey{}, evalCtx)
+}
+
+// requestFlagContext is the targeting flagTargeting stored in ctx, else
+// the gRPC peer's.
+func requestFlagContext(ctx context.Context) openfeature.EvaluationContext {
+	if evalCtx, ok := ctx.Value(flagContextKey{}).(openfeature.EvaluationContext); ok {
+		return evalCtx
+	}
+	return rpcFlagContext(ctx)
+}
+
+// flagTargeting stores flagContext in the request context, for lookups
+// that only get a context.Context; it goes after the auth middleware.
+func flagTargeting() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(withFlagContext(c.Request.Context(), flagContext(c)))
+		c.Next()
+	}
+}
+
+// flagContext targets the authenticated caller, else the client IP.
+func flagContext(c *gin.Context) openfeature.EvaluationContext {
+	key := c.GetString(ctxEndUser)
+	if key == "" {
+		key = c.ClientIP()
+	}
+	return openfeature.NewEvaluationContext(key, map[string]any{
+		"http.route":          routePath(c.FullPath()),
+		"http.request.method": c.Request.Method,
+	})
+}
+
+// rpcFlagContext targets the gRPC peer; Twirp calls have none and share
+// one bucket.
+func rpcFlagContext(ctx context.Context) openfeature.EvaluationContext {
+	var key string
+	if p, ok := peer.FromContext(ctx); ok {
+		key, _, _ = net.SplitHostPort(p.Addr.String())
+	}
+	return openfeature.NewEvaluationContext(key, nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/* Tracing hook                                                               */
+/* -------------------------------------------------------------------------- */
+
+type flagTracing struct{ openfeature.UnimplementedHook }
+
+func (flagTracing) Finally(ctx context.Context, hc openfeature.HookContext, details openfeature.InterfaceEvaluationDetails, _ openfeature.HookHints) {
+	ev := telemetry.CreateEvaluationEvent(hc, details)
+	attrs := make([]attribute.KeyValue, 0, len(ev.Attributes))
+	for k, v := range ev.Attributes {
+		switch v := v.(type) {
+		case bool:
+			attrs = append(attrs, attribute.Bool(k, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(k, v))
+		case float64:
+			attrs = append(attrs, attribute.Float64(k, v))
+		default:
+			attrs = append(attrs, attribute.String(k, fmt.Sprint(v)))
+		}
+	}
+	slices.SortFunc(attrs, func(a, b attribute.KeyValue) int { return strings.Compare(string(a.Key), string(b.Key)) })
+	trace.SpanFromContext(ctx).AddEvent(ev.Name, trace.WithAttributes(attrs...))
+}
+
+/* -------------------------------------------------------------------------- */
+/* Providers                                                                  */
+/* -------------------------------------------------------------------------- */
+
+// flagSource resolves a flag to a raw value (nil: not set, use the default);
+// featureProvider converts it for OpenFeature's typed lookups.
+type flagSource interface {
+	name() string
+	resolve(ctx context.Context, flag string, flatCtx openfeature.FlattenedContext) (any, openfeature.ProviderResolutionDetail)
+}
+
+type featureProvider struct{ src flagSource }
+
+func (p featureProvider) Metadata() openfeature.Metadata {
+	return openfeature.Metadata{Name: p.src.name()}
+}
+
+func (p featureProvider) Hooks() []openfeature.Hook { return nil }
+
+func (p featureProvider) BooleanEvaluation(ctx context.Context, flag string, def bool, fc openfeature.FlattenedContext) openfeature.BoolResolutionDetail {
+	return resolveFlag(p.src, ctx, flag, def, fc, func(v any) (bool, bool) {
+		switch v := v.(type) {
+		case bool:
+			return v, true
+		case string:
+			b, err := strconv.ParseBool(v)
+			return b, err == nil
+		}
+		return false, false
+	})
+}
+
+func (p featureProvider) StringEvaluation(ctx context.Context, flag string, def string, fc openfeature.FlattenedContext) openfeature.StringResolutionDetail {
+	return resolveFlag(p.src, ctx, flag, def, fc, func(v any) (string, bool) {
+		s, ok := v.(string)
+		return s, ok
+	})
+}
+
+func (p featureProvider) FloatEvaluation(ctx context.Context, flag string, def float64, fc openfeature.FlattenedContext) openfeature.FloatResolutionDetail {
+	return resolveFlag(p.src, ctx, flag, def, fc, func(v any) (float64, bool) {
+		switch v := v.(type) {
+		case float64:
+			return v, true
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			return f, err == nil
+		}
+		return 0, false
+	})
+}
+
+func (p featureProvider) IntEvaluation(ctx context.Context, flag string, def int64, fc openfeature.FlattenedContext) openfeature.IntResolutionDetail {
+	return resolveFlag(p.src, ctx, flag, def, fc, func(v any) (int64, bool) {
+		switch v := v.(type) {
+		case float64: // JSON numbers
+			return int64(v), v == float64(int64(v))
+		case string:
+			n, err := strconv.ParseInt(v, 10, 64)
+			return n, err == nil
+		}
+		return 0, false
+	})
+}
+
+func (p featureProvider) ObjectEvaluation(ctx context.Context, flag string, def any, fc openfeature.FlattenedContext) openfeature.InterfaceResolutionDetail {
+	return resolveFlag(p.src, ctx, flag, def, fc, func(v any) (any, bool) {
+		if s, ok := v.(string); ok {
+			var out any
+			return out, json.Unmarshal([]byte(s), &out) == nil
+		}
+		return v, true
+	})
+}
+
+func resolveFlag[T any](src flagSource, ctx context.Context, flag string, def T, fc openfeature.FlattenedContext,
+	convert func(any) (T, bool)) openfeature.GenericResolutionDetail[T] {
+	raw, detail := src.resolve(ctx, flag, fc)
+	if raw == nil || detail.Error() != nil {
+		return openfeature.GenericResolutionDetail[T]{Value: def, ProviderResolutionDetail: detail}
+	}
+	v, ok := convert(raw)
+	if !ok {
+		return openfeature.GenericResolutionDetail[T]{Value: def, ProviderResolutionDetail: openfeature.ProviderResolutionDetail{
+			ResolutionError: openfeature.NewTypeMismatchResolutionError(fmt.Sprintf("%s: %v is not a %T", flag, raw, def)),
+			Reason:          openfeature.ErrorReason,
+		}}
+	}
+	return openfeature.GenericResolutionDetail[T]{Value: v, ProviderResolutionDetail: detail}
+}
+
+// envFlags reads FLAG_<KEY> through the config layers.
+type envFlags struct{}
+
+func (envFlags) name() string { return "env" }
+
+func (envFlags) resolve(_ context.Context, flag string, fc openfeature.FlattenedContext) (any, openfeature.ProviderResolutionDetail) {
+	raw := envString("FLAG_"+configKey(flag), "")
+	if raw == "" {
+		return nil, openfeature.ProviderResolutionDetail{Reason: openfeature.DefaultReason}
+	}
+	pct, isRollout := strings.CutSuffix(raw, "%")
+	if !isRollout {
+		return raw, openfeature.ProviderResolutionDetail{Reason: openfeature.StaticReason}
+	}
+	share, err := strconv.ParseFloat(pct, 64)
+	if err != nil {
+		return nil, openfeature.ProviderResolutionDetail{
+			ResolutionError: openfeature.NewParseErrorResolutionError(fmt.Sprintf("FLAG_%s=%q: want a percentage", configKey(flag), raw)),
+			Reason:          openfeature.ErrorReason,
+		}
+	}
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s/%v", flag, fc[openfeature.TargetingKey])
+	on := float64(h.Sum32()%10000) < share*100
+	variant := "off"
+	if on {
+		variant = "on"
+	}
+	return on, openfeature.ProviderResolutionDetail{Reason: openfeature.SplitReason, Variant: variant}
+}
+
+// ofrepFlags evaluates flags with the OpenFeature Remote Evaluation
+// Protocol, which flagd serves on port 8016.
+type ofrepFlags struct {
+	base   string
+	client *httpclient.Client
+}
+
+func (ofrepFlags) name() string { return "flagd" }
+
+func (o ofrepFlags) resolve(ctx context.Context, flag string, fc openfeature.FlattenedContext) (any, openfeature.ProviderResolutionDetail) {
+	failed := func(err openfeature.ResolutionError) (any, openfeature.ProviderResolutionDetail) {
+		return nil, openfeature.ProviderResolutionDetail{ResolutionError: err, Reason: openfeature.ErrorReason}
+	}
+	body, _ := json.Marshal(map[string]any{"context": fc})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		o.base+"/ofrep/v1/evaluate/flags/"+url.PathEscape(flag), bytes.NewReader(body))
+	if err != nil {
+		return failed(openfeature.NewGeneralResolutionError(err.Error()))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return failed(openfeature.NewGeneralResolutionError(err.Error()))
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Value        any                      `json:"value"`
+		Reason       string                   `json:"reason"`
+		Variant      string                   `json:"variant"`
+		Metadata     openfeature.FlagMetadata `json:"metadata"`
+		ErrorCode    string                   `json:"errorCode"`
+		ErrorDetails string                   `json:"errorDetails"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil && resp.StatusCode == http.StatusOK {
+		return failed(openfeature.NewParseErrorResolutionError(err.Error()))
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return out.Value, openfeature.ProviderResolutionDetail{
+			Reason: openfeature.Reason(out.Reason), Variant: out.Variant, FlagMetadata: out.Metadata,
+		}
+	case out.ErrorCode == string(openfeature.FlagNotFoundCode):
+		return failed(openfeature.NewFlagNotFoundResolutionError(out.ErrorDetails))
+	case out.ErrorCode != "":
+		return failed(openfeature.NewGeneralResolutionError(out.ErrorCode + ": " + out.ErrorDetails))
+	}
+	return failed(openfeature.NewGeneralResolutionError("flagd answered " + resp.Status))
+}
@@ -0,0 +1,204 @@
+// flaky.go — a simulated downstream dependency ("inventory") that degrades
+//   for a window and then recovers by itself:
+//   • GET  /scenario/dependency calls it under a dependency.call client span
+//   • POST /admin/dependency/degrade?for=2m&error_rate=0.5&latency=800ms
+//     starts an incident, POST /admin/dependency/recover ends it early
+//   • DEPENDENCY_DEGRADE_EVERY schedules incidents (DEPENDENCY_DEGRADE_FOR)
+//   • dependency.degraded gauge (0/1) for alert-and-recovery demos
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errDependencyFailed = errors.New("inventory: upstream error (simulated)")
+
+// baselineLatency is what the dependency costs when healthy.
+var baselineLatency = latencySpec{Latency: duration(15 * time.Millisecond), Distribution: "lognormal", LatencySigma: 0.3}
+
+type degradation struct {
+	until     time.Time
+	errorRate float64
+	latency   latencySpec
+}
+
+type flakyDependency struct {
+	name string
+	mu   sync.Mutex
+	deg  degradation
+}
+
+var dependency = func() *flakyDependency {
+	d := &flakyDependency{name: "inventory"}
+	_, _ = meter.Int64ObservableGauge("dependency.degraded",
+		metric.WithDescription("1 while the simulated dependency is degraded"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			var v int64
+			if _, on := d.state(); on {
+				v = 1
+			}
+			o.Observe(v, metric.WithAttributes(attribute.String("peer.service", d.name)))
+			return nil
+		}))
+	return d
+}()
+
+func (d *flakyDependency) state() (degradation, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.deg, time.Now().Before(d.deg.until)
+}
+
+func (d *flakyDependency) degrade(deg degradation) {
+	d.mu.Lock()
+	d.deg = deg
+	d.mu.Unlock()
+}
+
+func (d *flakyDependency) heal() {
+	d.mu.Lock()
+	d.deg.until = time.Time{}
+	d.mu.Unlock()
+}
+
+// call simulates one request to the dependency.
+func (d *flakyDependency) call(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "dependency.call", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("peer.service", d.name)))
+	defer span.End()
+
+	deg, degraded := d.state()
+	span.SetAttributes(attribute.Bool("dependency.degraded", degraded))
+	spec := baselineLatency
+	if degraded && deg.latency.Latency > 0 {
+		spec = deg.latency
+	}
+	if err := sleepCtx(ctx, spec.sample()); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	if degraded && rand.Float64() < deg.errorRate {
+		span.SetAttributes(attribute.Bool("injected", true))
+		span.RecordError(errDependencyFailed)
+		span.SetStatus(codes.Error, errDependencyFailed.Error())
+		return errDependencyFailed
+	}
+	return nil
+}
+
+func dependencyHandler(c *gin.Context) {
+	if err := dependency.call(c.Request.Context()); err != nil {
+		if errors.Is(err, errDependencyFailed) {
+			respondError(c, err, http.StatusBadGateway)
+		} else {
+			respondError(c, err, contextErrorStatus(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"dependency": dependency.name, "status": "ok"})
+}
+
+/* -------------------------------------------------------------------------- */
+/* Admin & schedule                                                           */
+/* -------------------------------------------------------------------------- */
+
+func registerDependencyAdmin(r gin.IRouter) {
+	status := func(c *gin.Context) {
+		deg, on := dependency.state()
+		out := gin.H{"dependency": dependency.name, "degraded": on}
+		if on {
+			out["until"] = deg.until.UTC().Format(time.RFC3339)
+			out["error_rate"] = deg.errorRate
+			out["latency"] = deg.latency
+		}
+		c.JSON(http.StatusOK, out)
+	}
+	r.GET("/admin/dependency", status)
+	r.POST("/admin/dependency/degrade", func(c *gin.Context) {
+		deg, err := parseDegradation(c.DefaultQuery("for", "2m"), c.DefaultQuery("error_rate", "0.5"), c.Query("latency"))
+		if err != nil {
+			respondError(c, err, http.StatusBadRequest)
+			return
+		}
+		dependency.degrade(deg)
+		traceSpan(c.Request.Context()).AddEvent("dependency.degraded")
+		status(c)
+	})
+	r.POST("/admin/dependency/recover", func(c *gin.Context) {
+		dependency.heal()
+		traceSpan(c.Request.Context()).AddEvent("dependency.recovered")
+		status(c)
+	})
+}
+
+func parseDegradation(window, rate, latency string) (degradation, error) {
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return degradation{}, fmt.Errorf("for must be a positive duration")
+	}
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r < 0 || r > 1 {
+		return degradation{}, fmt.Errorf("error_rate must be within [0,1]")
+	}
+	deg := degradation{until: time.Now().Add(d), errorRate: r}
+	if latency != "" {
+		if deg.latency, err = parseLatencySpec(latency); err != nil {
+			return degradation{}, err
+		}
+	}
+	return deg, nil
+}
+
+// startDependencySchedule degrades the dependency every
+// DEPENDENCY_DEGRADE_EVERY for DEPENDENCY_DEGRADE_FOR (0 disables it).
+func startDependencySchedule(l *slog.Logger) func() {
+	every := envDuration("DEPENDENCY_DEGRADE_EVERY", 0)
+	if every <= 0 {
+		return func() {}
+	}
+	window := envDuration("DEPENDENCY_DEGRADE_FOR", 2*time.Minute)
+	rate := envString("DEPENDENCY_ERROR_RATE", "0.5")
+	latency := envString("DEPENDENCY_LATENCY", "")
+	if _, err := parseDegradation(window.String(), rate, latency); err != nil {
+		l.Error("dependency schedule disabled", "err", err)
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				deg, _ := parseDegradation(window.String(), rate, latency)
+				dependency.degrade(deg)
+				l.Info("dependency degraded", "dependency", dependency.name, "until", deg.until, "error_rate", deg.errorRate)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
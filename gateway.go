@@ -0,0 +1,72 @@
+// gateway.go — /v1/items, the REST mapping of ItemService via grpc-gateway:
+//   • the routes come from the google.api.http options in items.proto, so
+//     REST and gRPC share one definition
+//   • mounted on the main router (same auth, logging and request span), it
+//     forwards each call over a real gRPC connection to GRPC_LISTEN, so a
+//     trace shows the hop: HTTP server → gRPC client → gRPC server
+//   • the request span is renamed after the matched pattern
+//     (GET /v1/items/{id}) instead of gin's catch-all route
+//   • its calls carry gatewayToken and the request's tenant, so the gRPC
+//     server doesn't check them again (grpcauth.go); clients can't send
+//     that metadata through it
+//   • gRPC status codes come back as HTTP statuses (NOT_FOUND → 404) with
+//     a google.rpc.Status body; disabled along with GRPC_LISTEN=off
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	itemsv1 "github.com/micro-company/http-trace-example/proto/items/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// newGateway returns the gateway handler and a func closing its connection,
+// or a nil handler when gRPC is disabled.
+func newGateway() (http.Handler, func() error, error) {
+	addr := grpcAddr()
+	if addr == "" {
+		return nil, func() error { return nil }, nil
+	}
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mux := runtime.NewServeMux(runtime.WithMetadata(func(ctx context.Context, r *http.Request) metadata.MD {
+		if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+			span := traceSpan(ctx)
+			span.SetName(r.Method + " " + pattern)
+			span.SetAttributes(attribute.String("http.route", pattern))
+		}
+		md := metadata.Pairs(mdGatewayToken, gatewayToken)
+		if t, ok := tenantFromContext(r.Context()); ok {
+			md.Set(mdGatewayTenant, t.ID)
+			md.Set(mdGatewayTenantSource, t.Source)
+		}
+		return md
+	}), runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+		if strings.HasPrefix(strings.ToLower(key), strings.ToLower(runtime.MetadataHeaderPrefix)+"x-gateway-") {
+			return "", false
+		}
+		return runtime.DefaultHeaderMatcher(key)
+	}))
+	if err := itemsv1.RegisterItemServiceHandler(context.Background(), mux, conn); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return mux, conn.Close, nil
+}
@@ -0,0 +1,16 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package model
+
+type Item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Other items sharing a word of the name.
+	Related []*Item `json:"related"`
+}
+
+type Mutation struct {
+}
+
+type Query struct {
+}
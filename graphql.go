@@ -0,0 +1,192 @@
+// graphql.go — POST|GET /graphql, items over GraphQL (gqlgen, schema in
+//   graph/schema.graphqls, generated code in graph/):
+//   • the request span gets graphql.operation.type/.name and the document;
+//     responses carrying errors mark it failed even though they are 200s
+//   • every field with a real resolver gets a graphql.resolve <Type>.<field>
+//     span (plain struct fields do not), so { items { related { name } } }
+//     shows one Item.related span, and one store query, per item: the N+1
+//     pattern, side by side in the trace
+//   • GRAPHQL_COMPLEXITY_LIMIT (200) caps query cost, since related nests
+
+//go:generate go run github.com/99designs/gqlgen@v0.17.73 generate
+
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gin-gonic/gin"
+	"github.com/micro-company/http-trace-example/graph"
+	"github.com/micro-company/http-trace-example/graph/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func graphqlHandler() gin.HandlerFunc {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: gqlResolver{}}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(envInt("GRAPHQL_COMPLEXITY_LIMIT", 200)))
+	srv.Use(gqlTracing{})
+	return gin.WrapH(srv)
+}
+
+/* -------------------------------------------------------------------------- */
+/* Tracing extension                                                          */
+/* -------------------------------------------------------------------------- */
+
+type gqlTracing struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.FieldInterceptor
+} = gqlTracing{}
+
+func (gqlTracing) ExtensionName() string                   { return "OpenTelemetry" }
+func (gqlTracing) Validate(graphql.ExecutableSchema) error { return nil }
+
+func (gqlTracing) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	span := traceSpan(ctx)
+	oc := graphql.GetOperationContext(ctx)
+	span.SetAttributes(
+		attribute.String("graphql.operation.name", oc.OperationName),
+		attribute.String("graphql.document", oc.RawQuery),
+	)
+	if oc.Operation != nil {
+		span.SetAttributes(attribute.String("graphql.operation.type", string(oc.Operation.Operation)))
+	}
+
+	respond := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		resp := respond(ctx)
+		if resp != nil && len(resp.Errors) > 0 {
+			span.SetAttributes(attribute.Int("graphql.errors", len(resp.Errors)))
+			span.SetStatus(codes.Error, resp.Errors.Error())
+		}
+		return resp
+	}
+}
+
+func (gqlTracing) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	ctx, span := tracer.Start(ctx, "graphql.resolve "+fc.Object+"."+fc.Field.Name, trace.WithAttributes(
+		attribute.String("graphql.field.parent_type", fc.Object),
+		attribute.String("graphql.field.name", fc.Field.Name),
+		attribute.String("graphql.field.path", fc.Path().String()),
+	))
+	defer span.End()
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
+
+/* -------------------------------------------------------------------------- */
+/* Resolvers                                                                  */
+/* -------------------------------------------------------------------------- */
+
+type (
+	gqlResolver struct{}
+	gqlQuery    struct{}
+	gqlMutation struct{}
+	gqlItem     struct{}
+)
+
+func (gqlResolver) Query() graph.QueryResolver       { return gqlQuery{} }
+func (gqlResolver) Mutation() graph.MutationResolver { return gqlMutation{} }
+func (gqlResolver) Item() graph.ItemResolver         { return gqlItem{} }
+
+func toGQLItem(it Item) *model.Item {
+	return &model.Item{ID: it.ID, Name: it.Name}
+}
+
+// Item resolves Query.item; a missing item is null, not an error.
+func (gqlQuery) Item(ctx context.Context, id int) (*model.Item, error) {
+	item, err := repo.Get(ctx, id)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toGQLItem(item), nil
+}
+
+func (gqlQuery) Items(ctx context.Context) ([]*model.Item, error) {
+	items, err := repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.Item, 0, len(items))
+	for _, it := range items {
+		out = append(out, toGQLItem(it))
+	}
+	return out, nil
+}
+
+// Related lists the store once per parent item, deliberately without
+// batching (a dataloader would collapse these into one query).
+func (gqlItem) Related(ctx context.Context, obj *model.Item, limit *int) ([]*model.Item, error) {
+	items, err := repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n := 3
+	if limit != nil {
+		n = max(*limit, 0)
+	}
+	words := strings.Fields(strings.ToLower(obj.Name))
+	out := []*model.Item{}
+	for _, it := range items {
+		if len(out) == n {
+			break
+		}
+		if it.ID != obj.ID && slices.ContainsFunc(strings.Fields(strings.ToLower(it.Name)), func(w string) bool {
+			return slices.Contains(words, w)
+		}) {
+			out = append(out, toGQLItem(it))
+		}
+	}
+	return out, nil
+}
+
+func (gqlMutation) CreateItem(ctx context.Context, name string) (*model.Item, error) {
+	item, err := repo.Create(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return toGQLItem(item), nil
+}
+
+func (gqlMutation) UpdateItem(ctx context.Context, id int, name string) (*model.Item, error) {
+	item, err := repo.Update(ctx, id, func(item Item) (Item, error) {
+		item.Name = name
+		return item, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toGQLItem(item), nil
+}
+
+func (gqlMutation) DeleteItem(ctx context.Context, id int) (bool, error) {
+	if err := repo.Delete(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
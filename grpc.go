@@ -0,0 +1,152 @@
+// grpc.go — items.v1.ItemService over gRPC, next to the REST API:
+//   • listens on GRPC_LISTEN (127.0.0.1:9090; "off" disables it), backed by
+//     repo, so gRPC writes publish the same events and hit the same caches
+//   • calls pass the same auth, tenant, rate-limit and maintenance checks
+//     as HTTP requests (grpcauth.go)
+//   • otelgrpc's stats handler makes a server span per RPC (rpc.system=grpc,
+//     rpc.service, rpc.method, rpc.grpc.status_code) continuing the
+//     caller's traceparent from the metadata
+//   • store errors map onto gRPC codes the way storeErrorStatus maps them
+//     onto HTTP statuses
+//   • server reflection is on, so grpcurl needs no proto files
+
+//go:generate protoc -I proto --go_out=proto --go_opt=paths=source_relative --go-grpc_out=proto --go-grpc_opt=paths=source_relative --grpc-gateway_out=proto --grpc-gateway_opt=paths=source_relative --twirp_out=proto --twirp_opt=paths=source_relative items/v1/items.proto
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	itemsv1 "github.com/micro-company/http-trace-example/proto/items/v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// grpcItemServer adapts repo to the generated ItemServiceServer.
+type grpcItemServer struct {
+	itemsv1.UnimplementedItemServiceServer
+}
+
+func toProtoItem(it Item) *itemsv1.Item {
+	return &itemsv1.Item{Id: int64(it.ID), Name: it.Name}
+}
+
+func (grpcItemServer) CreateItem(ctx context.Context, req *itemsv1.CreateItemRequest) (*itemsv1.Item, error) {
+	item, err := repo.Create(ctx, req.GetName())
+	if err != nil {
+		return nil, storeErrorCode(err)
+	}
+	return toProtoItem(item), nil
+}
+
+func (grpcItemServer) GetItem(ctx context.Context, req *itemsv1.GetItemRequest) (*itemsv1.Item, error) {
+	traceSpan(ctx).SetAttributes(attribute.Int64("item.id", req.GetId()))
+	item, err := repo.Get(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, storeErrorCode(err)
+	}
+	return toProtoItem(item), nil
+}
+
+func (grpcItemServer) ListItems(ctx context.Context, _ *itemsv1.ListItemsRequest) (*itemsv1.ListItemsResponse, error) {
+	items, err := repo.List(ctx)
+	if err != nil {
+		return nil, storeErrorCode(err)
+	}
+	resp := &itemsv1.ListItemsResponse{Items: make([]*itemsv1.Item, 0, len(items))}
+	for _, it := range items {
+		resp.Items = append(resp.Items, toProtoItem(it))
+	}
+	return resp, nil
+}
+
+func (grpcItemServer) UpdateItem(ctx context.Context, req *itemsv1.UpdateItemRequest) (*itemsv1.Item, error) {
+	traceSpan(ctx).SetAttributes(attribute.Int64("item.id", req.GetId()))
+	item, err := repo.Update(ctx, int(req.GetId()), func(item Item) (Item, error) {
+		item.Name = req.GetName()
+		return item, nil
+	})
+	if err != nil {
+		return nil, storeErrorCode(err)
+	}
+	return toProtoItem(item), nil
+}
+
+func (grpcItemServer) DeleteItem(ctx context.Context, req *itemsv1.DeleteItemRequest) (*itemsv1.DeleteItemResponse, error) {
+	traceSpan(ctx).SetAttributes(attribute.Int64("item.id", req.GetId()))
+	if err := repo.Delete(ctx, int(req.GetId())); err != nil {
+		return nil, storeErrorCode(err)
+	}
+	return &itemsv1.DeleteItemResponse{}, nil
+}
+
+// storeErrorCode maps repository errors onto gRPC status errors.
+func storeErrorCode(err error) error {
+	switch {
+	case errors.Is(err, errNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, errQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, errInvalidName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// grpcAddr is the gRPC listen address ("" when disabled).
+func grpcAddr() string {
+	addr := envString("GRPC_LISTEN", "127.0.0.1:9090")
+	if strings.EqualFold(addr, "off") {
+		return ""
+	}
+	return addr
+}
+
+// startGRPC serves ItemService in the background; the returned func drains
+// in-flight RPCs (up to 5s, like the other auxiliary servers) and stops it.
+func startGRPC(l *slog.Logger, auth *rpcAuth) func() {
+	addr := grpcAddr()
+	if addr == "" {
+		return func() {}
+	}
+	ln, err := listen(addr)
+	if err != nil {
+		l.Error("gRPC listen", "addr", addr, "err", err)
+		return func() {}
+	}
+
+	srv := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()), grpc.UnaryInterceptor(auth.unary))
+	itemsv1.RegisterItemServiceServer(srv, grpcItemServer{})
+	reflection.Register(srv)
+	go func() {
+		l.Info("gRPC …", "addr", ln.Addr().String())
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			l.Error("gRPC server error", "err", err)
+		}
+	}()
+
+	return func() {
+		done := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			srv.Stop()
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// grpcauth.go — the HTTP middleware's checks, for calls to the gRPC server:
+//   • in the same order as on HTTP: the per-client rate limit keyed by peer
+//     IP (RESOURCE_EXHAUSTED), API_KEYS from x-api-key metadata
+//     (UNAUTHENTICATED when missing, PERMISSION_DENIED when unknown), a JWT
+//     from authorization metadata (required for every method but Get* and
+//     List*), the tenant from the token's claim or TENANT_HEADER metadata
+//     and its rate limit, then maintenance mode (UNAVAILABLE)
+//   • the limiters and tenant labels are the HTTP ones: a client or tenant
+//     has one budget across protocols, and tenant.id keeps one label set
+//   • /v1 gateway calls went through all of it as HTTP requests; they carry
+//     a per-process token and the tenant already resolved, and are neither
+//     checked nor counted twice
+//   • server reflection is a stream and stays open
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// gatewayToken marks the calls the /v1 gateway makes on behalf of HTTP
+// requests it has already let through.
+var gatewayToken = rand.Text()
+
+// Metadata the gateway adds to its calls; clients can't set it through the
+// gateway (see newGateway).
+const (
+	mdGatewayToken        = "x-gateway-token"
+	mdGatewayTenant       = "x-gateway-tenant"
+	mdGatewayTenantSource = "x-gateway-tenant-source"
+)
+
+// rpcAuth is what serve set up for HTTP; a zero field is a check that is
+// off there too.
+type rpcAuth struct {
+	keys       map[string]string
+	jwt        *jwtAuthenticator
+	clients    *rateLimiter
+	tenants    *tenantLabels // nil unless MULTI_TENANT
+	tenantRate *rateLimiter
+}
+
+func (a *rpcAuth) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.check(ctx, path.Base(info.FullMethod))
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// check returns the call's context with its tenant, or the status error
+// refusing it.
+func (a *rpcAuth) check(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	span := traceSpan(ctx)
+
+	if subtle.ConstantTimeCompare([]byte(first(mdGatewayToken)), []byte(gatewayToken)) == 1 {
+		if id := first(mdGatewayTenant); id != "" && a.tenants != nil {
+			ctx = a.withTenant(ctx, tenant{ID: id, Source: first(mdGatewayTenantSource)})
+		}
+		return ctx, nil
+	}
+
+	if a.clients != nil {
+		if ok, _ := a.clients.allow(peerIP(ctx), time.Now()); !ok {
+			span.SetAttributes(attribute.Bool("ratelimit.limited", true))
+			return ctx, status.Error(codes.ResourceExhausted, errRateLimited.Error())
+		}
+	}
+
+	if len(a.keys) > 0 {
+		presented := first("x-api-key")
+		if presented == "" {
+			return ctx, status.Error(codes.Unauthenticated, errMissingAPIKey.Error())
+		}
+		name := lookupAPIKey(a.keys, presented)
+		if name == "" {
+			return ctx, status.Error(codes.PermissionDenied, errInvalidAPIKey.Error())
+		}
+		span.SetAttributes(attribute.String("enduser.id", name))
+	}
+
+	var claim string
+	if a.jwt != nil {
+		raw, ok := bearerToken(first("authorization"))
+		switch {
+		case !ok && rpcMutation(method):
+			return ctx, status.Error(codes.Unauthenticated, errMissingToken.Error())
+		case ok:
+			claims, err := a.jwt.validate(ctx, raw)
+			if err != nil {
+				span.SetAttributes(attribute.String("auth.failure", err.Error()))
+				return ctx, status.Error(codes.Unauthenticated, errInvalidToken.Error())
+			}
+			span.SetAttributes(attribute.String("enduser.id", claims.Subject))
+			if claims.Scope != "" {
+				span.SetAttributes(attribute.String("enduser.scope", claims.Scope))
+			}
+			claim = claims.stringClaim(envString("TENANT_CLAIM", "tenant_id"))
+		}
+	}
+
+	if a.tenants != nil {
+		t := tenant{ID: claim, Source: "jwt"}
+		if t.ID == "" {
+			t = tenant{ID: first(envString("TENANT_HEADER", "X-Tenant-ID")), Source: "header"}
+		}
+		if t.ID != "" {
+			if !validTenantID(t.ID) {
+				return ctx, status.Error(codes.InvalidArgument, errInvalidTenant.Error())
+			}
+			ctx = a.withTenant(ctx, t)
+			if a.tenantRate != nil {
+				if ok, _ := a.tenantRate.allow(t.ID, time.Now()); !ok {
+					span.SetAttributes(attribute.Bool("ratelimit.limited", true))
+					quotaExceeded(ctx, "rate", a.tenantRate.rate())
+					return ctx, status.Error(codes.ResourceExhausted, errRateLimited.Error())
+				}
+			}
+		}
+	}
+
+	if maintenance.on.Load() {
+		span.SetAttributes(attribute.Bool("maintenance", true))
+		return ctx, status.Error(codes.Unavailable, errMaintenance.Error())
+	}
+	return ctx, nil
+}
+
+func (a *rpcAuth) withTenant(ctx context.Context, t tenant) context.Context {
+	t.Label = a.tenants.label(t.ID)
+	ctx = withTenant(ctx, t)
+	traceSpan(ctx).SetAttributes(tenantKey.String(t.Label), attribute.String("tenant.source", t.Source))
+	return ctx
+}
+
+// rpcMutation is the gRPC counterpart of isMutation.
+func rpcMutation(method string) bool {
+	return !strings.HasPrefix(method, "Get") && !strings.HasPrefix(method, "List")
+}
+
+// peerIP is the caller's address without the port.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,138 @@
+// hedge.go — GET /scenario/hedge?after=100ms&slow_rate=0.5 demonstrates
+//   hedged requests against the echo service: if the primary attempt has
+//   not answered after `after`, an identical hedge is sent, the first
+//   success wins and the loser is cancelled. Both attempts are sibling
+//   hedge.attempt spans; the loser is marked hedge.cancelled and the parent
+//   records hedge.winner.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type hedgeResult struct {
+	attempt string
+	err     error
+}
+
+func hedgeHandler(c *gin.Context) {
+	after, err1 := time.ParseDuration(c.DefaultQuery("after", "100ms"))
+	slowRate, err2 := strconv.ParseFloat(c.DefaultQuery("slow_rate", "0.5"), 64)
+	if err1 != nil || err2 != nil || after < 0 || slowRate < 0 || slowRate > 1 {
+		respondError(c, errors.New("want after ≥ 0 (duration) and slow_rate in [0,1]"), http.StatusBadRequest)
+		return
+	}
+	if !echoEnabled() {
+		respondError(c, errors.New("echo service disabled"), http.StatusNotFound)
+		return
+	}
+
+	ctx := c.Request.Context()
+	results := make(chan hedgeResult, 2)
+	cancels := map[string]context.CancelFunc{}
+	launch := func(name string) {
+		actx, cancel := context.WithCancel(ctx)
+		cancels[name] = cancel
+		go func() { results <- hedgeResult{name, hedgeAttempt(actx, c.Request, name, slowRate)} }()
+	}
+
+	start := time.Now()
+	launch("primary")
+	hedge := time.NewTimer(after)
+	defer hedge.Stop()
+
+	var (
+		winner  string
+		lastErr error
+		sent    bool
+		pending = 1
+	)
+	for winner == "" && (pending > 0 || !sent) {
+		select {
+		case <-hedge.C:
+			traceSpan(ctx).AddEvent("hedge.sent", trace.WithAttributes(attribute.Int64("hedge.after_ms", after.Milliseconds())))
+			launch("hedge")
+			sent = true
+			pending++
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				winner = r.attempt
+			} else if lastErr = r.err; !sent {
+				// primary failed outright: hedge immediately
+				hedge.Reset(0)
+			}
+		}
+	}
+	for name, cancel := range cancels {
+		if name != winner {
+			cancel()
+		}
+	}
+
+	traceSpan(ctx).SetAttributes(
+		attribute.Bool("hedge.sent", sent),
+		attribute.String("hedge.winner", winner),
+	)
+	if winner == "" {
+		respondError(c, fmt.Errorf("all attempts failed: %w", lastErr), http.StatusBadGateway)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"winner":     winner,
+		"hedged":     sent,
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+	})
+}
+
+// hedgeAttempt calls the echo service, slow with probability slowRate.
+func hedgeAttempt(ctx context.Context, in *http.Request, name string, slowRate float64) error {
+	delay := 10 + rand.IntN(30)
+	if rand.Float64() < slowRate {
+		delay = 400 + rand.IntN(400)
+	}
+	ctx, span := tracer.Start(ctx, "hedge.attempt", trace.WithAttributes(
+		attribute.String("hedge.attempt", name),
+		attribute.Int("hedge.planned_delay_ms", delay),
+	))
+	defer span.End()
+
+	url := fmt.Sprintf("%s/echo?attempt=%s&delay=%dms", echoURL(), name, delay)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err == nil {
+		forwardAuth(req, in)
+		var resp *http.Response
+		if resp, err = outboundOnce.Do(req); err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode >= 400 {
+				err = fmt.Errorf("echo returned %d", resp.StatusCode)
+			}
+		}
+	}
+
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// lost the race; not a failure
+			span.SetAttributes(attribute.Bool("hedge.cancelled", true))
+			span.AddEvent("hedge.cancelled")
+		} else {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+// Package httpclient is the traced outbound HTTP client shared by every
+// feature that calls another service (or the app itself):
+//   - each logical call gets an "http.client.call" span; every attempt below
+//     it is a separate client span from the wrapped transport
+//   - transient failures (network errors, 429, 502, 503, 504) are retried
+//     with capped exponential backoff and full jitter; Retry-After is honored
+//   - only requests the server may safely get twice are retried: idempotent
+//     methods (GET, HEAD, OPTIONS, TRACE, PUT, DELETE) and requests carrying
+//     an Idempotency-Key (or X-Idempotency-Key) header; a POST without one
+//     is sent once, since a failed attempt may still have been processed
+//   - retries are recorded as http.retry events with the attempt and delay
+//   - request bodies are replayed via Request.GetBody; requests without it
+//     are sent once
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const scope = "github.com/micro-company/http-trace-example/internal/httpclient"
+
+// Options configure a Client; zero values pick the defaults noted.
+type Options struct {
+	Transport   http.RoundTripper // http.DefaultTransport
+	Timeout     time.Duration     // per attempt; 0 = none
+	MaxAttempts int               // 1 disables retries; default 3
+	BaseDelay   time.Duration     // first backoff step; default 100ms
+	MaxDelay    time.Duration     // backoff cap; default 2s
+}
+
+// Client issues requests with retries. It is safe for concurrent use.
+type Client struct {
+	http   *http.Client
+	opts   Options
+	tracer trace.Tracer
+}
+
+// New returns a Client for opts.
+func New(opts Options) *Client {
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = 100 * time.Millisecond
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 2 * time.Second
+	}
+	return &Client{
+		http:   &http.Client{Transport: opts.Transport, Timeout: opts.Timeout},
+		opts:   opts,
+		tracer: otel.Tracer(scope),
+	}
+}
+
+// WithMaxAttempts returns a copy of c making at most n attempts per call.
+func (c *Client) WithMaxAttempts(n int) *Client {
+	opts := c.opts
+	opts.MaxAttempts = max(n, 1)
+	return New(opts)
+}
+
+// Do sends req, retrying transient failures. The returned response is the
+// last attempt's; as with http.Client, the caller closes its body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if c.opts.MaxAttempts == 1 || !replayable {
+		return c.http.Do(req)
+	}
+
+	ctx, span := c.tracer.Start(req.Context(), "http.client.call", trace.WithAttributes(
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", req.URL.Redacted()),
+	))
+	defer span.End()
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	attempt := 1
+	for ; ; attempt++ {
+		r := req.WithContext(ctx)
+		if attempt > 1 && req.GetBody != nil {
+			if r.Body, err = req.GetBody(); err != nil {
+				break
+			}
+		}
+
+		resp, err = c.http.Do(r)
+		if attempt == c.opts.MaxAttempts || !idempotent(req) || !retryable(ctx, resp, err) {
+			break
+		}
+
+		delay := c.backoff(attempt, resp)
+		span.AddEvent("http.retry", trace.WithAttributes(
+			attribute.Int("http.retry.attempt", attempt+1),
+			attribute.Int64("http.retry.delay_ms", delay.Milliseconds()),
+			attribute.String("http.retry.reason", reason(resp, err)),
+		))
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if werr := wait(ctx, delay); werr != nil {
+			resp, err = nil, werr
+			break
+		}
+	}
+
+	span.SetAttributes(attribute.Int("http.request.resend_count", attempt-1))
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case resp.StatusCode >= 500:
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, err
+}
+
+// idempotent reports whether sending req twice has the effect of sending
+// it once, by its method or because it names an idempotency key.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff is full-jitter exponential, or Retry-After when the server sent
+// one, never above MaxDelay.
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s >= 0 {
+			return min(time.Duration(s)*time.Second, c.opts.MaxDelay)
+		}
+	}
+	ceil := min(c.opts.BaseDelay<<(attempt-1), c.opts.MaxDelay)
+	if ceil <= 0 { // shift overflow
+		ceil = c.opts.MaxDelay
+	}
+	return rand.N(ceil) + 1
+}
+
+func reason(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("status %d", resp.StatusCode)
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,131 @@
+// itemmetrics.go — domain metrics for the item store, next to HTTP RED:
+//   • items.created / items.deleted count successful mutations, whichever
+//     API (REST, gRPC, GraphQL, JSON-RPC, WebSocket, bulk) made them
+//   • item.store.size is the number of items held
+//   • items.store.duration times each store call by operation and outcome
+//     (ok|not_found|precondition_failed|invalid_name|error), including
+//     the fake-DB latency and the decorators (cache, events, outbox)
+//   • everything carries db.system.name, the backend behind the store;
+//     the counters also carry tenant.id under MULTI_TENANT
+
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	itemsCreated, _ = meter.Int64Counter("items.created",
+		metric.WithDescription("Items created"))
+	itemsDeleted, _ = meter.Int64Counter("items.deleted",
+		metric.WithDescription("Items deleted"))
+	itemStoreDuration, _ = meter.Float64Histogram("items.store.duration",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Item store calls by operation and outcome"))
+)
+
+// meteredStore records the item metrics for every call to next.
+type meteredStore struct {
+	next    itemStore
+	backend attribute.KeyValue
+}
+
+// newMeteredStore wraps next and registers item.store.size, read from raw
+// so that observing it costs no span or injected latency.
+func newMeteredStore(next itemStore, raw *memoryStore, backend string) meteredStore {
+	s := meteredStore{next: next, backend: attribute.String("db.system.name", backend)}
+	_, _ = meter.Int64ObservableGauge("item.store.size",
+		metric.WithDescription("Items held by the store"),
+		metric.WithInt64Callback(func(ctx context.Context, o metric.Int64Observer) error {
+			n, err := raw.Count(ctx)
+			if err != nil {
+				return err
+			}
+			o.Observe(int64(n), metric.WithAttributes(s.backend))
+			return nil
+		}))
+	return s
+}
+
+// counterAttrs adds the request's tenant to the backend.
+func (s meteredStore) counterAttrs(ctx context.Context) metric.AddOption {
+	return metric.WithAttributes(append(tenantAttrs(ctx), s.backend)...)
+}
+
+func (s meteredStore) record(ctx context.Context, op string, start time.Time, err error) {
+	outcome := "ok"
+	switch {
+	case errors.Is(err, errNotFound):
+		outcome = "not_found"
+	case errors.Is(err, errPreconditionFailed):
+		outcome = "precondition_failed"
+	case errors.Is(err, errInvalidName):
+		outcome = "invalid_name"
+	case err != nil:
+		outcome = "error"
+	}
+	itemStoreDuration.Record(context.WithoutCancel(ctx), float64(time.Since(start).Microseconds())/1000,
+		metric.WithAttributes(s.backend,
+			attribute.String("operation", op),
+			attribute.String("outcome", outcome)))
+}
+
+func (s meteredStore) Get(ctx context.Context, id int) (Item, error) {
+	start := time.Now()
+	item, err := s.next.Get(ctx, id)
+	s.record(ctx, "get", start, err)
+	return item, err
+}
+
+func (s meteredStore) List(ctx context.Context) ([]Item, error) {
+	start := time.Now()
+	items, err := s.next.List(ctx)
+	s.record(ctx, "list", start, err)
+	return items, err
+}
+
+func (s meteredStore) Each(ctx context.Context, fn func(Item) error) error {
+	start := time.Now()
+	err := s.next.Each(ctx, fn)
+	s.record(ctx, "list", start, err)
+	return err
+}
+
+func (s meteredStore) Create(ctx context.Context, name string) (Item, error) {
+	start := time.Now()
+	item, err := s.next.Create(ctx, name)
+	s.record(ctx, "create", start, err)
+	if err == nil {
+		itemsCreated.Add(context.WithoutCancel(ctx), 1, s.counterAttrs(ctx))
+	}
+	return item, err
+}
+
+func (s meteredStore) Update(ctx context.Context, id int, fn func(Item) (Item, error)) (Item, error) {
+	start := time.Now()
+	item, err := s.next.Update(ctx, id, fn)
+	s.record(ctx, "update", start, err)
+	return item, err
+}
+
+func (s meteredStore) Delete(ctx context.Context, id int) error {
+	start := time.Now()
+	err := s.next.Delete(ctx, id)
+	s.record(ctx, "delete", start, err)
+	if err == nil {
+		itemsDeleted.Add(context.WithoutCancel(ctx), 1, s.counterAttrs(ctx))
+	}
+	return err
+}
+
+func (s meteredStore) Count(ctx context.Context) (int, error) {
+	start := time.Now()
+	n, err := s.next.Count(ctx)
+	s.record(ctx, "count", start, err)
+	return n, err
+}
@@ -0,0 +1,161 @@
+// jobs.go — asynchronous jobs:
+//   • POST /items/import-async takes the same body as /items/bulk, answers
+//     202 with Location: /jobs/<id> and creates the items on the worker
+//     pool (503 when its queue is full)
+//   • GET /jobs/:id reports queued|running|succeeded|failed and the result
+//   • each job runs under its own root span (job.run <kind>) linked to the
+//     submitting request's span, so a slow import does not stretch the
+//     request's trace; both trace IDs are in the job status
+//   • an import runs for the submitter's tenant and flag targeting, so
+//     TENANT_MAX_ITEMS and strict-item-names hold as on /items/bulk
+//   • finished jobs are forgotten after JOB_RETENTION (10m)
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type job struct {
+	ID            string     `json:"id"`
+	Kind          string     `json:"kind"`
+	Status        string     `json:"status"` // queued|running|succeeded|failed
+	SubmittedAt   time.Time  `json:"submitted_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	SubmitTraceID string     `json:"submit_trace_id"`
+	TraceID       string     `json:"trace_id,omitempty"`
+	Result        any        `json:"result,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+var (
+	jobsMu sync.Mutex
+	jobs   = map[string]*job{}
+)
+
+// submitJob registers a job running fn and answers 202 pointing at it.
+func submitJob(c *gin.Context, kind string, fn func(ctx context.Context) (any, error)) {
+	submit := traceSpan(c.Request.Context())
+	j := &job{
+		ID:            uuid.NewString(),
+		Kind:          kind,
+		Status:        "queued",
+		SubmittedAt:   time.Now().UTC(),
+		SubmitTraceID: submit.SpanContext().TraceID().String(),
+	}
+	jobsMu.Lock()
+	pruneJobs(j.SubmittedAt)
+	jobs[j.ID] = j
+	jobsMu.Unlock()
+
+	link := trace.Link{SpanContext: submit.SpanContext()}
+	if err := jobPool.Submit(kind, func(wait time.Duration) { runJob(j, wait, link, fn) }); err != nil {
+		jobsMu.Lock()
+		delete(jobs, j.ID)
+		jobsMu.Unlock()
+		c.Header("Retry-After", "1")
+		respondError(c, err, http.StatusServiceUnavailable)
+		return
+	}
+	submit.SetAttributes(attribute.String("job.id", j.ID), attribute.String("job.kind", kind))
+
+	c.Header("Location", "/jobs/"+j.ID)
+	c.JSON(http.StatusAccepted, gin.H{"id": j.ID, "status": j.Status, "status_url": "/jobs/" + j.ID})
+}
+
+func runJob(j *job, wait time.Duration, link trace.Link, fn func(ctx context.Context) (any, error)) {
+	ctx, span := tracer.Start(context.Background(), "job.run "+j.Kind,
+		trace.WithNewRoot(),
+		trace.WithLinks(link),
+		trace.WithAttributes(
+			attribute.String("job.id", j.ID),
+			attribute.String("job.kind", j.Kind),
+			attribute.Int64("job.queue_wait_ms", wait.Milliseconds()),
+		))
+	defer span.End()
+
+	started := time.Now().UTC()
+	jobsMu.Lock()
+	j.Status, j.StartedAt, j.TraceID = "running", &started, span.SpanContext().TraceID().String()
+	jobsMu.Unlock()
+
+	result, err := fn(ctx)
+
+	finished := time.Now().UTC()
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	j.Result, j.FinishedAt, j.Status = result, &finished, "succeeded"
+	if err != nil {
+		j.Status, j.Error = "failed", err.Error()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.SetAttributes(attribute.String("job.status", j.Status))
+}
+
+// pruneJobs drops jobs finished longer than JOB_RETENTION ago and returns
+// how many; callers hold jobsMu.
+func pruneJobs(now time.Time) (n int) {
+	retention := envDuration("JOB_RETENTION", 10*time.Minute)
+	for id, j := range jobs {
+		if j.FinishedAt != nil && now.Sub(*j.FinishedAt) > retention {
+			delete(jobs, id)
+			n++
+		}
+	}
+	return n
+}
+
+func getJob(c *gin.Context) {
+	jobsMu.Lock()
+	j, ok := jobs[c.Param("id")]
+	var out job
+	if ok {
+		out = *j
+	}
+	jobsMu.Unlock()
+	if !ok {
+		respondError(c, errNotFound, http.StatusNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// importItemsAsync is /items/bulk as a job.
+func importItemsAsync(c *gin.Context) {
+	names, failRate, ok := bindBulk(c)
+	if !ok {
+		return
+	}
+	// the job's context starts empty: carry over whom it runs for
+	targeting := requestFlagContext(c.Request.Context())
+	t, tenanted := tenantFromContext(c.Request.Context())
+	submitJob(c, "import", func(ctx context.Context) (any, error) {
+		ctx = withFlagContext(ctx, targeting)
+		if tenanted {
+			ctx = withTenant(ctx, t)
+			trace.SpanFromContext(ctx).SetAttributes(tenantAttrs(ctx)...)
+		}
+		results, failed := createBulk(ctx, names, failRate)
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.Int("bulk.total", len(names)),
+			attribute.Int("bulk.failed", failed),
+		)
+		result := gin.H{"created": len(names) - failed, "failed": failed, "results": results}
+		if failed == len(names) {
+			return result, errors.New("no items imported")
+		}
+		return result, nil
+	})
+}
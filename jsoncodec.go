@@ -0,0 +1,23 @@
+// jsoncodec.go — the JSON library behind the CRUD endpoints, chosen at build
+//   time with the tags gin itself uses, so one tag switches gin's c.JSON
+//   and ShouldBindJSON together with the list stream (itemstream.go):
+//       go build -tags jsoniter .       # github.com/json-iterator/go
+//       go build -tags go_json .        # github.com/goccy/go-json
+//       go build -tags "sonic avx" .    # github.com/bytedance/sonic (amd64)
+//   • without a tag it is encoding/json; all three are configured to
+//     produce the same bytes
+//   • the codec shows in GET /version (json_codec) and `app bench json`
+//     measures it against encoding/json (bench.go)
+
+//go:build !jsoniter && !go_json && !(sonic && avx && (linux || windows || darwin) && amd64)
+
+package main
+
+import "encoding/json"
+
+const jsonCodec = "encoding/json"
+
+var (
+	jsonMarshal   = json.Marshal
+	jsonUnmarshal = json.Unmarshal
+)
@@ -0,0 +1,12 @@
+//go:build go_json
+
+package main
+
+import json "github.com/goccy/go-json"
+
+const jsonCodec = "go-json"
+
+var (
+	jsonMarshal   = json.Marshal
+	jsonUnmarshal = json.Unmarshal
+)
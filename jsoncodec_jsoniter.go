@@ -0,0 +1,12 @@
+//go:build jsoniter
+
+package main
+
+import jsoniter "github.com/json-iterator/go"
+
+const jsonCodec = "jsoniter"
+
+var (
+	jsonMarshal   = jsoniter.ConfigCompatibleWithStandardLibrary.Marshal
+	jsonUnmarshal = jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal
+)
@@ -0,0 +1,12 @@
+//go:build sonic && avx && (linux || windows || darwin) && amd64
+
+package main
+
+import "github.com/bytedance/sonic"
+
+const jsonCodec = "sonic"
+
+var (
+	jsonMarshal   = sonic.ConfigStd.Marshal
+	jsonUnmarshal = sonic.ConfigStd.Unmarshal
+)
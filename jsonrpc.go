@@ -0,0 +1,227 @@
+// jsonrpc.go — POST /rpc speaks JSON-RPC 2.0 for item operations:
+//   • methods items.create {name}, items.get {id}, items.list,
+//     items.update {id,name}, items.delete {id}; params by name
+//   • batches and notifications (no id, no answer) per the spec; transport
+//     is always 200, failures are in the error member
+//   • each call is a span named after its method, with rpc.system=jsonrpc,
+//     rpc.jsonrpc.request_id and rpc.jsonrpc.error_code, under the HTTP
+//     request span; a batch shows as siblings
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcNotFound       = -32001 // implementation-defined: no such item
+	rpcQuotaExceeded  = -32002 // implementation-defined: tenant item quota
+)
+
+const maxRPCBatch = 100
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent: notification
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcMethod func(ctx context.Context, params json.RawMessage) (any, error)
+
+var rpcMethods = map[string]rpcMethod{
+	"items.create": func(ctx context.Context, params json.RawMessage) (any, error) {
+		var p struct{ Name string }
+		if err := bindRPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return repo.Create(ctx, p.Name)
+	},
+	"items.get": func(ctx context.Context, params json.RawMessage) (any, error) {
+		var p struct{ ID int }
+		if err := bindRPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return repo.Get(ctx, p.ID)
+	},
+	"items.list": func(ctx context.Context, _ json.RawMessage) (any, error) {
+		return repo.List(ctx)
+	},
+	"items.update": func(ctx context.Context, params json.RawMessage) (any, error) {
+		var p struct {
+			ID   int
+			Name string
+		}
+		if err := bindRPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return repo.Update(ctx, p.ID, func(item Item) (Item, error) {
+			item.Name = p.Name
+			return item, nil
+		})
+	},
+	"items.delete": func(ctx context.Context, params json.RawMessage) (any, error) {
+		var p struct{ ID int }
+		if err := bindRPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := repo.Delete(ctx, p.ID); err != nil {
+			return nil, err
+		}
+		return true, nil
+	},
+}
+
+func bindRPCParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{rpcInvalidParams, "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+func jsonRPCHandler(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest)
+		return
+	}
+	ctx := c.Request.Context()
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			c.JSON(http.StatusOK, rpcFailure(nil, &rpcError{rpcParseError, "parse error"}))
+			return
+		}
+		if len(batch) == 0 || len(batch) > maxRPCBatch {
+			c.JSON(http.StatusOK, rpcFailure(nil, &rpcError{rpcInvalidRequest, "batch must hold 1 to " + strconv.Itoa(maxRPCBatch) + " calls"}))
+			return
+		}
+		traceSpan(ctx).SetAttributes(attribute.Int("rpc.jsonrpc.batch_size", len(batch)))
+		out := []rpcResponse{}
+		for _, raw := range batch {
+			if resp, ok := callRPC(ctx, raw); ok {
+				out = append(out, resp)
+			}
+		}
+		if len(out) == 0 {
+			c.Status(http.StatusNoContent) // all notifications
+			return
+		}
+		c.JSON(http.StatusOK, out)
+		return
+	}
+
+	if resp, ok := callRPC(ctx, body); ok {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// callRPC runs one call in a span named after its method; ok is false for
+// notifications, which get no response.
+func callRPC(ctx context.Context, raw json.RawMessage) (resp rpcResponse, ok bool) {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			return rpcFailure(nil, &rpcError{rpcParseError, "parse error"}), true
+		}
+		return rpcFailure(nil, &rpcError{rpcInvalidRequest, "invalid request"}), true
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcFailure(req.ID, &rpcError{rpcInvalidRequest, `invalid request: need "jsonrpc":"2.0" and a method`}), true
+	}
+	notification := len(req.ID) == 0
+	m, found := rpcMethods[req.Method]
+	name := req.Method
+	if !found {
+		name = "jsonrpc.unknown_method" // keep span names bounded
+	}
+
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("rpc.method", req.Method),
+		attribute.String("rpc.jsonrpc.version", "2.0"),
+		attribute.String("rpc.jsonrpc.request_id", string(req.ID)),
+		attribute.Bool("rpc.jsonrpc.notification", notification),
+	))
+	defer span.End()
+
+	var (
+		result any
+		err    error
+	)
+	if found {
+		result, err = m(ctx, req.Params)
+	} else {
+		err = &rpcError{rpcMethodNotFound, "method not found: " + req.Method}
+	}
+	if err != nil {
+		rerr := toRPCError(err)
+		span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", rerr.Code))
+		span.SetStatus(codes.Error, rerr.Message)
+		if rerr.Code == rpcInternalError {
+			span.RecordError(err)
+		}
+		return rpcFailure(req.ID, rerr), !notification
+	}
+	return rpcResponse{JSONRPC: "2.0", Result: result, ID: req.ID}, !notification
+}
+
+func toRPCError(err error) *rpcError {
+	var rerr *rpcError
+	switch {
+	case errors.As(err, &rerr):
+		return rerr
+	case errors.Is(err, errNotFound):
+		return &rpcError{rpcNotFound, err.Error()}
+	case errors.Is(err, errQuotaExceeded):
+		return &rpcError{rpcQuotaExceeded, err.Error()}
+	case errors.Is(err, errInvalidName):
+		return &rpcError{rpcInvalidParams, err.Error()}
+	}
+	return &rpcError{rpcInternalError, err.Error()}
+}
+
+func rpcFailure(id json.RawMessage, err *rpcError) rpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return rpcResponse{JSONRPC: "2.0", Error: err, ID: id}
+}
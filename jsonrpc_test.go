@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJSONRPCBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	call := func(id, method, params string) string {
+		s := `{"jsonrpc":"2.0","method":"` + method + `"`
+		if params != "" {
+			s += `,"params":` + params
+		}
+		if id != "" {
+			s += `,"id":` + id
+		}
+		return s + "}"
+	}
+	tooMany := make([]string, maxRPCBatch+1)
+	for i := range tooMany {
+		tooMany[i] = call(fmt.Sprint(i), "items.list", "")
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		batch  bool     // the answer is an array
+		want   []string // "id code" per response, code 0 for a result
+	}{
+		{
+			name:   "single call",
+			body:   call("1", "items.get", `{"id":1}`),
+			status: http.StatusOK, want: []string{"1 0"},
+		},
+		{
+			name:   "answers keep the batch order",
+			body:   "[" + call(`"a"`, "items.create", `{"name":"cap"}`) + "," + call(`"b"`, "items.get", `{"id":2}`) + "]",
+			status: http.StatusOK, batch: true, want: []string{`"a" 0`, `"b" 0`},
+		},
+		{
+			name:   "each call fails on its own",
+			body:   "[" + call("1", "items.get", `{"id":99}`) + "," + call("2", "items.nope", "") + "," + call("3", "items.get", `{"id":1}`) + "]",
+			status: http.StatusOK, batch: true, want: []string{"1 -32001", "2 -32601", "3 0"},
+		},
+		{
+			name:   "notifications get no answer",
+			body:   "[" + call("", "items.list", "") + "," + call("7", "items.list", "") + "]",
+			status: http.StatusOK, batch: true, want: []string{"7 0"},
+		},
+		{
+			name:   "a batch of notifications only",
+			body:   "[" + call("", "items.list", "") + "," + call("", "items.get", `{"id":1}`) + "]",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "an invalid member",
+			body:   `[1,` + call("2", "items.list", "") + `]`,
+			status: http.StatusOK, batch: true, want: []string{"null -32600", "2 0"},
+		},
+		{
+			name:   "empty batch",
+			body:   `[]`,
+			status: http.StatusOK, want: []string{"null -32600"},
+		},
+		{
+			name:   "batch over the limit",
+			body:   "[" + strings.Join(tooMany, ",") + "]",
+			status: http.StatusOK, want: []string{"null -32600"},
+		},
+		{
+			name:   "batch that is not JSON",
+			body:   `[{"jsonrpc":"2.0",`,
+			status: http.StatusOK, want: []string{"null -32700"},
+		},
+	}
+
+	r := gin.New()
+	r.POST("/rpc", jsonRPCHandler)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			_, _ = store.Create(context.Background(), "pen")
+			useRepo(t, store)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == http.StatusNoContent {
+				if w.Body.Len() > 0 {
+					t.Errorf("204 with a body: %s", w.Body)
+				}
+				return
+			}
+
+			var resps []rpcResponse
+			body := w.Body.Bytes()
+			if isBatch := len(body) > 0 && body[0] == '['; isBatch != tt.batch {
+				t.Fatalf("answer is an array: %v, want %v: %s", isBatch, tt.batch, body)
+			}
+			if !tt.batch {
+				body = append(append([]byte("["), body...), ']')
+			}
+			if err := json.Unmarshal(body, &resps); err != nil {
+				t.Fatalf("decoding %s: %v", w.Body, err)
+			}
+			var got []string
+			for _, resp := range resps {
+				code := 0
+				if resp.Error != nil {
+					code = resp.Error.Code
+				}
+				got = append(got, fmt.Sprintf("%s %d", resp.ID, code))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("answers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
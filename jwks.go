@@ -0,0 +1,218 @@
+// jwks.go — JSON Web Key Set fetching & caching:
+//   • RSA and EC public keys, looked up by "kid"
+//   • unknown kid or expired set (JWKS_CACHE_TTL) triggers a refetch,
+//     rate-limited by minRefresh
+//   • jwks.refresh spans plus cache hit/miss, refresh & key-count metrics
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/micro-company/http-trace-example/internal/httpclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwksCache struct {
+	url        string
+	client     *httpclient.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	mu      sync.RWMutex
+	keys    map[string]any
+	fetched time.Time
+
+	lookups   metric.Int64Counter
+	refreshes metric.Int64Counter
+}
+
+func newJWKSCache(url string) *jwksCache {
+	j := &jwksCache{
+		url:        url,
+		client:     newOutboundClient(5 * time.Second),
+		ttl:        envDuration("JWKS_CACHE_TTL", time.Hour),
+		minRefresh: time.Minute,
+		keys:       make(map[string]any),
+	}
+
+	j.lookups, _ = meter.Int64Counter("jwks.cache.lookups",
+		metric.WithDescription("JWKS key lookups by result (hit/miss)"))
+	j.refreshes, _ = meter.Int64Counter("jwks.refreshes",
+		metric.WithDescription("JWKS fetches by result (ok/error)"))
+	_, _ = meter.Int64ObservableGauge("jwks.cache.keys",
+		metric.WithDescription("Signing keys currently cached"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			j.mu.RLock()
+			defer j.mu.RUnlock()
+			o.Observe(int64(len(j.keys)))
+			return nil
+		}))
+	return j
+}
+
+// keyfunc returns a jwt.Keyfunc whose refetches are traced under ctx.
+func (j *jwksCache) keyfunc(ctx context.Context) jwt.Keyfunc {
+	return func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if k, ok := j.lookup(kid); ok {
+			j.lookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "hit")))
+			return k, nil
+		}
+		j.lookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "miss")))
+
+		if err := j.refresh(ctx); err != nil {
+			return nil, err
+		}
+		if k, ok := j.lookup(kid); ok {
+			return k, nil
+		}
+		return nil, fmt.Errorf("no JWKS key for kid %q", kid)
+	}
+}
+
+func (j *jwksCache) lookup(kid string) (any, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if time.Since(j.fetched) > j.ttl {
+		return nil, false
+	}
+	if k, ok := j.keys[kid]; ok {
+		return k, true
+	}
+	// tokens without a kid are accepted when the set has exactly one key
+	if kid == "" && len(j.keys) == 1 {
+		for _, k := range j.keys {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+func (j *jwksCache) refresh(ctx context.Context) (err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if time.Since(j.fetched) < j.minRefresh {
+		return nil
+	}
+	j.fetched = time.Now()
+
+	ctx, span := tracer.Start(ctx, "jwks.refresh",
+		trace.WithAttributes(attribute.String("url.full", j.url)))
+	defer func() {
+		result := "ok"
+		if err != nil {
+			result = "error"
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.SetAttributes(attribute.Int("jwks.keys", len(j.keys)))
+		j.refreshes.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching JWKS: unexpected status %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue // skip key types we cannot use
+		}
+		keys[k.Kid] = pub
+	}
+	j.keys = keys
+	return nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := b64Int(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64Int(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64Int(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64Int(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, errors.New("unsupported key type " + k.Kty)
+}
+
+func b64Int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,187 @@
+// jwt.go — JWT bearer authentication:
+//   • HS256/384/512 via JWT_HMAC_SECRET, RS*/ES* keys from JWT_JWKS_URL,
+//     or an OIDC issuer (OIDC_ISSUER_URL, see oidc.go)
+//   • optional JWT_ISSUER / JWT_AUDIENCE checks
+//   • each check runs in a jwt.validate child span
+//   • mutations (POST/PUT/PATCH/DELETE) require a valid token, except on
+//     the probes and, once admin credentials are set, the admin routes
+//   • sub & scope claims copied onto the span and request log line; the
+//     TENANT_CLAIM claim is handed to tenantContext under MULTI_TENANT
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// ctxEndUserScope is the gin context key holding the token's scope claim.
+const ctxEndUserScope = "enduser.scope"
+
+var (
+	errMissingToken = errors.New("missing bearer token")
+	errInvalidToken = errors.New("invalid bearer token")
+)
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope"`
+
+	all map[string]any // every claim, for the configurable ones
+}
+
+func (c *tokenClaims) UnmarshalJSON(b []byte) error {
+	type plain tokenClaims // without this method
+	if err := json.Unmarshal(b, (*plain)(c)); err != nil {
+		return err
+	}
+	return json.Unmarshal(b, &c.all)
+}
+
+// stringClaim returns the named claim when it is a string.
+func (c *tokenClaims) stringClaim(name string) string {
+	s, _ := c.all[name].(string)
+	return s
+}
+
+type jwtAuthenticator struct {
+	keyfunc func(context.Context) jwt.Keyfunc
+	parser  *jwt.Parser
+	source  string // hmac | jwks | oidc
+}
+
+/* -------------------------------------------------------------------------- */
+/* Setup                                                                      */
+/* -------------------------------------------------------------------------- */
+
+// newJWTAuthenticator returns nil when no key source is configured.
+func newJWTAuthenticator(ctx context.Context) (*jwtAuthenticator, error) {
+	issuer := envString("JWT_ISSUER", "")
+	audience := envString("JWT_AUDIENCE", "")
+	asymmetric := jwt.WithValidMethods([]string{
+		"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512",
+	})
+
+	a := &jwtAuthenticator{}
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+
+	switch {
+	case envString("JWT_HMAC_SECRET", "") != "":
+		secret := []byte(envString("JWT_HMAC_SECRET", ""))
+		a.source = "hmac"
+		a.keyfunc = func(context.Context) jwt.Keyfunc {
+			return func(*jwt.Token) (any, error) { return secret, nil }
+		}
+		opts = append(opts, jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}))
+	case envString("JWT_JWKS_URL", "") != "":
+		a.source = "jwks"
+		a.keyfunc = newJWKSCache(envString("JWT_JWKS_URL", "")).keyfunc
+		opts = append(opts, asymmetric)
+	case envString("OIDC_ISSUER_URL", "") != "":
+		d, err := discoverOIDC(ctx, envString("OIDC_ISSUER_URL", ""))
+		if err != nil {
+			return nil, err
+		}
+		a.source = "oidc"
+		a.keyfunc = newJWKSCache(d.JWKSURI).keyfunc
+		opts = append(opts, asymmetric)
+		issuer = d.Issuer
+		if aud := envString("OIDC_CLIENT_ID", ""); aud != "" && audience == "" {
+			audience = aud
+		}
+	default:
+		return nil, nil
+	}
+
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+	return a, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* Middleware                                                                 */
+/* -------------------------------------------------------------------------- */
+
+func (a *jwtAuthenticator) middleware() gin.HandlerFunc {
+	tenantClaim := envString("TENANT_CLAIM", "tenant_id")
+	return func(c *gin.Context) {
+		if callerAuthExempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		raw, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			if isMutation(c.Request.Method) {
+				respondError(c, errMissingToken, http.StatusUnauthorized)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		claims, err := a.validate(c.Request.Context(), raw)
+		if err != nil {
+			traceSpan(c.Request.Context()).SetAttributes(attribute.String("auth.failure", err.Error()))
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			respondError(c, errInvalidToken, http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		setEndUser(c, claims.Subject)
+		if claims.Scope != "" {
+			c.Set(ctxEndUserScope, claims.Scope)
+			traceSpan(c.Request.Context()).SetAttributes(attribute.String("enduser.scope", claims.Scope))
+		}
+		if t := claims.stringClaim(tenantClaim); t != "" {
+			c.Set(ctxTenantClaim, t)
+		}
+		c.Next()
+	}
+}
+
+func (a *jwtAuthenticator) validate(ctx context.Context, raw string) (*tokenClaims, error) {
+	ctx, span := tracer.Start(ctx, "jwt.validate")
+	defer span.End()
+	span.SetAttributes(attribute.String("auth.key_source", a.source))
+
+	var claims tokenClaims
+	tok, err := a.parser.ParseWithClaims(raw, &claims, a.keyfunc(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "token rejected")
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("auth.alg", tok.Method.Alg()))
+	return &claims, nil
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
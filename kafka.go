@@ -0,0 +1,216 @@
+// kafka.go — Kafka transport for item events (segmentio/kafka-go):
+//   • KAFKA_BROKERS (comma-separated) + KAFKA_TOPIC (item-events)
+//   • structured CloudEvents (application/cloudevents+json), keyed by item
+//   • a PRODUCER span per message; W3C trace context is injected into the
+//     record headers so consumers can continue the trace
+//   • outside the outbox the writer is async: Publish only queues the
+//     message, and the span ends (with any error) once the broker answers;
+//     kafka.published counts the outcomes
+//   • KAFKA_CONSUME=true runs a consumer-group worker (KAFKA_GROUP_ID) that
+//     extracts it again and processes each event in a CONSUMER span
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const cloudEventsJSON = "application/cloudevents+json"
+
+// kafkaHeaders adapts record headers to a TextMapCarrier.
+type kafkaHeaders []kafka.Header
+
+var _ propagation.TextMapCarrier = (*kafkaHeaders)(nil)
+
+func (h *kafkaHeaders) Get(key string) string {
+	for _, hdr := range *h {
+		if hdr.Key == key {
+			return string(hdr.Value)
+		}
+	}
+	return ""
+}
+
+func (h *kafkaHeaders) Set(key, value string) {
+	for i, hdr := range *h {
+		if hdr.Key == key {
+			(*h)[i].Value = []byte(value)
+			return
+		}
+	}
+	*h = append(*h, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (h *kafkaHeaders) Keys() []string {
+	keys := make([]string, len(*h))
+	for i, hdr := range *h {
+		keys[i] = hdr.Key
+	}
+	return keys
+}
+
+var kafkaPublished, _ = meter.Int64Counter("kafka.published",
+	metric.WithDescription("Kafka event writes by result (delivered|failed)"))
+
+type kafkaPublisher struct {
+	w     *kafka.Writer
+	topic string
+}
+
+// newKafkaPublisher with async returns from Publish without waiting for
+// the broker, so a slow broker never holds up a mutation; the outbox relay
+// needs to know a row was delivered and uses a synchronous writer.
+func newKafkaPublisher(brokers []string, topic string, async bool) *kafkaPublisher {
+	p := &kafkaPublisher{
+		topic: topic,
+		w: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			BatchTimeout:           10 * time.Millisecond,
+			WriteTimeout:           5 * time.Second,
+			RequiredAcks:           kafka.RequireOne,
+			AllowAutoTopicCreation: true,
+			Async:                  async,
+		},
+	}
+	if async {
+		p.w.Completion = p.completed
+	}
+	return p
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, ev itemEvent) error {
+	ctx, span := tracer.Start(ctx, p.topic+" publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation.type", "send"),
+			attribute.String("messaging.destination.name", p.topic),
+			attribute.String("messaging.message.id", ev.ID),
+			attribute.String("cloudevents.event_type", ev.Type),
+		))
+
+	value, err := json.Marshal(ev)
+	if err != nil {
+		span.End()
+		return err
+	}
+	headers := kafkaHeaders{{Key: "content-type", Value: []byte(cloudEventsJSON)}}
+	otel.GetTextMapPropagator().Inject(ctx, &headers)
+
+	err = p.w.WriteMessages(ctx, kafka.Message{
+		Key:        []byte(ev.Subject),
+		Value:      value,
+		Headers:    headers,
+		WriterData: span,
+	})
+	if err != nil || !p.w.Async {
+		// queued async writes are finished by completed instead
+		finishKafkaWrite(span, err)
+	}
+	return err
+}
+
+// completed is the async writer's Completion callback; every message in a
+// call went to the same partition and shares err.
+func (p *kafkaPublisher) completed(msgs []kafka.Message, err error) {
+	for _, m := range msgs {
+		if span, ok := m.WriterData.(trace.Span); ok {
+			finishKafkaWrite(span, err)
+		}
+	}
+}
+
+func finishKafkaWrite(span trace.Span, err error) {
+	result := "delivered"
+	if err != nil {
+		result = "failed"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	kafkaPublished.Add(context.Background(), 1, metric.WithAttributes(attribute.String("result", result)))
+	span.End()
+}
+
+// Close flushes queued async writes before closing the connections.
+func (p *kafkaPublisher) Close() error { return p.w.Close() }
+
+/* -------------------------------------------------------------------------- */
+/* Consumer                                                                   */
+/* -------------------------------------------------------------------------- */
+
+// startKafkaConsumer runs the worker when KAFKA_CONSUME is set; the returned
+// func stops it after the in-progress message.
+func startKafkaConsumer(l *slog.Logger) func() {
+	brokers := envList("KAFKA_BROKERS")
+	if !envBool("KAFKA_CONSUME", false) || len(brokers) == 0 {
+		return func() {}
+	}
+	topic := envString("KAFKA_TOPIC", "item-events")
+	group := envString("KAFKA_GROUP_ID", "item-events-worker")
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: brokers,
+		Topic:   topic,
+		GroupID: group,
+		MaxWait: time.Second,
+	})
+	l = l.With("topic", topic, "group", group)
+	l.Info("kafka consumer started")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer r.Close()
+		for {
+			msg, err := r.FetchMessage(ctx)
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					return
+				}
+				l.Warn("kafka fetch", "err", err)
+				if sleepCtx(ctx, time.Second) != nil {
+					return
+				}
+				continue
+			}
+			consumeKafkaMessage(l, group, msg)
+			if err := r.CommitMessages(ctx, msg); err != nil && !errors.Is(err, context.Canceled) {
+				l.Warn("kafka commit", "err", err)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func consumeKafkaMessage(l *slog.Logger, group string, msg kafka.Message) {
+	headers := kafkaHeaders(msg.Headers)
+	producer := otel.GetTextMapPropagator().Extract(context.Background(), &headers)
+	parent, opts := consumeSpanOptions(producer,
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.operation.type", "process"),
+		attribute.String("messaging.destination.name", msg.Topic),
+		attribute.String("messaging.consumer.group.name", group),
+		attribute.Int("messaging.destination.partition.id", msg.Partition),
+		attribute.Int64("messaging.kafka.offset", msg.Offset),
+	)
+	ctx, span := tracer.Start(parent, msg.Topic+" process", opts...)
+	defer span.End()
+
+	handleItemEvent(ctx, l.With("offset", msg.Offset), "kafka", msg.Value)
+}
@@ -0,0 +1,42 @@
+// listen.go — listener selection from an address string:
+//   ":8080", "tcp://0.0.0.0:8080" or "unix:///tmp/app.sock".
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+// listenAddr is LISTEN, or HOST:PORT (PORT 8080 by default) when unset.
+func listenAddr() string {
+	if addr := envString("LISTEN", ""); addr != "" {
+		return addr
+	}
+	return net.JoinHostPort(envString("HOST", ""), envString("PORT", "8080"))
+}
+
+// listen opens addr; for unix sockets a stale socket file is replaced and
+// the returned listener removes it again on Close.
+func listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		ln.(*net.UnixListener).SetUnlinkOnClose(true)
+		// let a reverse proxy running as another user in our group connect
+		if err := os.Chmod(path, 0o660); err != nil {
+			_ = ln.Close()
+			return nil, err
+		}
+		return ln, nil
+	}
+	return net.Listen("tcp", strings.TrimPrefix(addr, "tcp://"))
+}
@@ -0,0 +1,250 @@
+// loadgen.go — built-in traffic so dashboards populate themselves in demos:
+//   • LOADGEN_PROFILE=steady|spike|ramp|diurnal turns it on
+//   • LOADGEN_RPS is the peak rate, LOADGEN_PERIOD the profile cycle
+//   • each request runs under its own loadgen.request root span and goes
+//     through the outbound client, so traces look like a real caller's
+//   • loadgen.requests counts requests by profile and status class
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var loadRequests, _ = meter.Int64Counter("loadgen.requests",
+	metric.WithDescription("Requests issued by the built-in load generator"))
+
+type loadGenerator struct {
+	profile     string
+	rps         float64
+	period      time.Duration
+	target      string
+	auth        func(*http.Request)
+	concurrency chan struct{}
+	maxID       atomic.Int64 // highest ID seen in a create response
+	start       time.Time
+	report      *loadReport // per-request outcomes for `app load`; may be nil
+}
+
+// newLoadGenerator returns nil when LOADGEN_PROFILE is unset.
+func newLoadGenerator() (*loadGenerator, error) {
+	profile := envString("LOADGEN_PROFILE", "")
+	if profile == "" {
+		return nil, nil
+	}
+	g := &loadGenerator{
+		profile:     profile,
+		rps:         envFloat("LOADGEN_RPS", 5),
+		period:      envDuration("LOADGEN_PERIOD", 10*time.Minute),
+		target:      envString("LOADGEN_TARGET", ""),
+		auth:        staticAuth(envString("LOADGEN_API_KEY", ""), envString("LOADGEN_BEARER_TOKEN", "")),
+		concurrency: make(chan struct{}, max(1, envInt("LOADGEN_CONCURRENCY", 32))),
+	}
+	if g.target == "" {
+		target, err := selfURL()
+		if err != nil {
+			return nil, fmt.Errorf("LOADGEN_TARGET: %w", err)
+		}
+		g.target = target
+	}
+	if err := g.validate(); err != nil {
+		return nil, fmt.Errorf("LOADGEN_*: %w", err)
+	}
+	return g, nil
+}
+
+// validate checks the settings shared with `app load`.
+func (g *loadGenerator) validate() error {
+	switch g.profile {
+	case "steady", "spike", "ramp", "diurnal":
+	default:
+		return fmt.Errorf("profile %q: want steady|spike|ramp|diurnal", g.profile)
+	}
+	if g.rps <= 0 || g.period <= 0 {
+		return fmt.Errorf("rps and period must be positive")
+	}
+	return nil
+}
+
+// rate is the target requests/second at elapsed time t.
+func (g *loadGenerator) rate(t time.Duration) float64 {
+	phase := float64(t%g.period) / float64(g.period)
+	switch g.profile {
+	case "spike":
+		// a fifth of peak, with the last tenth of each cycle at full peak
+		if phase >= 0.9 {
+			return g.rps
+		}
+		return g.rps / 5
+	case "ramp":
+		// sawtooth from 0 to peak over one period
+		return g.rps * phase
+	case "diurnal":
+		// one "day" per period: trough at 10% of peak, crest at midday
+		return g.rps * (0.55 - 0.45*math.Cos(2*math.Pi*phase))
+	}
+	return g.rps
+}
+
+/* -------------------------------------------------------------------------- */
+/* Scheduling                                                                 */
+/* -------------------------------------------------------------------------- */
+
+// run issues requests until ctx is done; in-flight requests are left to
+// finish before it returns.
+func (g *loadGenerator) run(ctx context.Context, l *slog.Logger) {
+	l.Info("load generator started", "profile", g.profile, "rps", g.rps, "period", g.period, "target", g.target)
+	g.start = time.Now()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		// below 1 rps, tick once a second and fire with probability r
+		r := g.rate(time.Since(g.start))
+		wait, fire := time.Second, rand.Float64() < r
+		if r >= 1 {
+			wait, fire = time.Duration(float64(time.Second)/r), true
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			l.Info("load generator stopped")
+			return
+		case <-t.C:
+		}
+		if !fire {
+			continue
+		}
+
+		select {
+		case g.concurrency <- struct{}{}:
+		default:
+			// the target can't keep up; drop rather than queue unboundedly
+			loadRequests.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("loadgen.profile", g.profile), attribute.String("status_class", "dropped")))
+			if g.report != nil {
+				g.report.record(loadSample{class: "dropped"}, trace.SpanContext{})
+			}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-g.concurrency }()
+			g.fire(context.WithoutCancel(ctx))
+		}()
+	}
+}
+
+// startLoadGenerator runs g in the background; the returned func stops it
+// and waits for in-flight requests.
+func startLoadGenerator(l *slog.Logger, g *loadGenerator) func() {
+	if g == nil {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.run(ctx, l)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/* Requests                                                                   */
+/* -------------------------------------------------------------------------- */
+
+// next picks a weighted random API call.
+func (g *loadGenerator) next() (method, path, route string, body []byte) {
+	id := strconv.FormatInt(1+rand.Int64N(max(1, g.maxID.Load())), 10)
+	switch n := rand.IntN(100); {
+	case n < 40:
+		return http.MethodGet, "/items/" + id, "/items/:id", nil
+	case n < 60:
+		return http.MethodGet, "/items", "/items", nil
+	case n < 80:
+		return http.MethodPost, "/items", "/items", fmt.Appendf(nil, `{"name":"load-%d"}`, rand.IntN(1e6))
+	case n < 90:
+		return http.MethodPut, "/items/" + id, "/items/:id", fmt.Appendf(nil, `{"name":"load-%d"}`, rand.IntN(1e6))
+	case n < 95:
+		return http.MethodDelete, "/items/" + id, "/items/:id", nil
+	case n < 98:
+		return http.MethodGet, "/slow?ms=" + strconv.Itoa(50+rand.IntN(250)), "/slow", nil
+	}
+	return http.MethodGet, "/fail", "/fail", nil
+}
+
+func (g *loadGenerator) fire(ctx context.Context) {
+	method, path, route, body := g.next()
+	ctx, span := tracer.Start(ctx, "loadgen.request", trace.WithAttributes(
+		attribute.String("loadgen.profile", g.profile),
+		attribute.String("http.request.method", method),
+		attribute.String("http.route", route),
+	))
+	defer span.End()
+
+	status, began := "error", time.Now()
+	defer func() {
+		loadRequests.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("loadgen.profile", g.profile), attribute.String("status_class", status)))
+		if g.report != nil {
+			g.report.record(loadSample{method: method, route: route, class: status, latency: time.Since(began)}, span.SpanContext())
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, method, g.target+path, bytes.NewReader(body))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	g.auth(req)
+
+	resp, err := outboundClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	status = fmt.Sprintf("%dxx", resp.StatusCode/100)
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if method == http.MethodPost && resp.StatusCode == http.StatusCreated {
+		var created struct{ ID int64 }
+		if err := json.NewDecoder(resp.Body).Decode(&created); err == nil {
+			for cur := g.maxID.Load(); created.ID > cur; cur = g.maxID.Load() {
+				if g.maxID.CompareAndSwap(cur, created.ID) {
+					break
+				}
+			}
+		}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+}
@@ -0,0 +1,119 @@
+// loadreport.go — the summary `app load` prints when it finishes:
+//   • latency percentiles (p50/p95/p99, max) over every completed request
+//   • counts by status class and the error rate (transport errors and 5xx)
+//   • trace IDs of the slowest and of the first failed requests, as links
+//     when TRACE_URL is set ({trace_id} is replaced); only sampled traces
+//     are listed, since the others never reach the backend
+//   the generator's requests carry traceparent, so each linked trace holds
+//   the client's loadgen.request span and the server's spans below it
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// loadSamples is how many slow and failed traces a report keeps.
+const loadSamples = 5
+
+// loadReport collects per-request outcomes; the server's own generator
+// runs without one.
+type loadReport struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	classes   map[string]int // status_class, as on loadgen.requests
+	slowest   []loadSample   // longest first
+	failed    []loadSample
+}
+
+type loadSample struct {
+	traceID trace.TraceID
+	method  string
+	route   string
+	class   string
+	latency time.Duration
+}
+
+func newLoadReport() *loadReport { return &loadReport{classes: map[string]int{}} }
+
+// record adds one request; dropped ones have no latency.
+func (r *loadReport) record(s loadSample, sc trace.SpanContext) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.classes[s.class]++
+	if s.class == "dropped" {
+		return
+	}
+	r.latencies = append(r.latencies, s.latency)
+	if !sc.IsSampled() {
+		return
+	}
+	s.traceID = sc.TraceID()
+	if loadFailed(s.class) && len(r.failed) < loadSamples {
+		r.failed = append(r.failed, s)
+	}
+	i, _ := slices.BinarySearchFunc(r.slowest, s, func(a, b loadSample) int { return int(b.latency - a.latency) })
+	if i < loadSamples {
+		r.slowest = slices.Insert(r.slowest, i, s)[:min(len(r.slowest)+1, loadSamples)]
+	}
+}
+
+func loadFailed(class string) bool { return class == "error" || class == "5xx" }
+
+// print writes the summary; traceURL is a link template with {trace_id}.
+func (r *loadReport) print(w io.Writer, elapsed time.Duration, traceURL string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	done := len(r.latencies)
+	failed := r.classes["error"] + r.classes["5xx"]
+	fmt.Fprintf(w, "\n%d requests in %s (%.1f/s), %d dropped\n",
+		done, elapsed.Round(time.Millisecond), float64(done)/elapsed.Seconds(), r.classes["dropped"])
+	if done == 0 {
+		return
+	}
+
+	slices.Sort(r.latencies)
+	fmt.Fprintf(w, "latency  p50 %s  p95 %s  p99 %s  max %s\n",
+		r.percentile(0.50), r.percentile(0.95), r.percentile(0.99), r.latencies[done-1].Round(time.Microsecond))
+
+	var classes []string
+	for _, class := range []string{"2xx", "3xx", "4xx", "5xx", "error"} {
+		if n := r.classes[class]; n > 0 {
+			classes = append(classes, fmt.Sprintf("%s %d", class, n))
+		}
+	}
+	fmt.Fprintf(w, "status   %s\n", strings.Join(classes, "  "))
+	fmt.Fprintf(w, "errors   %.2f%% (5xx and transport errors)\n", 100*float64(failed)/float64(done))
+
+	printSamples(w, "slowest traces", r.slowest, traceURL)
+	printSamples(w, "failed traces", r.failed, traceURL)
+}
+
+// percentile uses the nearest-rank method over the sorted latencies.
+func (r *loadReport) percentile(p float64) time.Duration {
+	i := int(math.Ceil(p*float64(len(r.latencies)))) - 1
+	return r.latencies[max(i, 0)].Round(time.Microsecond)
+}
+
+func printSamples(w io.Writer, title string, samples []loadSample, traceURL string) {
+	if len(samples) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, s := range samples {
+		ref := s.traceID.String()
+		if traceURL != "" {
+			ref = strings.ReplaceAll(traceURL, "{trace_id}", ref)
+		}
+		fmt.Fprintf(w, "  %-10s %-6s %-7s %-11s %s\n", s.latency.Round(time.Microsecond), s.method, s.class, s.route, ref)
+	}
+}
@@ -0,0 +1,99 @@
+// longpoll.go — GET /items/changes?since=<seq>&wait=30s returns the item
+//   changes after since, holding the request for up to wait when there are
+//   none yet, so clients without SSE or WebSocket can follow the feed.
+//   • the answer is {"changes":[…],"next":<seq>}; pass next as since on the
+//     following poll. "reset":true means changes were evicted: refetch
+//   • the write timeout is not lifted: wait is clamped to LONGPOLL_MAX_WAIT
+//     (50s) and to just under HTTP_WRITE_TIMEOUT, if set, otherwise the
+//     server would cut the connection before the empty answer goes out
+//   • the request span records the wait (longpoll.wait_ms, .waited_ms) and
+//     how it ended (longpoll.outcome=changes|timeout|shutdown|canceled);
+//     polls that waited are left out of the SLO latency numbers
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func itemChangesHandler(c *gin.Context) {
+	since, err := strconv.ParseInt(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil || since < 0 {
+		respondError(c, errors.New("since must be a non-negative sequence number"), http.StatusBadRequest)
+		return
+	}
+	wait, err := time.ParseDuration(c.DefaultQuery("wait", "0s"))
+	if err != nil || wait < 0 {
+		respondError(c, errors.New("wait must be a duration such as 30s"), http.StatusBadRequest)
+		return
+	}
+	limit := envDuration("LONGPOLL_MAX_WAIT", 50*time.Second)
+	if wt := envDuration("HTTP_WRITE_TIMEOUT", 60*time.Second); wt > 0 { // 0: no write timeout
+		limit = min(limit, max(wt-time.Second, 0))
+	}
+	ctx := c.Request.Context()
+	span := traceSpan(ctx)
+	if wait > limit {
+		span.SetAttributes(attribute.Int64("longpoll.requested_wait_ms", wait.Milliseconds()))
+		wait = limit
+	}
+	span.SetAttributes(attribute.Int64("longpoll.since", since), attribute.Int64("longpoll.wait_ms", wait.Milliseconds()))
+
+	sub, backlog, complete, cancel := changes.subscribe(since)
+	defer cancel()
+
+	outcome := "changes"
+	start := time.Now()
+	if len(backlog) == 0 && wait > 0 {
+		c.Set(ctxStreaming, true)
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case e, ok := <-sub.ch:
+			if !ok {
+				outcome = "shutdown"
+				break
+			}
+			backlog = append(backlog, e)
+			// pick up whatever arrived together with it
+			for drained := false; !drained; {
+				select {
+				case e, ok := <-sub.ch:
+					if ok {
+						backlog = append(backlog, e)
+					} else {
+						drained = true
+					}
+				default:
+					drained = true
+				}
+			}
+		case <-timer.C:
+			outcome = "timeout"
+		case <-ctx.Done():
+			span.SetAttributes(attribute.String("longpoll.outcome", "canceled"))
+			return
+		}
+		span.SetAttributes(attribute.Int64("longpoll.waited_ms", time.Since(start).Milliseconds()))
+	} else if len(backlog) == 0 {
+		outcome = "timeout"
+	}
+
+	next := since
+	for _, e := range backlog {
+		span.AddLink(trace.Link{SpanContext: e.producer})
+		next = e.Seq
+	}
+	span.SetAttributes(attribute.String("longpoll.outcome", outcome), attribute.Int("longpoll.changes", len(backlog)))
+	if backlog == nil {
+		backlog = []changeEntry{}
+	}
+	c.JSON(http.StatusOK, gin.H{"changes": backlog, "next": next, "reset": !complete})
+}
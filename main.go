@@ -1,31 +1,39 @@
 // main.go — Gin CRUD demo with:
 //   • OTLP/HTTP spans → Tempo
-//   • sync.Map store
+//   • sync.Map store behind a traced fake-DB layer (db.query spans)
 //   • slog structured logs (trace_id + span_id)
 //   • Spec-compliant error handling
 //   • /fail  &  /panic endpoints to generate 5xx traces
+//   • gzip/deflate response compression & request decompression
+//   • optional built-in load generator (LOADGEN_PROFILE)
+//   • embedded echo service on a second port for two-service traces
 
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"runtime/debug"
+	"slices"
 	"strconv"
-	"sync"
-	"sync/atomic"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
@@ -39,46 +47,102 @@ import (
 type Item struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
+	// set by every create and update; a pointer because only encoding/json
+	// honours omitzero, and the other codecs must leave it out too
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 }
 
-var (
-	store sync.Map
-	idSeq atomic.Int64
-)
+var tracer = otel.Tracer("otel-crud-example")
 
 /* -------------------------------------------------------------------------- */
 /* OpenTelemetry setup                                                        */
 /* -------------------------------------------------------------------------- */
 
-func initOpenTelemetry() func() {
-	ctx := context.Background()
+// initOpenTelemetry installs the global tracer provider (reporting as res)
+// and propagators; the returned func flushes it.
+func initOpenTelemetry(res *resource.Resource) func() {
+	tp := newTracerProvider(res)
+	if profilingEnabled() {
+		otel.SetTracerProvider(profilingTracerProvider{tp})
+	} else {
+		otel.SetTracerProvider(tp)
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	return shutdownTracerProvider(tp)
+}
 
-	exp, err := otlptracehttp.New(ctx,
-		otlptracehttp.WithEndpoint(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")), // e.g. "collector:4318"
-		otlptracehttp.WithInsecure(),
-		otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: true}),
-		otlptracehttp.WithTimeout(5*time.Second),
+// newTracerProvider exports to OTEL_EXPORTER_OTLP_ENDPOINT under res, or
+// to stdout with OTEL_TRACES_EXPORTER=stdout; the embedded echo service gets
+// its own provider so it reports as a separate service.
+func newTracerProvider(res *resource.Resource) *sdktrace.TracerProvider {
+	var (
+		exp sdktrace.SpanExporter
+		err error
 	)
+	switch name := envString("OTEL_TRACES_EXPORTER", "otlp"); name {
+	case "otlp":
+		exp, err = otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpoint(envString("OTEL_EXPORTER_OTLP_ENDPOINT", "")), // e.g. "collector:4318"
+			otlptracehttp.WithInsecure(),
+			otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: true}),
+			otlptracehttp.WithTimeout(5*time.Second),
+		)
+	case "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		err = fmt.Errorf("OTEL_TRACES_EXPORTER %q: want otlp or stdout", name)
+	}
 	if err != nil {
-		panic("failed to create OTLP exporter: " + err.Error())
+		panic("failed to create span exporter: " + err.Error())
 	}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp),
-		sdktrace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("otel-crud-example"),
-		)),
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(endProbe{&spanStats}),
+		sdktrace.WithBatcher(countingExporter{exp, &spanStats}),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
 	)
-	otel.SetTracerProvider(tp)
+}
+
+func shutdownTracerProvider(tp *sdktrace.TracerProvider) func() {
+	return func() {
+		// bounded, so an unreachable collector cannot stall process exit
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = tp.Shutdown(ctx)
+	}
+}
+
+func serviceResource() *resource.Resource { return namedResource("otel-crud-example") }
 
-	return func() { _ = tp.Shutdown(ctx) }
+// namedResource carries the same version and commit as GET /version.
+func namedResource(name string) *resource.Resource {
+	b := buildInfo()
+	attrs := []attribute.KeyValue{
+		semconv.ServiceNameKey.String(name),
+		semconv.ServiceVersionKey.String(b.Version),
+	}
+	if b.Commit != "" {
+		attrs = append(attrs, attribute.String("vcs.ref.head.revision", b.Commit))
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
 }
 
 /* -------------------------------------------------------------------------- */
 /* slog middleware — adds trace_id + span_id                                  */
 /* -------------------------------------------------------------------------- */
 
+// newLogger writes LOG_FORMAT (text or json) lines at the current logLevel.
+func newLogger(w io.Writer, source bool) *slog.Logger {
+	opts := &slog.HandlerOptions{AddSource: source, Level: logLevel}
+	if strings.EqualFold(envString("LOG_FORMAT", "text"), "json") {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
 func slogWithTrace(l *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
@@ -86,13 +150,26 @@ func slogWithTrace(l *slog.Logger) gin.HandlerFunc {
 		span := trace.SpanFromContext(c.Request.Context())
 		sc := span.SpanContext()
 
-		l.Info("request",
+		attrs := []any{
 			"method", c.Request.Method,
 			"path", c.FullPath(),
 			"status", c.Writer.Status(),
 			"trace_id", sc.TraceID().String(),
 			"span_id", sc.SpanID().String(),
-		)
+		}
+		if id := requestIDFromContext(c.Request.Context()); id != "" {
+			attrs = append(attrs, "request_id", id)
+		}
+		if user := c.GetString(ctxEndUser); user != "" {
+			attrs = append(attrs, "enduser", user)
+		}
+		if scope := c.GetString(ctxEndUserScope); scope != "" {
+			attrs = append(attrs, "scope", scope)
+		}
+		if t, ok := tenantFromContext(c.Request.Context()); ok {
+			attrs = append(attrs, "tenant", t.Label)
+		}
+		l.Info("request", attrs...)
 	}
 }
 
@@ -122,6 +199,7 @@ func recoveryWithOtel(l *slog.Logger) gin.HandlerFunc {
 					"error", err,
 					"trace_id", span.SpanContext().TraceID().String(),
 					"span_id", span.SpanContext().SpanID().String(),
+					"request_id", requestIDFromContext(c.Request.Context()),
 				)
 				c.AbortWithStatus(http.StatusInternalServerError)
 			}
@@ -131,39 +209,343 @@ func recoveryWithOtel(l *slog.Logger) gin.HandlerFunc {
 }
 
 /* -------------------------------------------------------------------------- */
-/* Main                                                                       */
+/* Server (app serve)                                                         */
 /* -------------------------------------------------------------------------- */
 
-func main() {
-	shutdown := initOpenTelemetry()
+// serve runs the API server until a termination signal; if the server
+// stops on its own instead, serve returns why and the process exits 1.
+func serve() error {
+	shutdown := initOpenTelemetry(serviceResource())
 	defer shutdown()
+	metricsHandler, shutdownMetrics := initMetrics()
+	defer shutdownMetrics()
+
+	logger := newLogger(os.Stdout, true)
+
+	// Gin reads GIN_MODE itself, but only from the environment
+	switch mode := envString("GIN_MODE", gin.DebugMode); mode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+		gin.SetMode(mode)
+	default:
+		logger.Error("GIN_MODE: want debug, release or test", "mode", mode)
+		os.Exit(1)
+	}
+
+	rs, err := loadRuntimeSettings()
+	if err != nil {
+		logger.Error("loading runtime settings", "err", err)
+		os.Exit(1)
+	}
+	rs.apply()
+	watchReload(logger)
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{AddSource: true}))
+	stopFlags, err := initFeatureFlags()
+	if err != nil {
+		logger.Error("configuring feature flags", "err", err)
+		os.Exit(1)
+	}
+	defer stopFlags()
+
+	stopProfiling, err := startProfiling(logger)
+	if err != nil {
+		logger.Error("starting continuous profiling", "err", err)
+		os.Exit(1)
+	}
+	defer stopProfiling()
+
+	if path := envString("SNAPSHOT_FILE", ""); path != "" {
+		found, n, err := restoreSnapshot(context.Background(), path)
+		if err != nil {
+			logger.Error("restoring store snapshot", "file", path, "err", err)
+			os.Exit(1)
+		}
+		if found {
+			logger.Info("store restored from snapshot", "file", path, "items", n)
+		}
+	}
+
+	db, err := newTracedStore(memStore)
+	if err != nil {
+		logger.Error("configuring fake db", "err", err)
+		os.Exit(1)
+	}
+	ready.add("store", db.ping)
+	var stopBroadcast func()
+	repo, stopBroadcast = startChangeBroadcast(logger, newCoalescingStore(db))
+
+	outbox := envBool("OUTBOX", false)
+	pub, err := newEventPublisher(!outbox)
+	if err != nil {
+		logger.Error("configuring event publisher", "err", err)
+		os.Exit(1)
+	}
+	stopRelay := func() {}
+	switch {
+	case pub != nil && outbox:
+		ob := newOutboxStore(repo, db, memStore, forgetRead(repo))
+		repo = ob
+		defer pub.Close()
+		stopRelay = startOutboxRelay(logger, ob, pub)
+	case pub != nil:
+		repo = publishingStore{repo, pub, logger}
+		defer pub.Close()
+	case outbox:
+		logger.Error("OUTBOX needs a broker (KAFKA_BROKERS or AMQP_URL)")
+		os.Exit(1)
+	}
+	repo = publishingStore{repo, webhooks, logger}
+	defer webhooks.Close()
+	repo = publishingStore{repo, changes, logger}
+	respCache := newResponseCache(logger)
+	if respCache != nil {
+		repo = publishingStore{repo, respCache, logger}
+		defer respCache.Close()
+	}
+	repo = newMeteredStore(repo, memStore, db.system)
+	perTenant := envInt("TENANT_MAX_ITEMS", 0)
+	if !multiTenant() {
+		perTenant = 0
+	}
+	if global := envInt("MAX_ITEMS", 0); global > 0 || perTenant > 0 {
+		repo = newQuotaStore(repo, memStore, global, perTenant)
+	}
+	repo = nameRulesStore{repo}
 
 	r := gin.New()
-	r.Use(otelgin.Middleware("otel-crud-example"))
+	// ClientIP, which keys the rate limit, reads X-Forwarded-For only from these
+	if err := r.SetTrustedProxies(envList("TRUSTED_PROXIES")); err != nil {
+		logger.Error("parsing TRUSTED_PROXIES", "err", err)
+		os.Exit(1)
+	}
+	mountRouter("api", r)
+	var ginOpts []otelgin.Option
+	if multiTenant() {
+		ginOpts = append(ginOpts, otelgin.WithGinMetricAttributeFn(tenantMetricAttributes))
+	}
+	r.Use(otelgin.Middleware("otel-crud-example", ginOpts...))
+	r.Use(requestID())
 	r.Use(recoveryWithOtel(logger))
 	r.Use(slogWithTrace(logger))
+	if envBool("PPROF_LABELS", true) {
+		r.Use(pprofLabels())
+	}
+	r.Use(countInflight())
+	r.Use(sloRecorder())
+	r.Use(tlsClientAttributes())
+	level := envInt("COMPRESS_LEVEL", gzip.DefaultCompression)
+	mountedMiddleware = httpMiddleware{
+		Compression: envBool("COMPRESS", true) && level != gzip.NoCompression,
+		CORS:        envBool("CORS", false),
+		RateLimit:   envBool("RATE_LIMIT", false),
+	}
+	logger.Info("http middleware", "gin_mode", gin.Mode(),
+		"compression", mountedMiddleware.Compression,
+		"cors", mountedMiddleware.CORS,
+		"rate_limit", mountedMiddleware.RateLimit)
+	if mountedMiddleware.CORS {
+		r.Use(cors())
+	}
+	if mountedMiddleware.Compression {
+		r.Use(compression(level, envInt("COMPRESS_MIN_SIZE", 1024)))
+	}
+	r.Use(limitBody(int64(envInt("MAX_BODY", 1<<20))))
+	r.Use(decompressRequest(int64(envInt("MAX_DECOMPRESSED_BODY", 10<<20))))
+	// gRPC goes through the same checks as HTTP (grpcauth.go)
+	var rpcChecks rpcAuth
+	if mountedMiddleware.RateLimit {
+		if settings.Load().RateLimit.RPS <= 0 {
+			logger.Error("RATE_LIMIT_RPS must be positive")
+			os.Exit(1)
+		}
+		rpcChecks.clients = newClientRateLimiter()
+		r.Use(rateLimit(rpcChecks.clients))
+	}
+
+	apiKeys, err := loadAPIKeys()
+	if err != nil {
+		logger.Error("loading API keys", "err", err)
+		os.Exit(1)
+	}
+	if len(apiKeys) > 0 {
+		r.Use(apiKeyAuth(apiKeys))
+		rpcChecks.keys = apiKeys
+	}
+	jwtAuth, err := newJWTAuthenticator(context.Background())
+	if err != nil {
+		logger.Error("configuring JWT authentication", "err", err)
+		os.Exit(1)
+	}
+	if jwtAuth != nil {
+		r.Use(jwtAuth.middleware())
+		rpcChecks.jwt = jwtAuth
+	}
+	if multiTenant() {
+		rpcChecks.tenants = newTenantLabels(logger)
+		r.Use(tenantContext(rpcChecks.tenants))
+		if rl := newTenantRateLimiter(); rl != nil {
+			rpcChecks.tenantRate = rl
+			r.Use(tenantRateLimit(rl))
+		}
+	}
+	r.Use(flagTargeting())
+
+	basePath = cleanBasePath(envString("BASE_PATH", ""))
+
+	/* ops: same port unless ADMIN_LISTEN splits them out */
+	var opsSrv *http.Server
+	if adminAddr := envString("ADMIN_LISTEN", ""); adminAddr != "" {
+		ops := newOpsRouter(logger)
+		mountRouter("ops", ops)
+		ops.Use(adminAuth(logger))
+		registerOpsRoutes(ops, metricsHandler)
+		opsSrv = serveOps(logger, adminAddr, ops)
+	} else {
+		r.Use(adminAuth(logger))
+		registerOpsRoutes(r.Group(basePath), metricsHandler)
+	}
+
+	r.Use(maintenanceGate())
+	r.Use(chaosInjector())
+
+	// a group copies the middleware in place when it is created
+	api := r.Group(basePath)
+
+	registerUI(api)
 
 	/* CRUD */
-	r.POST("/items", createItem)
-	r.GET("/items", listItems)
-	r.GET("/items/:id", getItem)
-	r.PUT("/items/:id", updateItem)
-	r.DELETE("/items/:id", deleteItem)
+	api.POST("/items", createItem)
+	api.POST("/items/bulk", bulkCreateItems)
+	api.POST("/items/import-async", importItemsAsync)
+	api.GET("/jobs/:id", getJob)
+	api.GET("/items", respCache.handler(), listItems)
+	api.GET("/items/events", itemEventsHandler)
+	api.GET("/items/changes", itemChangesHandler)
+	api.GET("/ws", wsHandler)
+	api.GET("/items/:id", respCache.handler(), getItem)
+	api.PUT("/items/:id", updateItem)
+	api.DELETE("/items/:id", deleteItem)
+	gateway, closeGateway, err := newGateway()
+	if err != nil {
+		logger.Error("configuring gRPC gateway", "err", err)
+		os.Exit(1)
+	}
+	defer closeGateway()
+	if gateway != nil {
+		api.Any("/v1/*path", gin.WrapH(unprefixed(gateway)))
+	}
+	gql := graphqlHandler()
+	api.GET("/graphql", gql)
+	api.POST("/graphql", gql)
+	api.POST("/rpc", jsonRPCHandler)
+	registerTwirp(api)
+	registerWebhookRoutes(api)
 
 	/* 5xx examples */
-	r.GET("/fail", func(c *gin.Context) {
+	api.GET("/fail", func(c *gin.Context) {
 		respondError(c, errors.New("simulated server failure"), http.StatusInternalServerError)
 	})
-	r.GET("/panic", func(_ *gin.Context) {
+	api.GET("/panic", func(_ *gin.Context) {
 		panic("simulated panic")
 	})
 
-	logger.Info("Listening on :8080 …")
-	if err := r.Run(":8080"); err != nil {
-		logger.Error("server error", "err", err)
+	/* latency examples */
+	api.GET("/slow", slowHandler)
+	api.GET("/timeout", routeTimeout(envDuration("TIMEOUT_ROUTE_BUDGET", 2*time.Second)), timeoutHandler)
+
+	/* resource pressure */
+	api.GET("/chaos/memory", chaosMemoryHandler)
+	api.GET("/chaos/cpu", chaosCPUHandler)
+	registerLeakRoutes(api)
+	api.GET("/chaos/contend", chaosContendHandler)
+
+	/* multi-hop scenarios */
+	api.GET("/scenario/cascade", cascadeHandler)
+	api.GET("/scenario/retry-storm", retryStormHandler)
+	api.GET("/scenario/run", scenarioRunHandler)
+	api.GET("/scenario/dependency", dependencyHandler)
+	api.GET("/scenario/echo", scenarioEchoHandler)
+	api.GET("/aggregate", aggregateHandler)
+	api.GET("/scenario/hedge", hedgeHandler)
+	api.GET("/scenario/saga", sagaHandler)
+
+	tlsCfg, acme, err := newTLSConfig()
+	if err != nil {
+		logger.Error("configuring TLS", "err", err)
+		os.Exit(1)
 	}
+	if addr := envString("TLS_AUTOCERT_HTTP_ADDR", ""); acme != nil && addr != "" {
+		go func() {
+			// http-01 challenges; everything else is redirected to HTTPS
+			if err := http.ListenAndServe(addr, acme.HTTPHandler(nil)); err != nil {
+				logger.Error("ACME challenge listener", "err", err)
+			}
+		}()
+	}
+	addr := listenAddr()
+	ln, err := listen(addr)
+	if err != nil {
+		logger.Error("listen", "addr", addr, "err", err)
+		os.Exit(1)
+	}
+	srv := newHTTPServer(r, tlsCfg)
+	srv.RegisterOnShutdown(func() { _ = changes.Close() })
+	logger.Info("server timeouts",
+		"read_header", srv.ReadHeaderTimeout, "read", srv.ReadTimeout,
+		"write", srv.WriteTimeout, "idle", srv.IdleTimeout)
+
+	gen, err := newLoadGenerator()
+	if err != nil {
+		logger.Error("configuring load generator", "err", err)
+		os.Exit(1)
+	}
+	stopJobs := startWorkerPool(logger)
+	stopLoad := startLoadGenerator(logger, gen)
+	stopCanary, err := startCanary(logger)
+	if err != nil {
+		logger.Error("configuring canary", "err", err)
+		os.Exit(1)
+	}
+	stopDependency := startDependencySchedule(logger)
+	stopConsumer := startKafkaConsumer(logger)
+	stopAMQPConsumer := startAMQPConsumer(logger)
+	stopReplay, err := startReplay(logger)
+	if err != nil {
+		logger.Error("loading replay file", "err", err)
+		os.Exit(1)
+	}
+	stopScheduler, err := startScheduler(logger)
+	if err != nil {
+		logger.Error("configuring scheduled tasks", "err", err)
+		os.Exit(1)
+	}
+
+	echoSrv, flushEcho := startEcho(logger)
+	defer flushEcho()
+	stopGRPC := startGRPC(logger, &rpcChecks)
+
+	stopWatchdog := startWatchdog(logger)
+	serveErr := runServer(logger, srv, ln, opsSrv, echoSrv)
+	if serveErr != nil {
+		logger.Error("server error", "err", serveErr)
+	}
+	stopGRPC()
+	stopScheduler()
+	stopReplay()
+	stopAMQPConsumer()
+	stopBroadcast()
+	stopConsumer()
+	stopDependency()
+	stopCanary()
+	stopLoad()
+	stopJobs()
+	stopRelay()
+	writeFinalSnapshot(logger)
+	stopWatchdog()
+	logger.Info("flushing telemetry")
+	if serveErr != nil {
+		return fmt.Errorf("serving: %w", serveErr)
+	}
+	return nil
 }
 
 /* -------------------------------------------------------------------------- */
@@ -173,24 +555,47 @@ func main() {
 func createItem(c *gin.Context) {
 	var in struct{ Name string }
 	if err := c.ShouldBindJSON(&in); err != nil {
-		respondError(c, err, http.StatusBadRequest)
+		respondBindError(c, err)
+		return
+	}
+	item, err := repo.Create(c.Request.Context(), in.Name)
+	var quota *quotaError
+	if errors.As(err, &quota) {
+		respondProblem(c, err, http.StatusForbidden, "urn:otel-crud-example:problem:quota-exceeded",
+			"Item quota exceeded", gin.H{"quota": quota.scope, "limit": quota.limit})
+		return
+	}
+	if err != nil {
+		respondError(c, err, storeErrorStatus(err))
 		return
 	}
 
-	id := int(idSeq.Add(1))
-	item := Item{ID: id, Name: in.Name}
-	store.Store(id, item)
-
+	setLastModified(c, item)
 	c.JSON(http.StatusCreated, item)
 }
 
 func listItems(c *gin.Context) {
-	items := make([]Item, 0)
-	store.Range(func(_, v any) bool {
-		items = append(items, v.(Item))
-		return true
-	})
-	c.JSON(http.StatusOK, items)
+	ctx := c.Request.Context()
+	isPaged := paged(c)
+	order, ok := listOrder(c, isPaged)
+	if !ok {
+		return
+	}
+	if order == nil {
+		streamItems(c, func(fn func(Item) error) error { return repo.Each(ctx, fn) })
+		return
+	}
+	items, err := repo.List(ctx)
+	if err != nil {
+		respondError(c, err, storeErrorStatus(err))
+		return
+	}
+	slices.SortFunc(items, order)
+	items, ok = paginate(c, items)
+	if !ok {
+		return
+	}
+	streamItems(c, sliceItems(items))
 }
 
 func getItem(c *gin.Context) {
@@ -199,12 +604,13 @@ func getItem(c *gin.Context) {
 		respondError(c, err, http.StatusBadRequest)
 		return
 	}
-	val, ok := store.Load(id)
-	if !ok {
-		respondError(c, errors.New("not found"), http.StatusNotFound)
+	item, err := repo.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, storeErrorStatus(err))
 		return
 	}
-	c.JSON(http.StatusOK, val.(Item))
+	setLastModified(c, item)
+	c.JSON(http.StatusOK, item)
 }
 
 func updateItem(c *gin.Context) {
@@ -213,21 +619,31 @@ func updateItem(c *gin.Context) {
 		respondError(c, err, http.StatusBadRequest)
 		return
 	}
-	val, ok := store.Load(id)
-	if !ok {
-		respondError(c, errors.New("not found"), http.StatusNotFound)
-		return
-	}
-	item := val.(Item)
-
+	ctx := c.Request.Context()
 	var in struct{ Name string }
 	if err := c.ShouldBindJSON(&in); err != nil {
-		respondError(c, err, http.StatusBadRequest)
+		respondBindError(c, err)
 		return
 	}
-
-	item.Name = in.Name
-	store.Store(id, item)
+	var current Item // what the update was checked against
+	item, err := repo.Update(ctx, id, func(item Item) (Item, error) {
+		current = item
+		if err := unmodifiedSince(c, item); err != nil {
+			return Item{}, err
+		}
+		item.Name = in.Name
+		return item, nil
+	})
+	switch {
+	case errors.Is(err, errPreconditionFailed):
+		setLastModified(c, current)
+		respondError(c, err, http.StatusPreconditionFailed)
+		return
+	case err != nil:
+		respondError(c, err, storeErrorStatus(err))
+		return
+	}
+	setLastModified(c, item)
 	c.JSON(http.StatusOK, item)
 }
 
@@ -237,14 +653,28 @@ func deleteItem(c *gin.Context) {
 		respondError(c, err, http.StatusBadRequest)
 		return
 	}
-	if _, ok := store.Load(id); !ok {
-		respondError(c, errors.New("not found"), http.StatusNotFound)
+	if err := repo.Delete(c.Request.Context(), id); err != nil {
+		respondError(c, err, storeErrorStatus(err))
 		return
 	}
-	store.Delete(id)
 	c.Status(http.StatusNoContent)
 }
 
+// storeErrorStatus maps repository errors onto HTTP statuses.
+func storeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errQuotaExceeded):
+		return http.StatusForbidden
+	case errors.Is(err, errInvalidName):
+		return http.StatusBadRequest
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return contextErrorStatus(err)
+	}
+	return http.StatusInternalServerError
+}
+
 /* -------------------------------------------------------------------------- */
 /* Error helper (spec-compliant)                                              */
 /* -------------------------------------------------------------------------- */
@@ -263,6 +693,23 @@ func respondError(c *gin.Context, err error, status int) {
 	c.JSON(status, gin.H{"error": err.Error()})
 }
 
+// respondProblem is respondError with an RFC 9457 Problem Details body;
+// ext adds members beside type, title, status and detail.
+func respondProblem(c *gin.Context, err error, status int, typ, title string, ext gin.H) {
+	span := traceSpan(c.Request.Context())
+	span.RecordError(err)
+	if status >= 500 {
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	body := gin.H{"type": typ, "title": title, "status": status, "detail": err.Error()}
+	for k, v := range ext {
+		body[k] = v
+	}
+	c.Header("Content-Type", "application/problem+json")
+	c.Render(status, render.JSON{Data: body})
+}
+
 /* -------------------------------------------------------------------------- */
 /* Span helper                                                                */
 /* -------------------------------------------------------------------------- */
@@ -0,0 +1,81 @@
+// maintenance.go — maintenance mode:
+//   POST /admin/maintenance/on|off; while on, every non-ops route answers
+//   503 with Retry-After so SLO burn and alert routing can be demoed
+//   hands-free. MAINTENANCE=true starts the process in maintenance.
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var errMaintenance = errors.New("service under maintenance")
+
+var maintenance struct {
+	on         atomic.Bool
+	retryAfter atomic.Int64 // seconds
+}
+
+func init() { initMaintenance() }
+
+// initMaintenance reads MAINTENANCE and MAINTENANCE_RETRY_AFTER; run again
+// by Config.install.
+func initMaintenance() {
+	maintenance.on.Store(envBool("MAINTENANCE", false))
+	maintenance.retryAfter.Store(int64(envDuration("MAINTENANCE_RETRY_AFTER", 2*time.Minute).Seconds()))
+}
+
+// isOpsPath reports routes that keep working during maintenance.
+func isOpsPath(path string) bool {
+	return isAdminPath(path) || isProbePath(path)
+}
+
+// isProbePath reports the read-only routes probes and scrapers call.
+func isProbePath(path string) bool {
+	switch routePath(path) {
+	case "/healthz", "/readyz", "/version", "/metrics":
+		return true
+	}
+	return false
+}
+
+func maintenanceGate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !maintenance.on.Load() || isOpsPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		traceSpan(c.Request.Context()).SetAttributes(attribute.Bool("maintenance", true))
+		c.Header("Retry-After", strconv.FormatInt(maintenance.retryAfter.Load(), 10))
+		respondError(c, errMaintenance, http.StatusServiceUnavailable)
+		c.Abort()
+	}
+}
+
+func registerMaintenanceAdmin(r gin.IRouter) {
+	status := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"maintenance":         maintenance.on.Load(),
+			"retry_after_seconds": maintenance.retryAfter.Load(),
+		})
+	}
+	r.GET("/admin/maintenance", status)
+	r.POST("/admin/maintenance/on", func(c *gin.Context) {
+		if s, err := strconv.Atoi(c.Query("retry_after")); err == nil && s > 0 {
+			maintenance.retryAfter.Store(int64(s))
+		}
+		maintenance.on.Store(true)
+		status(c)
+	})
+	r.POST("/admin/maintenance/off", func(c *gin.Context) {
+		maintenance.on.Store(false)
+		status(c)
+	})
+}
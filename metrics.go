@@ -0,0 +1,201 @@
+// metrics.go — OpenTelemetry metrics exposed in Prometheus format on /metrics,
+//   including Go runtime metrics (heap, GC, goroutines).
+//   • one view shapes every instrument, keeping cardinality in check:
+//     METRICS_DROP_ATTRIBUTES removes attributes (by default the per-client
+//     and raw-URL ones; "none" keeps all), METRICS_DROP_INSTRUMENTS removes whole instruments
+//     and METRICS_RENAME renames them; names and keys may use * and ?
+//   • METRICS_LATENCY_BUCKETS sets the bucket edges of every duration
+//     histogram (unit s or ms) as durations, so an SLO threshold such as
+//     300ms can be an exact edge; METRICS_HISTOGRAM=exponential switches all
+//     histograms to base-2 exponential (Prometheus native histograms)
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// meter is shared by every subsystem; it resolves through the global
+// provider, so instruments created before initMetrics still export.
+var meter = otel.Meter("otel-crud-example")
+
+func initMetrics() (http.Handler, func()) {
+	exp, err := prometheus.New()
+	if err != nil {
+		panic("failed to create Prometheus exporter: " + err.Error())
+	}
+
+	view, err := metricView()
+	if err != nil {
+		panic("invalid metric views: " + err.Error())
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(exp),
+		sdkmetric.WithResource(serviceResource()),
+		sdkmetric.WithView(view),
+	)
+	otel.SetMeterProvider(mp)
+
+	if err := runtime.Start(runtime.WithMeterProvider(mp)); err != nil {
+		panic("failed to start runtime metrics: " + err.Error())
+	}
+
+	return promhttp.Handler(), func() { _ = mp.Shutdown(context.Background()) }
+}
+
+/* -------------------------------------------------------------------------- */
+/* Views                                                                      */
+/* -------------------------------------------------------------------------- */
+
+// defaultDroppedAttributes grow a series per client or per URL.
+const defaultDroppedAttributes = "client.address,client.port,network.peer.address,network.peer.port," +
+	"url.full,url.path,url.query,user_agent.original,http.request.header.*"
+
+// attrRule drops key from instruments matching instrument ("*" for all).
+type attrRule struct{ instrument, key string }
+
+// metricView combines every configured rule into one view, so each
+// instrument still yields exactly one stream (the SDK would export one per
+// matching view).
+func metricView() (sdkmetric.View, error) {
+	var drops []attrRule
+	raw := envString("METRICS_DROP_ATTRIBUTES", defaultDroppedAttributes)
+	if raw == "none" {
+		raw = ""
+	}
+	for _, e := range strings.Split(raw, ",") {
+		if e = strings.TrimSpace(e); e == "" {
+			continue
+		}
+		inst, key, ok := strings.Cut(e, ":")
+		if !ok {
+			inst, key = "*", e
+		}
+		if err := validPattern(inst, key); err != nil {
+			return nil, fmt.Errorf("METRICS_DROP_ATTRIBUTES %q: %w", e, err)
+		}
+		drops = append(drops, attrRule{inst, key})
+	}
+	droppedInstruments := envList("METRICS_DROP_INSTRUMENTS")
+	if err := validPattern(droppedInstruments...); err != nil {
+		return nil, fmt.Errorf("METRICS_DROP_INSTRUMENTS: %w", err)
+	}
+	histogram, err := histogramAggregation()
+	if err != nil {
+		return nil, err
+	}
+	latencyBuckets, err := latencyBuckets()
+	if err != nil {
+		return nil, err
+	}
+	renames := map[string]string{}
+	for _, e := range envList("METRICS_RENAME") {
+		from, to, ok := strings.Cut(e, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("METRICS_RENAME %q: want old.name=new.name", e)
+		}
+		renames[from] = to
+	}
+
+	return func(inst sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+		s := sdkmetric.Stream{Name: inst.Name, Description: inst.Description, Unit: inst.Unit}
+		for _, p := range droppedInstruments {
+			if match(p, inst.Name) {
+				s.Aggregation = sdkmetric.AggregationDrop{}
+				return s, true
+			}
+		}
+		if to, ok := renames[inst.Name]; ok {
+			s.Name = to
+		}
+		if inst.Kind == sdkmetric.InstrumentKindHistogram {
+			s.Aggregation = histogram
+			if scale, ok := timeUnits[inst.Unit]; ok && histogram == nil && len(latencyBuckets) > 0 {
+				bounds := make([]float64, len(latencyBuckets))
+				for i, d := range latencyBuckets {
+					bounds[i] = float64(d) / float64(scale)
+				}
+				s.Aggregation = sdkmetric.AggregationExplicitBucketHistogram{Boundaries: bounds}
+			}
+		}
+		var keys []string
+		for _, d := range drops {
+			if match(d.instrument, inst.Name) {
+				keys = append(keys, d.key)
+			}
+		}
+		if len(keys) > 0 {
+			s.AttributeFilter = func(kv attribute.KeyValue) bool {
+				for _, k := range keys {
+					if match(k, string(kv.Key)) {
+						return false
+					}
+				}
+				return true
+			}
+		}
+		return s, true
+	}, nil
+}
+
+// timeUnits are the duration units histograms are recorded in.
+var timeUnits = map[string]time.Duration{"s": time.Second, "ms": time.Millisecond, "us": time.Microsecond}
+
+// histogramAggregation is nil for the SDK's (or the instrument's advised)
+// explicit buckets.
+func histogramAggregation() (sdkmetric.Aggregation, error) {
+	switch h := envString("METRICS_HISTOGRAM", "explicit"); h {
+	case "explicit":
+		return nil, nil
+	case "exponential":
+		return sdkmetric.AggregationBase2ExponentialHistogram{
+			MaxSize:  int32(envInt("METRICS_EXPONENTIAL_MAX_SIZE", 160)),
+			MaxScale: 8, // the finest schema Prometheus native histograms accept
+		}, nil
+	default:
+		return nil, fmt.Errorf("METRICS_HISTOGRAM %q: want explicit or exponential", h)
+	}
+}
+
+// latencyBuckets parses METRICS_LATENCY_BUCKETS, e.g. "50ms,100ms,300ms,1s".
+func latencyBuckets() ([]time.Duration, error) {
+	var out []time.Duration
+	for _, e := range envList("METRICS_LATENCY_BUCKETS") {
+		d, err := time.ParseDuration(e)
+		if err != nil {
+			return nil, fmt.Errorf("METRICS_LATENCY_BUCKETS: %w", err)
+		}
+		out = append(out, d)
+	}
+	if !slices.IsSorted(out) || len(slices.Compact(slices.Clone(out))) != len(out) {
+		return nil, fmt.Errorf("METRICS_LATENCY_BUCKETS: edges must be increasing")
+	}
+	return out, nil
+}
+
+func match(pattern, name string) bool {
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+func validPattern(patterns ...string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// oidc.go — OpenID Connect discovery for JWT validation (Keycloak, Dex, …):
+//   OIDC_ISSUER_URL → /.well-known/openid-configuration → issuer + jwks_uri.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type oidcDiscovery struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+func discoverOIDC(ctx context.Context, issuer string) (_ *oidcDiscovery, err error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	ctx, span := tracer.Start(ctx, "oidc.discovery",
+		trace.WithAttributes(attribute.String("url.full", url)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := outboundClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery: unexpected status %s", resp.Status)
+	}
+
+	var d oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("OIDC discovery: %w", err)
+	}
+	if d.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery: %s has no jwks_uri", url)
+	}
+	// the spec requires the advertised issuer to match the configured one
+	if strings.TrimSuffix(d.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("OIDC discovery: issuer mismatch (%q != %q)", d.Issuer, issuer)
+	}
+	return &d, nil
+}
@@ -0,0 +1,64 @@
+// ops.go — operational endpoints (/healthz, /readyz, /metrics, /debug/*,
+//   /admin/*): mounted on the public router by default, or served from
+//   their own listener when ADMIN_LISTEN is set (e.g. ":9091") so they can
+//   be firewalled independently of the API.
+
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+func newOpsRouter(l *slog.Logger) *gin.Engine {
+	r := gin.New()
+	r.Use(otelgin.Middleware("otel-crud-example"))
+	r.Use(requestID())
+	r.Use(recoveryWithOtel(l))
+	r.Use(slogWithTrace(l))
+	return r
+}
+
+func registerOpsRoutes(r gin.IRouter, metrics http.Handler) {
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	registerReadyz(r)
+	registerVersion(r)
+	r.GET("/metrics", gin.WrapH(metrics))
+	if envBool("PPROF_ENABLED", true) {
+		registerPprof(r)
+	}
+	registerExpvar(r)
+	registerRoutesDebug(r)
+	registerChaosAdmin(r)
+	registerMaintenanceAdmin(r)
+	registerRuntimeAdmin(r)
+	registerAdminConsole(r)
+	registerSLOAdmin(r)
+	registerDependencyAdmin(r)
+	registerSelftest(r)
+	registerDeadLetterAdmin(r)
+}
+
+// serveOps starts the ops listener in the background; the returned server
+// (nil if the address could not be bound) is shut down with the main one.
+func serveOps(l *slog.Logger, addr string, h http.Handler) *http.Server {
+	ln, err := listen(addr)
+	if err != nil {
+		l.Error("admin listen", "addr", addr, "err", err)
+		return nil
+	}
+	srv := newHTTPServer(h, nil)
+	go func() {
+		l.Info("Admin listener …", "addr", addr)
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			l.Error("admin server error", "err", err)
+		}
+	}()
+	return srv
+}
@@ -0,0 +1,99 @@
+// outbound.go — shared clients for calls the app makes to other services
+//   (or itself), built on internal/httpclient: otelhttp client spans per
+//   attempt, W3C trace context, the inbound X-Request-ID forwarded, and
+//   retries with backoff (OUTBOUND_MAX_ATTEMPTS, OUTBOUND_BACKOFF_BASE/MAX).
+
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/micro-company/http-trace-example/internal/httpclient"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+var (
+	outboundClient *httpclient.Client
+
+	// outboundOnce never retries: for demos whose point is the failure
+	// itself (cascade, retry storm, hedging).
+	outboundOnce *httpclient.Client
+)
+
+func init() { initOutbound() }
+
+// initOutbound (re)builds the shared clients from the current settings.
+func initOutbound() {
+	outboundClient = newOutboundClient(30 * time.Second)
+	outboundOnce = outboundClient.WithMaxAttempts(1)
+}
+
+// newOutboundClient returns a retrying client with a per-attempt timeout.
+func newOutboundClient(timeout time.Duration) *httpclient.Client {
+	return newOutboundClientOver(http.DefaultTransport, timeout)
+}
+
+// newOutboundClientOver is newOutboundClient sending through base.
+func newOutboundClientOver(base http.RoundTripper, timeout time.Duration) *httpclient.Client {
+	return httpclient.New(httpclient.Options{
+		Transport:   otelhttp.NewTransport(requestIDTransport{base}),
+		Timeout:     timeout,
+		MaxAttempts: envInt("OUTBOUND_MAX_ATTEMPTS", 3),
+		BaseDelay:   envDuration("OUTBOUND_BACKOFF_BASE", 100*time.Millisecond),
+		MaxDelay:    envDuration("OUTBOUND_BACKOFF_MAX", 2*time.Second),
+	})
+}
+
+// errNoSelfURL: a unix-socket LISTEN has no TCP address to call back on.
+var errNoSelfURL = errors.New("LISTEN is a unix socket: set SELF_URL for the app's calls to itself")
+
+// selfURL is where the app reaches its own API (scenarios, load generator,
+// CLI): SELF_URL, or the LISTEN port on loopback. With TLS on the scheme is
+// https and the host one the certificate is expected to cover — the first
+// TLS_AUTOCERT_DOMAINS entry, or localhost for a static certificate.
+func selfURL() (string, error) {
+	if u := envString("SELF_URL", ""); u != "" {
+		return u, nil
+	}
+	addr := listenAddr()
+	if strings.HasPrefix(addr, "unix://") {
+		return "", errNoSelfURL
+	}
+	port := "8080"
+	if _, p, err := net.SplitHostPort(strings.TrimPrefix(addr, "tcp://")); err == nil && p != "" {
+		port = p
+	}
+	scheme, host := "http", "127.0.0.1"
+	if domains := envList("TLS_AUTOCERT_DOMAINS"); len(domains) > 0 {
+		scheme, host = "https", domains[0]
+	} else if envString("TLS_CERT_FILE", "") != "" {
+		scheme, host = "https", "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port) + cleanBasePath(envString("BASE_PATH", "")), nil
+}
+
+// forwardAuth copies caller credentials so self-calls pass the same auth.
+func forwardAuth(dst, src *http.Request) {
+	for _, h := range []string{"Authorization", "X-API-Key"} {
+		if v := src.Header.Get(h); v != "" {
+			dst.Header.Set(h, v)
+		}
+	}
+}
+
+// staticAuth sets fixed credentials on requests the app originates itself
+// (load generator, canary); empty values are skipped.
+func staticAuth(apiKey, bearer string) func(*http.Request) {
+	return func(req *http.Request) {
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+	}
+}
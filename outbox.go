@@ -0,0 +1,228 @@
+// outbox.go — transactional outbox for item events (OUTBOX=true):
+//   • each mutation and the INSERT of its event into the outbox table run in
+//     one db.transaction span; if the outbox insert fails the mutation is
+//     rolled back, so no change is ever committed without its event
+//   • a relay polls the outbox every OUTBOX_POLL_INTERVAL (500ms) and
+//     publishes rows through the configured broker; failed rows stay and are
+//     retried on the next poll
+//   • the outbox.relay span continues (or links to, per EVENTS_TRACE_MODE)
+//     the row's INSERT span, giving request → outbox row → publish
+//   • delivery is at-least-once: a row whose DELETE fails is sent again
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// outboxBatch caps the rows one poll publishes.
+const outboxBatch = 100
+
+type outboxRow struct {
+	id       int64
+	event    itemEvent
+	inserted trace.SpanContext // the INSERT INTO outbox span
+	attempts int
+}
+
+// outboxStore records an event for every mutation of next, inside the same
+// (simulated) transaction on db; raw undoes mutations on rollback, and
+// forget drops the cached and coalesced reads each undo made stale.
+type outboxStore struct {
+	itemStore
+	db     *tracedStore
+	raw    *memoryStore
+	forget func(ctx context.Context, id int)
+
+	txMu sync.Mutex // one transaction at a time
+
+	mu    sync.Mutex
+	rows  []*outboxRow
+	rowID int64
+}
+
+var outboxRelayed, _ = meter.Int64Counter("outbox.relayed",
+	metric.WithDescription("Outbox rows relayed to the broker by result (published|failed)"))
+
+func newOutboxStore(next itemStore, db *tracedStore, raw *memoryStore, forget func(ctx context.Context, id int)) *outboxStore {
+	s := &outboxStore{itemStore: next, db: db, raw: raw, forget: forget}
+	_, _ = meter.Int64ObservableGauge("outbox.pending",
+		metric.WithDescription("Outbox rows not yet published"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			o.Observe(int64(len(s.rows)))
+			return nil
+		}))
+	return s
+}
+
+// tx runs mutate and the outbox insert of the event it returns as one
+// unit; undo reverts mutate when the insert fails.
+func (s *outboxStore) tx(ctx context.Context, mutate func(ctx context.Context) (ev itemEvent, undo func(), err error)) error {
+	ctx, span := tracer.Start(ctx, "db.transaction", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system.name", s.db.system)))
+	defer span.End()
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	ev, undo, err := mutate(ctx)
+	if err != nil {
+		span.AddEvent("rollback")
+		return err
+	}
+	err = s.db.queryTable(ctx, "outbox", "INSERT",
+		"INSERT INTO outbox (event_id, type, payload, trace_context) VALUES (?, ?, ?, ?)",
+		func(ctx context.Context) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.rowID++
+			s.rows = append(s.rows, &outboxRow{id: s.rowID, event: ev, inserted: trace.SpanContextFromContext(ctx)})
+			return nil
+		})
+	if err != nil {
+		undo()
+		span.AddEvent("rollback")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.AddEvent("commit")
+	return nil
+}
+
+func (s *outboxStore) Create(ctx context.Context, name string) (item Item, err error) {
+	err = s.tx(ctx, func(ctx context.Context) (itemEvent, func(), error) {
+		if item, err = s.itemStore.Create(ctx, name); err != nil {
+			return itemEvent{}, nil, err
+		}
+		return newItemEvent("created", item), func() {
+			_ = s.raw.Delete(ctx, item.ID)
+			s.forget(ctx, item.ID)
+		}, nil
+	})
+	return item, err
+}
+
+func (s *outboxStore) Update(ctx context.Context, id int, fn func(Item) (Item, error)) (item Item, err error) {
+	err = s.tx(ctx, func(ctx context.Context) (itemEvent, func(), error) {
+		var old Item // what the swap that won replaced
+		item, err = s.itemStore.Update(ctx, id, func(cur Item) (Item, error) {
+			old = cur
+			return fn(cur)
+		})
+		if err != nil {
+			return itemEvent{}, nil, err
+		}
+		return newItemEvent("updated", item), func() {
+			_ = s.raw.Put(ctx, old)
+			s.forget(ctx, id)
+		}, nil
+	})
+	return item, err
+}
+
+func (s *outboxStore) Delete(ctx context.Context, id int) error {
+	return s.tx(ctx, func(ctx context.Context) (itemEvent, func(), error) {
+		old, _ := s.raw.Get(ctx, id)
+		if err := s.itemStore.Delete(ctx, id); err != nil {
+			return itemEvent{}, nil, err
+		}
+		return newItemEvent("deleted", Item{ID: id}), func() {
+			_ = s.raw.Put(ctx, old)
+			s.forget(ctx, id)
+		}, nil
+	})
+}
+
+/* -------------------------------------------------------------------------- */
+/* Relay                                                                      */
+/* -------------------------------------------------------------------------- */
+
+// startOutboxRelay publishes outbox rows through pub in the background; the
+// returned func stops polling and makes one last pass.
+func startOutboxRelay(l *slog.Logger, s *outboxStore, pub eventPublisher) func() {
+	interval := envDuration("OUTBOX_POLL_INTERVAL", 500*time.Millisecond)
+	l.Info("outbox relay started", "interval", interval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				s.relay(context.Background(), l, pub)
+				return
+			case <-t.C:
+				s.relay(ctx, l, pub)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// relay publishes up to outboxBatch pending rows, oldest first.
+func (s *outboxStore) relay(ctx context.Context, l *slog.Logger, pub eventPublisher) {
+	s.mu.Lock()
+	batch := append([]*outboxRow(nil), s.rows[:min(len(s.rows), outboxBatch)]...)
+	s.mu.Unlock()
+
+	for _, row := range batch {
+		if ctx.Err() != nil {
+			return
+		}
+		s.relayRow(ctx, l, pub, row)
+	}
+}
+
+func (s *outboxStore) relayRow(ctx context.Context, l *slog.Logger, pub eventPublisher, row *outboxRow) {
+	row.attempts++
+	parent, opts := consumeSpanOptions(trace.ContextWithRemoteSpanContext(ctx, row.inserted),
+		attribute.Int64("outbox.row_id", row.id),
+		attribute.Int("outbox.attempt", row.attempts),
+		attribute.String("cloudevents.event_type", row.event.Type),
+	)
+	ctx, span := tracer.Start(parent, "outbox.relay", opts...)
+	defer span.End()
+
+	if err := pub.Publish(ctx, row.event); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		outboxRelayed.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "failed")))
+		l.WarnContext(ctx, "relaying outbox row", "row_id", row.id, "attempt", row.attempts, "err", err)
+		return
+	}
+	outboxRelayed.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "published")))
+
+	err := s.db.queryTable(ctx, "outbox", "DELETE", "DELETE FROM outbox WHERE id = ?", func(context.Context) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, r := range s.rows {
+			if r == row {
+				s.rows = append(s.rows[:i], s.rows[i+1:]...)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// published but still pending: it goes out again next poll
+		span.AddEvent("outbox.delete_failed")
+		l.WarnContext(ctx, "removing relayed outbox row", "row_id", row.id, "err", err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// A failed outbox insert must leave the store as it was and tell the layers
+// above (forget) that the item changed back; a commit leaves one row.
+func TestOutboxRollback(t *testing.T) {
+	rename := func(ctx context.Context, s *outboxStore) error {
+		_, err := s.Update(ctx, 1, func(it Item) (Item, error) { it.Name = "changed"; return it, nil })
+		return err
+	}
+	tests := []struct {
+		name        string
+		seed        bool // item 1 exists beforehand, named "seed"
+		insertFails bool
+		mutate      func(ctx context.Context, s *outboxStore) error
+		wantName    string // item 1 afterwards; "" means absent
+		wantForgot  []int
+		wantRows    []string
+	}{
+		{
+			name:        "create rolled back",
+			insertFails: true,
+			mutate: func(ctx context.Context, s *outboxStore) error {
+				_, err := s.Create(ctx, "new")
+				return err
+			},
+			wantForgot: []int{1},
+		},
+		{
+			name:        "update rolled back",
+			seed:        true,
+			insertFails: true,
+			mutate:      rename,
+			wantName:    "seed",
+			wantForgot:  []int{1},
+		},
+		{
+			name:        "delete rolled back",
+			seed:        true,
+			insertFails: true,
+			mutate:      func(ctx context.Context, s *outboxStore) error { return s.Delete(ctx, 1) },
+			wantName:    "seed",
+			wantForgot:  []int{1},
+		},
+		{
+			name:     "update committed",
+			seed:     true,
+			mutate:   rename,
+			wantName: "changed",
+			wantRows: []string{"com.example.item.updated"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			raw := newMemoryStore()
+			if tt.seed {
+				_, _ = raw.Create(ctx, "seed")
+			}
+			// the fake DB only runs the outbox insert here: the mutations
+			// go to raw directly
+			db := &tracedStore{next: raw, system: "memory"}
+			if tt.insertFails {
+				db.errorRate = 1
+			}
+			var forgot []int
+			s := newOutboxStore(raw, db, raw, func(_ context.Context, id int) { forgot = append(forgot, id) })
+
+			err := tt.mutate(ctx, s)
+			if failed := errors.Is(err, errDBUnavailable); failed != tt.insertFails {
+				t.Fatalf("err = %v, want the insert to fail: %v", err, tt.insertFails)
+			}
+
+			got, err := raw.Get(ctx, 1)
+			if tt.wantName == "" && !errors.Is(err, errNotFound) {
+				t.Errorf("item 1 = %+v, %v; want it gone", got, err)
+			}
+			if tt.wantName != "" && (err != nil || got.Name != tt.wantName) {
+				t.Errorf("item 1 = %+v, %v; want name %q", got, err, tt.wantName)
+			}
+			wantCount := 0
+			if tt.wantName != "" {
+				wantCount = 1
+			}
+			if n, _ := raw.Count(ctx); n != wantCount {
+				t.Errorf("Count = %d, want %d", n, wantCount)
+			}
+			if !slices.Equal(forgot, tt.wantForgot) {
+				t.Errorf("forgot %v, want %v", forgot, tt.wantForgot)
+			}
+			var rows []string
+			for _, row := range s.rows {
+				rows = append(rows, row.event.Type)
+			}
+			if !slices.Equal(rows, tt.wantRows) {
+				t.Errorf("outbox rows %v, want %v", rows, tt.wantRows)
+			}
+		})
+	}
+}
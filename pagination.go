@@ -0,0 +1,128 @@
+// pagination.go — ?sort= and ?limit=&offset= on GET /items:
+//   • sorted by id unless ?sort says otherwise: -id, name, -name (ties by
+//     id) or none, the store's own order, streamed without loading the
+//     list first; a page always needs an order, so none means id there
+//   • without either parameter a list is its first PAGE_DEFAULT_LIMIT
+//     (100) items, so no client gets an unbounded answer by accident;
+//     PAGE_DEFAULT_LIMIT=0 brings back the whole list
+//   • a limit above PAGE_MAX_LIMIT (1000; 0 lifts it) is a 400; every list
+//     response advertises both in X-Page-Max-Limit and X-Page-Default-Limit
+//   • X-Total-Count holds the size of the whole list and Link (rel="next")
+//     points at the following page, if any
+//   • list.sort and pagination.limit/.offset land on the request span
+
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	headerPageMaxLimit     = "X-Page-Max-Limit"
+	headerPageDefaultLimit = "X-Page-Default-Limit"
+)
+
+// itemOrders are the ?sort values; none (nil) is the store's order.
+var itemOrders = map[string]func(a, b Item) int{
+	"id":    func(a, b Item) int { return cmp.Compare(a.ID, b.ID) },
+	"-id":   func(a, b Item) int { return cmp.Compare(b.ID, a.ID) },
+	"name":  func(a, b Item) int { return cmp.Or(strings.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID)) },
+	"-name": func(a, b Item) int { return cmp.Or(strings.Compare(b.Name, a.Name), cmp.Compare(a.ID, b.ID)) },
+	"none":  nil,
+}
+
+// listOrder reads ?sort (default id); ok is false once it has answered 400.
+// Pages get id order for none.
+func listOrder(c *gin.Context, paged bool) (order func(a, b Item) int, ok bool) {
+	name := c.DefaultQuery("sort", "id")
+	order, known := itemOrders[name]
+	if !known {
+		respondError(c, errors.New("sort must be one of id, -id, name, -name, none"), http.StatusBadRequest)
+		return nil, false
+	}
+	if order == nil && paged {
+		name, order = "id", itemOrders["id"]
+	}
+	traceSpan(c.Request.Context()).SetAttributes(attribute.String("list.sort", name))
+	return order, true
+}
+
+// pageLimits reads PAGE_MAX_LIMIT and PAGE_DEFAULT_LIMIT; 0 means none.
+func pageLimits() (maxLimit, defaultLimit int) {
+	maxLimit = max(envInt("PAGE_MAX_LIMIT", 1000), 0)
+	defaultLimit = max(envInt("PAGE_DEFAULT_LIMIT", 100), 0)
+	if maxLimit > 0 && defaultLimit > maxLimit {
+		defaultLimit = maxLimit
+	}
+	return maxLimit, defaultLimit
+}
+
+// paged reports whether a list request gets a page rather than everything,
+// and advertises the limits either way.
+func paged(c *gin.Context) bool {
+	maxLimit, defaultLimit := pageLimits()
+	if maxLimit > 0 {
+		c.Header(headerPageMaxLimit, strconv.Itoa(maxLimit))
+	}
+	if defaultLimit > 0 {
+		c.Header(headerPageDefaultLimit, strconv.Itoa(defaultLimit))
+	}
+	return c.Query("limit") != "" || c.Query("offset") != "" || defaultLimit > 0
+}
+
+// paginate cuts the sorted items down to the requested page and sets the
+// paging headers; ok is false once it has answered 400.
+func paginate(c *gin.Context, items []Item) (page []Item, ok bool) {
+	limitQ, offsetQ := c.Query("limit"), c.Query("offset")
+	maxLimit, defaultLimit := pageLimits()
+	if limitQ == "" && offsetQ == "" && defaultLimit == 0 {
+		return items, true
+	}
+	limit, offset := len(items), 0
+	if defaultLimit > 0 {
+		limit = defaultLimit
+	}
+	var err error
+	if limitQ != "" {
+		if limit, err = strconv.Atoi(limitQ); err != nil || limit < 1 {
+			respondError(c, errors.New("limit must be a positive integer"), http.StatusBadRequest)
+			return nil, false
+		}
+		if maxLimit > 0 && limit > maxLimit {
+			traceSpan(c.Request.Context()).SetAttributes(attribute.Int("pagination.max_limit", maxLimit))
+			respondError(c, fmt.Errorf("limit must be at most %d", maxLimit), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	if offsetQ != "" {
+		if offset, err = strconv.Atoi(offsetQ); err != nil || offset < 0 {
+			respondError(c, errors.New("offset must be a non-negative integer"), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	traceSpan(c.Request.Context()).SetAttributes(
+		attribute.Int("pagination.limit", limit),
+		attribute.Int("pagination.offset", offset),
+	)
+
+	total := len(items)
+	start := min(offset, total)
+	end := min(start+limit, total)
+
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	if end < total {
+		q := c.Request.URL.Query()
+		q.Set("limit", strconv.Itoa(limit))
+		q.Set("offset", strconv.Itoa(end))
+		c.Header("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, c.Request.URL.Path, q.Encode()))
+	}
+	return items[start:end], true
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListItemsPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		env        map[string]string
+		query      string
+		status     int
+		wantIDs    []int
+		sorted     bool   // compare wantIDs ignoring order
+		total      string // X-Total-Count
+		link       string
+		defaultHdr string // X-Page-Default-Limit
+	}{
+		{
+			name: "first page by default", env: map[string]string{"PAGE_DEFAULT_LIMIT": "2"},
+			status: http.StatusOK, wantIDs: []int{1, 2}, total: "5", link: `</items?limit=2&offset=2>; rel="next"`, defaultHdr: "2",
+		},
+		{
+			name: "limit and offset", query: "limit=2&offset=3",
+			status: http.StatusOK, wantIDs: []int{4, 5}, total: "5", defaultHdr: "100",
+		},
+		{
+			name: "offset past the end", query: "offset=10",
+			status: http.StatusOK, wantIDs: []int{}, total: "5", defaultHdr: "100",
+		},
+		{
+			name: "the next link keeps the sort", query: "sort=-id&limit=2",
+			status: http.StatusOK, wantIDs: []int{5, 4}, total: "5", link: `</items?limit=2&offset=2&sort=-id>; rel="next"`, defaultHdr: "100",
+		},
+		{
+			name: "by name, ties by id", query: "sort=name&limit=3",
+			status: http.StatusOK, wantIDs: []int{3, 5, 2}, total: "5", link: `</items?limit=3&offset=3&sort=name>; rel="next"`, defaultHdr: "100",
+		},
+		{
+			name: "the default is capped by the maximum", env: map[string]string{"PAGE_MAX_LIMIT": "3"},
+			status: http.StatusOK, wantIDs: []int{1, 2, 3}, total: "5", link: `</items?limit=3&offset=3>; rel="next"`, defaultHdr: "3",
+		},
+		{
+			name: "no default returns the whole list", env: map[string]string{"PAGE_DEFAULT_LIMIT": "0"},
+			status: http.StatusOK, wantIDs: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name: "store order is streamed unpaged", env: map[string]string{"PAGE_DEFAULT_LIMIT": "0"}, query: "sort=none",
+			status: http.StatusOK, wantIDs: []int{1, 2, 3, 4, 5}, sorted: true,
+		},
+		{
+			name: "store order on a page means id", query: "sort=none&limit=2",
+			status: http.StatusOK, wantIDs: []int{1, 2}, total: "5", link: `</items?limit=2&offset=2&sort=none>; rel="next"`, defaultHdr: "100",
+		},
+		{name: "limit over the maximum", env: map[string]string{"PAGE_MAX_LIMIT": "3"}, query: "limit=4", status: http.StatusBadRequest},
+		{name: "zero limit", query: "limit=0", status: http.StatusBadRequest},
+		{name: "negative offset", query: "offset=-1", status: http.StatusBadRequest},
+		{name: "unknown sort", query: "sort=size", status: http.StatusBadRequest},
+	}
+
+	s := newMemoryStore()
+	for _, name := range []string{"pen", "ink", "cap", "nib", "cap"} {
+		_, _ = s.Create(context.Background(), name)
+	}
+	useRepo(t, s)
+	r := gin.New()
+	r.GET("/items", listItems)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var items []Item
+			if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+				t.Fatalf("decoding %q: %v", w.Body, err)
+			}
+			ids := []int{}
+			for _, item := range items {
+				ids = append(ids, item.ID)
+			}
+			if tt.sorted {
+				slices.Sort(ids)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("IDs = %v, want %v", ids, tt.wantIDs)
+			}
+			for h, want := range map[string]string{
+				"X-Total-Count":        tt.total,
+				"Link":                 tt.link,
+				headerPageDefaultLimit: tt.defaultHdr,
+			} {
+				if got := w.Header().Get(h); got != want {
+					t.Errorf("%s = %q, want %q", h, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// pprof.go — net/http/pprof under /debug/pprof on the ops router, so CPU,
+//   heap and goroutine profiles can be captured during load tests.
+//   Disable with PPROF_ENABLED=false.
+//   • each API request runs with the pprof labels http.route and trace_id
+//     (PPROF_LABELS=false turns them off), so a CPU profile can be sliced
+//     by endpoint (`go tool pprof -tagfocus http.route=/items/:id`) and the
+//     hottest samples traced back to a request. trace_id is left out while
+//     Pyroscope is uploading, where every value would become a series.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+func registerPprof(r gin.IRouter) {
+	r.Any("/debug/pprof/*profile", func(c *gin.Context) {
+		switch c.Param("profile") {
+		case "/cmdline":
+			pprof.Cmdline(c.Writer, c.Request)
+		case "/profile":
+			pprof.Profile(c.Writer, c.Request)
+		case "/symbol":
+			pprof.Symbol(c.Writer, c.Request)
+		case "/trace":
+			pprof.Trace(c.Writer, c.Request)
+		default: // index page and named profiles (heap, goroutine, …)
+			// Index finds the profile name in the path, so it must not see BASE_PATH
+			unprefixed(http.HandlerFunc(pprof.Index)).ServeHTTP(c.Writer, c.Request)
+		}
+	})
+}
+
+// pprofLabels labels the request's goroutine (and any it starts) until the
+// handler chain returns; it must run after otelgin so the trace ID is known.
+func pprofLabels() gin.HandlerFunc {
+	withTraceID := !profilingEnabled()
+	return func(c *gin.Context) {
+		var labels []string
+		if route := c.FullPath(); route != "" {
+			labels = append(labels, "http.route", route)
+		}
+		if sc := traceSpan(c.Request.Context()).SpanContext(); withTraceID && sc.IsValid() {
+			labels = append(labels, "trace_id", sc.TraceID().String())
+		}
+		if len(labels) == 0 {
+			c.Next()
+			return
+		}
+		runtimepprof.Do(c.Request.Context(), runtimepprof.Labels(labels...), func(ctx context.Context) {
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// presets.go — APP_ENV=dev|staging|prod bundles defaults for a kind of
+//   deployment, so running the demo "the right way" is one variable:
+//   • a preset sits just above the built-in defaults: the config file,
+//     environment and flags all override it (see config.go)
+//   • `app config KEY` reports preset-supplied values as "preset:<name>"
+//   • unset APP_ENV means no preset, i.e. the plain defaults
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var presets = map[string]map[string]string{
+	// everything visible locally, no collector needed
+	"dev": {
+		"OTEL_TRACES_EXPORTER": "stdout",
+		"LOG_LEVEL":            "debug",
+		"LOG_FORMAT":           "text",
+		"SAMPLING_RATIO":       "1",
+		"GIN_MODE":             "debug",
+		// receivers on localhost
+		"WEBHOOK_ALLOW_PRIVATE": "true",
+	},
+	"staging": {
+		"LOG_LEVEL":      "info",
+		"LOG_FORMAT":     "json",
+		"SAMPLING_RATIO": "0.5",
+		"GIN_MODE":       "release",
+	},
+	// sampled, machine-readable, tighter timeouts and a drain that fits
+	// behind a load balancer
+	"prod": {
+		"LOG_LEVEL":            "info",
+		"LOG_FORMAT":           "json",
+		"SAMPLING_RATIO":       "0.1",
+		"GIN_MODE":             "release",
+		"HTTP_READ_TIMEOUT":    "10s",
+		"HTTP_WRITE_TIMEOUT":   "30s",
+		"HTTP_IDLE_TIMEOUT":    "60s",
+		"READYZ_TIMEOUT":       "1s",
+		"SHUTDOWN_READY_GRACE": "5s",
+		"SHUTDOWN_TIMEOUT":     "20s",
+	},
+}
+
+// preset returns the defaults for APP_ENV name ("" for none).
+func preset(name string) (map[string]string, error) {
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := presets[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("APP_ENV %q: want one of %s", name, strings.Join(slices.Sorted(maps.Keys(presets)), ", "))
+	}
+	return p, nil
+}
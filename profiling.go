@@ -0,0 +1,124 @@
+// profiling.go — continuous profiling to Pyroscope, off unless
+//   PYROSCOPE_SERVER_ADDRESS is set:
+//   • CPU and heap by default; PYROSCOPE_PROFILE_TYPES adds goroutines,
+//     mutex_* and block_* (switching on the runtime's sampling for them)
+//   • profiles are tagged with service_version and commit, the same values
+//     as the OTel resource, so both sides name the same build
+//   • span profiles: while a local root span (the server span of a request,
+//     a background job, …) runs, its goroutine carries the pprof labels
+//     span_id and span_name, and the span gets pyroscope.profile.id; Grafana
+//     uses that pair to go from a slow span to the code it spent time in and
+//     back. The trace's ID stays on the span side: as a label it would make
+//     a profile series per trace.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"time"
+
+	"github.com/grafana/pyroscope-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+func profilingEnabled() bool { return envString("PYROSCOPE_SERVER_ADDRESS", "") != "" }
+
+// startProfiling begins uploading profiles; the returned func flushes and
+// stops.
+func startProfiling(l *slog.Logger) (func(), error) {
+	addr := envString("PYROSCOPE_SERVER_ADDRESS", "")
+	if addr == "" {
+		return func() {}, nil
+	}
+	types := slices.Clone(pyroscope.DefaultProfileTypes)
+	for _, t := range envList("PYROSCOPE_PROFILE_TYPES") {
+		pt := pyroscope.ProfileType(t)
+		switch pt {
+		case pyroscope.ProfileGoroutines:
+		case pyroscope.ProfileMutexCount, pyroscope.ProfileMutexDuration:
+			runtime.SetMutexProfileFraction(5)
+		case pyroscope.ProfileBlockCount, pyroscope.ProfileBlockDuration:
+			runtime.SetBlockProfileRate(int(envDuration("PYROSCOPE_BLOCK_RATE", time.Millisecond).Nanoseconds()))
+		case pyroscope.ProfileCPU, pyroscope.ProfileAllocObjects, pyroscope.ProfileAllocSpace,
+			pyroscope.ProfileInuseObjects, pyroscope.ProfileInuseSpace:
+		default:
+			return nil, fmt.Errorf("PYROSCOPE_PROFILE_TYPES: unknown profile type %q", t)
+		}
+		if !slices.Contains(types, pt) {
+			types = append(types, pt)
+		}
+	}
+
+	b := buildInfo()
+	tags := map[string]string{"service_version": b.Version}
+	if b.Commit != "" {
+		tags["commit"] = b.Commit
+	}
+	p, err := pyroscope.Start(pyroscope.Config{
+		ApplicationName:   envString("PYROSCOPE_APPLICATION_NAME", "otel-crud-example"),
+		ServerAddress:     addr,
+		BasicAuthUser:     envString("PYROSCOPE_BASIC_AUTH_USER", ""),
+		BasicAuthPassword: envString("PYROSCOPE_BASIC_AUTH_PASSWORD", ""),
+		TenantID:          envString("PYROSCOPE_TENANT_ID", ""),
+		UploadRate:        envDuration("PYROSCOPE_UPLOAD_RATE", 15*time.Second),
+		Tags:              tags,
+		ProfileTypes:      types,
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.Info("continuous profiling started", "server", addr, "types", types)
+	return func() { _ = p.Stop() }, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* Span profiles                                                              */
+/* -------------------------------------------------------------------------- */
+
+const profileIDKey = attribute.Key("pyroscope.profile.id")
+
+// profilingTracerProvider labels the goroutines of local root spans.
+type profilingTracerProvider struct {
+	trace.TracerProvider
+}
+
+func (p profilingTracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return profilingTracer{tracer: p.TracerProvider.Tracer(name, opts...)}
+}
+
+type profilingTracer struct {
+	embedded.Tracer
+	tracer trace.Tracer
+}
+
+func (t profilingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent := trace.SpanContextFromContext(ctx)
+	ctx, span := t.tracer.Start(ctx, name, opts...)
+	sc := span.SpanContext()
+	if (parent.IsValid() && !parent.IsRemote()) || !sc.IsSampled() {
+		// children run under the root's labels; unsampled spans are never seen
+		return ctx, span
+	}
+	span.SetAttributes(profileIDKey.String(sc.SpanID().String()))
+	labelled := pprof.WithLabels(ctx, pprof.Labels("span_id", sc.SpanID().String(), "span_name", name))
+	pprof.SetGoroutineLabels(labelled)
+	return labelled, &profiledSpan{Span: span, restore: ctx}
+}
+
+// profiledSpan puts the goroutine's previous labels back when it ends.
+type profiledSpan struct {
+	trace.Span
+	restore context.Context
+}
+
+func (s *profiledSpan) End(opts ...trace.SpanEndOption) {
+	s.Span.End(opts...)
+	pprof.SetGoroutineLabels(s.restore)
+}
@@ -0,0 +1,216 @@
+// quota.go — per-tenant limits under MULTI_TENANT, each tenant on its own:
+//   • TENANT_RATE_LIMIT_RPS (off) refills a token bucket per tenant, of
+//     TENANT_RATE_LIMIT_BURST (2×RPS) tokens; over it: 429 with Retry-After;
+//     both are hot-reloadable once the limit is on at startup
+//   • TENANT_MAX_ITEMS (off) caps the items a tenant may hold, and
+//     MAX_ITEMS (off) the store as a whole, tenants or not, so a runaway
+//     load generator cannot eat the memory; creates past either fail with a
+//     quotaError (403 Problem Details, RESOURCE_EXHAUSTED over gRPC)
+//   • every refusal adds a quota.exceeded event to the span, and item
+//     refusals set quota.scope/quota.limit on it; the tenant.items and
+//     item.store.limit gauges show usage against the limits
+//   • requests without a tenant are only subject to MAX_ITEMS
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errQuotaExceeded = errors.New("item quota exceeded")
+
+// quotaError is a refused create; it matches errQuotaExceeded.
+type quotaError struct {
+	scope string // global | tenant
+	limit int
+}
+
+func (e *quotaError) Error() string {
+	if e.scope == "global" {
+		return fmt.Sprintf("item quota exceeded: the store holds its maximum of %d items", e.limit)
+	}
+	return fmt.Sprintf("item quota exceeded: the tenant holds its maximum of %d items", e.limit)
+}
+
+func (e *quotaError) Is(target error) bool { return target == errQuotaExceeded }
+
+// quotaExceeded records a refusal of kind (rate|items) on the span.
+func quotaExceeded(ctx context.Context, kind string, limit float64, attrs ...attribute.KeyValue) {
+	traceSpan(ctx).AddEvent("quota.exceeded", trace.WithAttributes(append(append(tenantAttrs(ctx),
+		attribute.String("quota.kind", kind),
+		attribute.Float64("quota.limit", limit)), attrs...)...))
+}
+
+/* -------------------------------------------------------------------------- */
+/* Rate                                                                       */
+/* -------------------------------------------------------------------------- */
+
+// newTenantRateLimiter builds tenantLimiter from the current
+// tenant_rate_limit settings; nil when the limit is off.
+func newTenantRateLimiter() *rateLimiter {
+	l := settings.Load().TenantRateLimit
+	if l.RPS <= 0 {
+		return nil
+	}
+	tenantLimiter = newRateLimiter(l.RPS, l.burst())
+	return tenantLimiter
+}
+
+func tenantRateLimit(rl *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, ok := tenantFromContext(c.Request.Context())
+		if !ok || isOpsPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		ok, wait := rl.allow(t.ID, time.Now())
+		if ok {
+			c.Next()
+			return
+		}
+		traceSpan(c.Request.Context()).SetAttributes(attribute.Bool("ratelimit.limited", true))
+		quotaExceeded(c.Request.Context(), "rate", rl.rate())
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		respondError(c, errRateLimited, http.StatusTooManyRequests)
+		c.Abort()
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/* Items                                                                      */
+/* -------------------------------------------------------------------------- */
+
+// quotaStore refuses creates past the global limit (read from raw) or the
+// creating tenant's; 0 disables either.
+type quotaStore struct {
+	itemStore
+	raw       *memoryStore
+	global    int
+	perTenant int
+
+	mu       sync.Mutex
+	reserved int               // creates past the check, not yet in raw
+	held     map[string]int    // tenant ID → items, reserved ones included
+	labels   map[string]string // tenant ID → label
+	owners   map[int]string    // item ID → tenant ID
+}
+
+func newQuotaStore(next itemStore, raw *memoryStore, global, perTenant int) *quotaStore {
+	s := &quotaStore{itemStore: next, raw: raw, global: global, perTenant: perTenant,
+		held: map[string]int{}, labels: map[string]string{}, owners: map[int]string{}}
+	if global > 0 {
+		_, _ = meter.Int64ObservableGauge("item.store.limit",
+			metric.WithDescription("Items the store may hold (MAX_ITEMS)"),
+			metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+				o.Observe(int64(global))
+				return nil
+			}))
+	}
+	if perTenant == 0 {
+		return s
+	}
+	_, _ = meter.Int64ObservableGauge("tenant.items",
+		metric.WithDescription("Items held per tenant"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			s.mu.Lock()
+			byLabel := map[string]int{}
+			for id, n := range s.held {
+				byLabel[s.labels[id]] += n
+			}
+			s.mu.Unlock()
+			for label, n := range byLabel {
+				o.Observe(int64(n), metric.WithAttributes(tenantKey.String(label)))
+			}
+			return nil
+		}))
+	_, _ = meter.Int64ObservableGauge("tenant.items.limit",
+		metric.WithDescription("Items a single tenant may hold"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(s.perTenant))
+			return nil
+		}))
+	return s
+}
+
+func (s *quotaStore) Create(ctx context.Context, name string) (Item, error) {
+	t, tenanted := tenantFromContext(ctx)
+	tenanted = tenanted && s.perTenant > 0
+
+	// reserve first: the create itself is slow and must not overrun
+	s.mu.Lock()
+	if err := s.check(t, tenanted); err != nil {
+		s.mu.Unlock()
+		span := traceSpan(ctx)
+		span.SetAttributes(attribute.String("quota.scope", err.scope), attribute.Int("quota.limit", err.limit))
+		quotaExceeded(ctx, "items", float64(err.limit), attribute.String("quota.scope", err.scope))
+		return Item{}, err
+	}
+	s.reserved++
+	if tenanted {
+		s.held[t.ID]++
+		s.labels[t.ID] = t.Label
+	}
+	s.mu.Unlock()
+
+	item, err := s.itemStore.Create(ctx, name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reserved--
+	if !tenanted {
+		return item, err
+	}
+	if err != nil {
+		s.release(t.ID)
+		return item, err
+	}
+	s.owners[item.ID] = t.ID
+	return item, nil
+}
+
+// check returns the quota one more item would break; s.mu must be held.
+func (s *quotaStore) check(t tenant, tenanted bool) *quotaError {
+	if tenanted && s.held[t.ID] >= s.perTenant {
+		return &quotaError{scope: "tenant", limit: s.perTenant}
+	}
+	if s.global > 0 {
+		// the raw store keeps a count: no span, no injected latency, no scan
+		if n, _ := s.raw.Count(context.Background()); n+s.reserved >= s.global {
+			return &quotaError{scope: "global", limit: s.global}
+		}
+	}
+	return nil
+}
+
+func (s *quotaStore) Delete(ctx context.Context, id int) error {
+	if err := s.itemStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if owner, ok := s.owners[id]; ok {
+		delete(s.owners, id)
+		s.release(owner)
+	}
+	return nil
+}
+
+// release gives back one of a tenant's items; s.mu must be held.
+func (s *quotaStore) release(id string) {
+	if s.held[id]--; s.held[id] <= 0 {
+		delete(s.held, id)
+		delete(s.labels, id)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// heldCreates holds its first hold Creates until release is closed, and
+// fails every Create with err when set.
+type heldCreates struct {
+	itemStore
+	hold    int32
+	entered chan struct{} // one send per held Create
+	release chan struct{}
+	calls   atomic.Int32
+	err     error
+}
+
+func (s *heldCreates) Create(ctx context.Context, name string) (Item, error) {
+	if s.calls.Add(1) <= s.hold {
+		s.entered <- struct{}{}
+		<-s.release
+	}
+	if s.err != nil {
+		return Item{}, s.err
+	}
+	return s.itemStore.Create(ctx, name)
+}
+
+func tenantCtx(id string) context.Context {
+	if id == "" {
+		return context.Background()
+	}
+	return withTenant(context.Background(), tenant{ID: id, Label: id})
+}
+
+// Creates still running hold their slot: the next one is judged as if they
+// had already landed.
+func TestQuotaStoreReservation(t *testing.T) {
+	tests := []struct {
+		name              string
+		global, perTenant int
+		inFlight          []string // tenant of each create still running; "" is none
+		next              string
+		wantScope         string // "" means the next create goes through
+	}{
+		{"global counts creates in flight", 2, 0, []string{"", ""}, "", "global"},
+		{"global below the limit", 3, 0, []string{"", ""}, "", ""},
+		{"tenant counts its creates in flight", 0, 1, []string{"a"}, "a", "tenant"},
+		{"other tenants are not affected", 0, 1, []string{"a"}, "b", ""},
+		{"no tenant, no tenant limit", 0, 1, []string{""}, "", ""},
+		{"tenants count against the global limit", 1, 5, []string{"a"}, "b", "global"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := newMemoryStore()
+			held := &heldCreates{itemStore: raw, hold: int32(len(tt.inFlight)),
+				entered: make(chan struct{}), release: make(chan struct{})}
+			s := newQuotaStore(held, raw, tt.global, tt.perTenant)
+
+			errs := make(chan error, len(tt.inFlight))
+			for _, id := range tt.inFlight {
+				go func() {
+					_, err := s.Create(tenantCtx(id), "in flight")
+					errs <- err
+				}()
+				<-held.entered
+			}
+
+			_, err := s.Create(tenantCtx(tt.next), "next")
+			var quota *quotaError
+			switch {
+			case tt.wantScope == "" && err != nil:
+				t.Errorf("next create: %v, want it to go through", err)
+			case tt.wantScope != "" && !errors.As(err, &quota):
+				t.Errorf("next create: %v, want a %s quota error", err, tt.wantScope)
+			case tt.wantScope != "" && quota.scope != tt.wantScope:
+				t.Errorf("refused by the %s quota, want %s", quota.scope, tt.wantScope)
+			}
+
+			close(held.release)
+			for range tt.inFlight {
+				if err := <-errs; err != nil {
+					t.Errorf("create in flight: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// A tenant gets its slot back when its create fails or its item is deleted.
+func TestQuotaStoreRelease(t *testing.T) {
+	ctx := tenantCtx("a")
+	raw := newMemoryStore()
+	inner := &heldCreates{itemStore: raw}
+	s := newQuotaStore(inner, raw, 0, 1)
+
+	steps := []struct {
+		name    string
+		do      func() error
+		wantErr error
+	}{
+		{"failed create", func() error {
+			inner.err = errDBUnavailable
+			defer func() { inner.err = nil }()
+			_, err := s.Create(ctx, "x")
+			return err
+		}, errDBUnavailable},
+		{"create after the failure", func() error { _, err := s.Create(ctx, "x"); return err }, nil},
+		{"create over the limit", func() error { _, err := s.Create(ctx, "y"); return err }, errQuotaExceeded},
+		{"delete", func() error { return s.Delete(ctx, 1) }, nil},
+		{"create after the delete", func() error { _, err := s.Create(ctx, "z"); return err }, nil},
+	}
+	for _, st := range steps {
+		if err := st.do(); !errors.Is(err, st.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", st.name, err, st.wantErr)
+		}
+	}
+}
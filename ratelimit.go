@@ -0,0 +1,139 @@
+// ratelimit.go — per-client token bucket, off unless RATE_LIMIT=true:
+//   • RATE_LIMIT_RPS (default 50) refills each client IP's bucket,
+//     RATE_LIMIT_BURST (default 2×RPS) is its size
+//   • over the limit: 429 with Retry-After, and ratelimit.limited=true on
+//     the server span so throttled traffic is easy to find
+//   • ops routes are never limited
+//   • the key is the connection's address; X-Forwarded-For counts only
+//     from TRUSTED_PROXIES (none by default), so a client can't mint keys
+//   • RPS and burst are hot-reloadable (`rate_limit: {rps, burst}` in
+//     RUNTIME_CONFIG); buckets carry over
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var errRateLimited = errors.New("rate limit exceeded")
+
+// maxBuckets bounds memory: past it, buckets that have refilled are dropped.
+const maxBuckets = 10000
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	rps, burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	return &rateLimiter{rps: rps, burst: float64(burst), buckets: map[string]*bucket{}}
+}
+
+// setRate changes the limit in place; a bucket over the new burst is
+// trimmed on its next request.
+func (rl *rateLimiter) setRate(rps float64, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.rps, rl.burst = rps, float64(burst)
+}
+
+func (rl *rateLimiter) rate() float64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.rps
+}
+
+// allow takes a token from key's bucket; when it is empty it returns how
+// long until the next one.
+func (rl *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		if len(rl.buckets) >= maxBuckets {
+			rl.evict(now)
+		}
+		b = &bucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = b
+	}
+	b.tokens = math.Min(rl.burst, b.tokens+now.Sub(b.last).Seconds()*rl.rps)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rl.rps * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// evict drops the buckets that would be full again by now.
+func (rl *rateLimiter) evict(now time.Time) {
+	for k, b := range rl.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*rl.rps >= rl.burst {
+			delete(rl.buckets, k)
+		}
+	}
+}
+
+// rateLimits is a limiter's reloadable part; a zero Burst means 2×RPS.
+type rateLimits struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+func (l rateLimits) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Ceil(2 * l.RPS))
+}
+
+// String is how reload diffs show it.
+func (l rateLimits) String() string {
+	return fmt.Sprintf("%g rps, burst %d", l.RPS, l.burst())
+}
+
+// The mounted limiters, nil when off; reloads retune them in place.
+var clientLimiter, tenantLimiter *rateLimiter
+
+// newClientRateLimiter builds clientLimiter from the current rate_limit
+// settings; HTTP and gRPC share it, so a client has one budget across both.
+func newClientRateLimiter() *rateLimiter {
+	l := settings.Load().RateLimit
+	clientLimiter = newRateLimiter(l.RPS, l.burst())
+	return clientLimiter
+}
+
+func rateLimit(rl *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isOpsPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		ok, wait := rl.allow(c.ClientIP(), time.Now())
+		if ok {
+			c.Next()
+			return
+		}
+		traceSpan(c.Request.Context()).SetAttributes(attribute.Bool("ratelimit.limited", true))
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		respondError(c, errRateLimited, http.StatusTooManyRequests)
+		c.Abort()
+	}
+}
@@ -0,0 +1,135 @@
+// readyz.go — GET /readyz, next to the /healthz liveness probe:
+//   • runs every registered check concurrently (READYZ_TIMEOUT, 2s): the
+//     store always, Redis when REDIS_ADDR is set, the span exporter's last
+//     batch, maintenance mode, and shutdown
+//   • each check is a readyz.check <name> child span, and its outcome and
+//     latency appear in the body
+//   • 503 when a check fails, unless it is listed in READYZ_OPTIONAL
+//     (reported, but not gating)
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type readyCheck struct {
+	name  string
+	check func(context.Context) error
+}
+
+type readiness struct {
+	mu       sync.Mutex
+	checks   []readyCheck
+	draining atomic.Bool
+}
+
+var ready = newReadiness()
+
+func newReadiness() *readiness {
+	r := &readiness{}
+	r.add("exporter", spanStats.healthy)
+	r.add("maintenance", func(context.Context) error {
+		if maintenance.on.Load() {
+			return errMaintenance
+		}
+		return nil
+	})
+	r.add("shutdown", func(context.Context) error {
+		if r.draining.Load() {
+			return errors.New("draining")
+		}
+		return nil
+	})
+	return r
+}
+
+// add registers a check; subsystems call it as they are configured.
+func (r *readiness) add(name string, check func(context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, readyCheck{name, check})
+}
+
+type checkResult struct {
+	Status    string  `json:"status"` // ok | fail
+	Error     string  `json:"error,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+	Optional  bool    `json:"optional,omitempty"`
+}
+
+func registerReadyz(r gin.IRouter) {
+	timeout := envDuration("READYZ_TIMEOUT", 2*time.Second)
+	optional := envList("READYZ_OPTIONAL")
+
+	r.GET("/readyz", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		results, ok := ready.run(ctx, optional)
+		status, body := http.StatusOK, "ready"
+		if !ok {
+			status, body = http.StatusServiceUnavailable, "not ready"
+		}
+		traceSpan(c.Request.Context()).SetAttributes(attribute.Bool("readyz.ready", ok))
+		c.JSON(status, gin.H{"status": body, "checks": results})
+	})
+}
+
+// run executes every check; ok is false if a non-optional one failed.
+func (r *readiness) run(ctx context.Context, optional []string) (map[string]checkResult, bool) {
+	r.mu.Lock()
+	checks := slices.Clone(r.checks)
+	r.mu.Unlock()
+
+	results := make(map[string]checkResult, len(checks))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	ok := true
+	for _, rc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := runCheck(ctx, rc)
+			res.Optional = slices.Contains(optional, rc.name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[rc.name] = res
+			if res.Status != "ok" && !res.Optional {
+				ok = false
+			}
+		}()
+	}
+	wg.Wait()
+	return results, ok
+}
+
+func runCheck(ctx context.Context, rc readyCheck) checkResult {
+	ctx, span := tracer.Start(ctx, "readyz.check "+rc.name,
+		trace.WithAttributes(attribute.String("readyz.check", rc.name)))
+	defer span.End()
+
+	start := time.Now()
+	err := rc.check(ctx)
+	res := checkResult{Status: "ok", LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		res.Status, res.Error = "fail", err.Error()
+	}
+	return res
+}
@@ -0,0 +1,290 @@
+// redis.go — cross-instance cache invalidation over Redis pub/sub:
+//   • REDIS_ADDR (host:port) turns on a per-instance read cache for
+//     GET /items/:id and broadcasts every local change on REDIS_CHANNEL
+//     (item-changes) so the other instances drop their copy
+//   • pub/sub has no headers, so the trace context rides in the message body
+//   • a PRODUCER span per broadcast, a CONSUMER span per received message
+//     (continued or linked per EVENTS_TRACE_MODE); an instance ignores its
+//     own messages
+//   • entries live REDIS_CACHE_TTL (1m) and at most REDIS_CACHE_MAX (10000)
+//     are kept; a read only fills the cache if no change to its item was
+//     seen while it loaded, so a slow read can't put back what a write
+//     dropped
+//   • outbox rollbacks, which write around the store chain, invalidate
+//     and broadcast too (see forgetRead)
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// itemChange is the broadcast payload.
+type itemChange struct {
+	Origin string            `json:"origin"` // sending instance
+	ItemID int               `json:"item_id"`
+	Op     string            `json:"op"` // created|updated|deleted|reverted
+	Trace  map[string]string `json:"trace,omitempty"`
+}
+
+var (
+	cacheLookups, _ = meter.Int64Counter("items.cache.lookups",
+		metric.WithDescription("Item cache lookups by result (hit|miss)"))
+	cacheInvalidations, _ = meter.Int64Counter("items.cache.invalidations",
+		metric.WithDescription("Item cache entries dropped, by source (local|remote)"))
+)
+
+/* -------------------------------------------------------------------------- */
+/* Local copies                                                               */
+/* -------------------------------------------------------------------------- */
+
+// itemCache holds copies for up to ttl, at most max of them. A drop leaves
+// a tombstone stamped with a generation; a read fills only an entry that
+// hasn't been dropped since the read began.
+type itemCache struct {
+	ttl time.Duration
+	max int
+
+	mu      sync.Mutex
+	gen     uint64
+	floor   uint64 // highest generation of a tombstone since evicted
+	entries map[int]cacheEntry
+}
+
+type cacheEntry struct {
+	item    Item
+	dropped uint64 // nonzero: a tombstone, from that generation
+	expires time.Time
+}
+
+func newItemCache(ttl time.Duration, max int) *itemCache {
+	return &itemCache{ttl: ttl, max: max, entries: map[int]cacheEntry{}}
+}
+
+// get returns id's copy, or the generation to hand fill after loading it.
+func (c *itemCache) get(id int, now time.Time) (item Item, hit bool, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if ok && e.dropped == 0 && now.Before(e.expires) {
+		return e.item, true, 0
+	}
+	return Item{}, false, c.gen
+}
+
+// fill stores item unless it was dropped after generation gen.
+func (c *itemCache) fill(item Item, gen uint64, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[item.ID]; ok {
+		if e.dropped > gen {
+			return
+		}
+	} else if c.floor > gen {
+		return // its tombstone may have been evicted
+	}
+	if _, ok := c.entries[item.ID]; !ok && len(c.entries) >= c.max {
+		c.evict(now)
+	}
+	c.entries[item.ID] = cacheEntry{item: item, expires: now.Add(c.ttl)}
+}
+
+// drop replaces id's copy with a tombstone and reports whether it had one.
+func (c *itemCache) drop(id int, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok && len(c.entries) >= c.max {
+		c.evict(now)
+	}
+	c.gen++
+	c.entries[id] = cacheEntry{dropped: c.gen, expires: now.Add(c.ttl)}
+	return ok && e.dropped == 0
+}
+
+// evict makes room: expired entries first, else any one; c.mu is held.
+func (c *itemCache) evict(now time.Time) {
+	n := len(c.entries)
+	for id, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, id)
+			c.floor = max(c.floor, e.dropped)
+		}
+	}
+	if len(c.entries) < n {
+		return
+	}
+	for id, e := range c.entries {
+		delete(c.entries, id)
+		c.floor = max(c.floor, e.dropped)
+		return
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/* Store                                                                      */
+/* -------------------------------------------------------------------------- */
+
+// cachingStore serves Get from a local copy, dropped on every change here
+// or, via Redis, on any other instance.
+type cachingStore struct {
+	itemStore
+	items   *itemCache
+	rdb     *redis.Client
+	channel string
+	origin  string
+	log     *slog.Logger
+}
+
+// startChangeBroadcast wraps next in the cache and subscribes to the
+// channel when REDIS_ADDR is set; otherwise next is returned as is. The
+// returned func unsubscribes and closes the client.
+func startChangeBroadcast(l *slog.Logger, next itemStore) (itemStore, func()) {
+	addr := envString("REDIS_ADDR", "")
+	if addr == "" {
+		return next, func() {}
+	}
+	channel := envString("REDIS_CHANNEL", "item-changes")
+	s := cachingStore{
+		itemStore: next,
+		items:     newItemCache(envDuration("REDIS_CACHE_TTL", time.Minute), max(envInt("REDIS_CACHE_MAX", 10000), 1)),
+		// fail fast: a broadcast runs inside the request that changed the item
+		rdb:     redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1, DialTimeout: time.Second}),
+		channel: channel,
+		origin:  uuid.NewString(),
+		log:     l.With("redis", addr, "channel", channel),
+	}
+
+	ready.add("redis", func(ctx context.Context) error { return s.rdb.Ping(ctx).Err() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := s.rdb.Subscribe(ctx, s.channel)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range sub.Channel() {
+			s.receive(msg)
+		}
+	}()
+	s.log.Info("item cache broadcasting via redis", "origin", s.origin)
+	return s, func() {
+		cancel()
+		sub.Close()
+		<-done
+		s.rdb.Close()
+	}
+}
+
+func (s cachingStore) Get(ctx context.Context, id int) (Item, error) {
+	cached, hit, gen := s.items.get(id, time.Now())
+	if hit {
+		cacheLookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "hit")))
+		traceSpan(ctx).SetAttributes(attribute.Bool("cache.hit", true))
+		return cached, nil
+	}
+	cacheLookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "miss")))
+	traceSpan(ctx).SetAttributes(attribute.Bool("cache.hit", false))
+	item, err := s.itemStore.Get(ctx, id)
+	if err == nil {
+		s.items.fill(item, gen, time.Now())
+	}
+	return item, err
+}
+
+func (s cachingStore) Create(ctx context.Context, name string) (Item, error) {
+	item, err := s.itemStore.Create(ctx, name)
+	if err == nil {
+		s.changed(ctx, "created", item.ID)
+	}
+	return item, err
+}
+
+func (s cachingStore) Update(ctx context.Context, id int, fn func(Item) (Item, error)) (Item, error) {
+	item, err := s.itemStore.Update(ctx, id, fn)
+	if err == nil {
+		s.changed(ctx, "updated", id)
+	}
+	return item, err
+}
+
+// reverted is changed for a rollback that wrote past the store chain.
+func (s cachingStore) reverted(ctx context.Context, id int) {
+	s.changed(ctx, "reverted", id)
+}
+
+func (s cachingStore) Delete(ctx context.Context, id int) error {
+	err := s.itemStore.Delete(ctx, id)
+	if err == nil {
+		s.changed(ctx, "deleted", id)
+	}
+	return err
+}
+
+// changed drops the local entry and tells the other instances. Like event
+// publishing, a failed broadcast only ends up on the span and in the log.
+func (s cachingStore) changed(ctx context.Context, op string, id int) {
+	s.items.drop(id, time.Now())
+	cacheInvalidations.Add(ctx, 1, metric.WithAttributes(attribute.String("source", "local")))
+
+	ctx, span := tracer.Start(ctx, s.channel+" publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.operation.type", "send"),
+			attribute.String("messaging.destination.name", s.channel),
+			attribute.Int("item.id", id),
+			attribute.String("item.change", op),
+		))
+	defer span.End()
+
+	change := itemChange{Origin: s.origin, ItemID: id, Op: op, Trace: map[string]string{}}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(change.Trace))
+	body, _ := json.Marshal(change)
+	n, err := s.rdb.Publish(ctx, s.channel, body).Result()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.log.WarnContext(ctx, "broadcasting item change", "item_id", id, "err", err)
+		return
+	}
+	span.SetAttributes(attribute.Int64("messaging.redis.receivers", n))
+}
+
+// receive invalidates the entry another instance changed.
+func (s cachingStore) receive(msg *redis.Message) {
+	var change itemChange
+	if err := json.Unmarshal([]byte(msg.Payload), &change); err != nil {
+		s.log.Warn("skipping undecodable item change", "err", err)
+		return
+	}
+	if change.Origin == s.origin {
+		return
+	}
+	producer := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(change.Trace))
+	parent, opts := consumeSpanOptions(producer,
+		attribute.String("messaging.system", "redis"),
+		attribute.String("messaging.operation.type", "receive"),
+		attribute.String("messaging.destination.name", msg.Channel),
+		attribute.Int("item.id", change.ItemID),
+		attribute.String("item.change", change.Op),
+		attribute.String("item.change.origin", change.Origin),
+	)
+	ctx, span := tracer.Start(parent, msg.Channel+" receive", opts...)
+	defer span.End()
+
+	cached := s.items.drop(change.ItemID, time.Now())
+	span.SetAttributes(attribute.Bool("cache.evicted", cached))
+	cacheInvalidations.Add(ctx, 1, metric.WithAttributes(attribute.String("source", "remote")))
+}
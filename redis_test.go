@@ -0,0 +1,189 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestItemCache(t *testing.T) {
+	const ttl = time.Minute
+	t0 := time.Now()
+	item := func(id int) Item { return Item{ID: id, Name: "v"} }
+	tests := []struct {
+		name string
+		max  int
+		run  func(c *itemCache)
+		at   time.Time
+		id   int
+		hit  bool
+	}{
+		{
+			name: "fill then hit",
+			run: func(c *itemCache) {
+				_, _, gen := c.get(1, t0)
+				c.fill(item(1), gen, t0)
+			},
+			at: t0, id: 1, hit: true,
+		},
+		{
+			name: "a read that overlapped a drop does not fill",
+			run: func(c *itemCache) {
+				_, _, gen := c.get(1, t0)
+				c.drop(1, t0)
+				c.fill(item(1), gen, t0)
+			},
+			at: t0, id: 1,
+		},
+		{
+			name: "a read begun after the drop fills",
+			run: func(c *itemCache) {
+				c.drop(1, t0)
+				_, _, gen := c.get(1, t0)
+				c.fill(item(1), gen, t0)
+			},
+			at: t0, id: 1, hit: true,
+		},
+		{
+			name: "entries expire after the TTL",
+			run: func(c *itemCache) {
+				_, _, gen := c.get(1, t0)
+				c.fill(item(1), gen, t0)
+			},
+			at: t0.Add(ttl), id: 1,
+		},
+		{
+			name: "a full cache evicts expired entries first",
+			max:  2,
+			run: func(c *itemCache) {
+				c.fill(item(1), 0, t0)
+				c.fill(item(2), 0, t0.Add(ttl/2))
+				c.fill(item(3), 0, t0.Add(ttl))
+			},
+			at: t0.Add(ttl), id: 2, hit: true,
+		},
+		{
+			name: "an evicted tombstone still turns away a stale fill",
+			max:  1,
+			run: func(c *itemCache) {
+				_, _, gen := c.get(1, t0)
+				c.drop(1, t0)
+				c.fill(item(2), c.gen, t0) // evicts the tombstone
+				c.fill(item(1), gen, t0)
+			},
+			at: t0, id: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newItemCache(ttl, cmp.Or(tt.max, 100))
+			tt.run(c)
+			if _, hit, _ := c.get(tt.id, tt.at); hit != tt.hit {
+				t.Errorf("get(%d) hit = %v, want %v", tt.id, hit, tt.hit)
+			}
+			if len(c.entries) > c.max {
+				t.Errorf("%d entries, max %d", len(c.entries), c.max)
+			}
+		})
+	}
+}
+
+// newTestCachingStore caches next; its broadcasts fail to reach Redis,
+// which only ends up on the span and in the (discarded) log.
+func newTestCachingStore(next itemStore) cachingStore {
+	noRedis := func(context.Context, string, string) (net.Conn, error) { return nil, errors.New("no redis in tests") }
+	return cachingStore{
+		itemStore: next,
+		items:     newItemCache(time.Minute, 100),
+		rdb:       redis.NewClient(&redis.Options{Dialer: noRedis, MaxRetries: -1}),
+		channel:   "item-changes",
+		origin:    "this-instance",
+		log:       slog.New(slog.DiscardHandler),
+	}
+}
+
+func changeMessage(t *testing.T, origin string, id int) *redis.Message {
+	t.Helper()
+	body, err := json.Marshal(itemChange{Origin: origin, ItemID: id, Op: "updated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &redis.Message{Channel: "item-changes", Payload: string(body)}
+}
+
+// Item 1 is cached as "v1", then changes to "v2" behind the cache's back,
+// as another instance would; the case decides what the cache learns of it.
+func TestCachingStoreInvalidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, ctx context.Context, s cachingStore)
+		want   string
+	}{
+		{"nothing heard: the copy is served", func(*testing.T, context.Context, cachingStore) {}, "v1"},
+		{"another instance's message drops the copy", func(t *testing.T, _ context.Context, s cachingStore) {
+			s.receive(changeMessage(t, "other-instance", 1))
+		}, "v2"},
+		{"its own message is ignored", func(t *testing.T, _ context.Context, s cachingStore) {
+			s.receive(changeMessage(t, s.origin, 1))
+		}, "v1"},
+		{"another item's message leaves it", func(t *testing.T, _ context.Context, s cachingStore) {
+			s.receive(changeMessage(t, "other-instance", 2))
+		}, "v1"},
+		{"a rollback via forgetRead drops the copy", func(_ *testing.T, ctx context.Context, s cachingStore) {
+			forgetRead(s)(ctx, 1)
+		}, "v2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			raw := newMemoryStore()
+			_, _ = raw.Create(ctx, "v1")
+			s := newTestCachingStore(raw)
+			defer s.rdb.Close()
+			if _, err := s.Get(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			_ = raw.Put(ctx, Item{ID: 1, Name: "v2"})
+
+			tt.change(t, ctx, s)
+			if got, err := s.Get(ctx, 1); err != nil || got.Name != tt.want {
+				t.Errorf("Get = %+v, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// A write through the cache drops the copy, and a read that was already
+// loading the old value doesn't put it back.
+func TestCachingStoreWriteDuringRead(t *testing.T) {
+	ctx := context.Background()
+	raw := newMemoryStore()
+	_, _ = raw.Create(ctx, "v1")
+	gate := &gatedStore{itemStore: raw, read: make(chan struct{}), release: make(chan struct{})}
+	s := newTestCachingStore(gate)
+	defer s.rdb.Close()
+
+	slow := make(chan readResult, 1)
+	go func() {
+		item, err := s.Get(ctx, 1)
+		slow <- readResult{item, err}
+	}()
+	<-gate.read
+	if _, err := s.Update(ctx, 1, func(it Item) (Item, error) { it.Name = "v2"; return it, nil }); err != nil {
+		t.Fatal(err)
+	}
+	close(gate.release)
+	if got := <-slow; got.item.Name != "v1" {
+		t.Fatalf("slow read = %+v, want the v1 it loaded", got.item)
+	}
+	if got, err := s.Get(ctx, 1); err != nil || got.Name != "v2" {
+		t.Errorf("Get after the write = %+v, %v; want v2", got, err)
+	}
+}
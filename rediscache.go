@@ -0,0 +1,204 @@
+// rediscache.go — the response cache in Redis, shared by every instance
+//   (RESPONSE_CACHE_TTL with REDIS_ADDR), cache-aside:
+//   • a miss runs the handler and SETs the response under
+//     respcache:<path?query> with the TTL as expiry; the key also goes into
+//     the respcache:keys set
+//   • a write on any instance DELs every key in that set, so no instance
+//     serves a response older than the last write (one stored by a request
+//     that raced the DEL lives for the TTL at most)
+//   • stampede protection across instances: the first miss takes
+//     respcache:lock:<key> (SET NX, RESPONSE_CACHE_LOCK_TIMEOUT, 2s); the
+//     others poll for its response up to that long before computing it
+//     themselves; cache.lock=acquired|waited|timeout on the server span
+//   • every command is a CLIENT span (GET, SET, DEL, …; a pipeline is one
+//     span) with db.system.name=redis, db.operation.name and server.address
+//   • Redis being down means misses: the span records the error, the
+//     request goes on
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	redisResponsePrefix = "respcache:"
+	redisResponseKeys   = "respcache:keys"
+	redisResponseLocks  = "respcache:lock:"
+)
+
+type redisResponses struct {
+	rdb   *redis.Client
+	ttl   time.Duration
+	lock  time.Duration
+	owner string // lock value, so an instance only releases its own lock
+}
+
+// releaseLock deletes the lock only while it still holds our value: after
+// a timeout it may belong to another instance.
+var releaseLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func newRedisResponses(addr string, ttl time.Duration) *redisResponses {
+	// fail fast like the change broadcast: every call sits in a request;
+	// no CLIENT SETINFO, older servers fail it and it would show as an error
+	rdb := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1, DialTimeout: time.Second, DisableIdentity: true})
+	rdb.AddHook(newRedisTracing(addr))
+	return &redisResponses{
+		rdb:   rdb,
+		ttl:   ttl,
+		lock:  envDuration("RESPONSE_CACHE_LOCK_TIMEOUT", 2*time.Second),
+		owner: uuid.NewString(),
+	}
+}
+
+func (r *redisResponses) name() string { return "redis" }
+
+func (r *redisResponses) get(ctx context.Context, key string) (cachedResponse, bool) {
+	b, err := r.rdb.Get(ctx, redisResponsePrefix+key).Bytes()
+	if err != nil {
+		return cachedResponse{}, false
+	}
+	var resp cachedResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return cachedResponse{}, false
+	}
+	return resp, true
+}
+
+func (r *redisResponses) put(ctx context.Context, key string, resp cachedResponse) {
+	b, _ := json.Marshal(resp)
+	_, _ = r.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, redisResponsePrefix+key, b, r.ttl)
+		p.SAdd(ctx, redisResponseKeys, key)
+		// the set outlives its newest entry a little, no longer
+		p.PExpire(ctx, redisResponseKeys, 2*r.ttl)
+		return nil
+	})
+}
+
+func (r *redisResponses) clear(ctx context.Context) {
+	keys, err := r.rdb.SMembers(ctx, redisResponseKeys).Result()
+	if err != nil {
+		return
+	}
+	del := []string{redisResponseKeys}
+	for _, k := range keys {
+		del = append(del, redisResponsePrefix+k)
+	}
+	r.rdb.Del(ctx, del...)
+}
+
+func (r *redisResponses) fill(ctx context.Context, key string) (cachedResponse, func()) {
+	span := traceSpan(ctx)
+	lock := redisResponseLocks + key
+	ok, err := r.rdb.SetNX(ctx, lock, r.owner, r.lock).Result()
+	if err != nil {
+		return cachedResponse{}, func() {}
+	}
+	if ok {
+		span.SetAttributes(attribute.String("cache.lock", "acquired"))
+		return cachedResponse{}, func() {
+			releaseLock.Run(context.WithoutCancel(ctx), r.rdb, []string{lock}, r.owner)
+		}
+	}
+
+	wait := time.NewTicker(50 * time.Millisecond)
+	defer wait.Stop()
+	deadline := time.After(r.lock)
+	for {
+		select {
+		case <-wait.C:
+		case <-deadline:
+			span.SetAttributes(attribute.String("cache.lock", "timeout"))
+			return cachedResponse{}, func() {}
+		case <-ctx.Done():
+			return cachedResponse{}, func() {}
+		}
+		if resp, ok := r.get(ctx, key); ok {
+			span.SetAttributes(attribute.String("cache.lock", "waited"))
+			return resp, nil
+		}
+	}
+}
+
+func (r *redisResponses) close() error { return r.rdb.Close() }
+
+/* -------------------------------------------------------------------------- */
+/* Command tracing                                                            */
+/* -------------------------------------------------------------------------- */
+
+// redisTracing is a go-redis hook with a CLIENT span per command.
+type redisTracing struct {
+	attrs []attribute.KeyValue
+}
+
+func newRedisTracing(addr string) redisTracing {
+	attrs := []attribute.KeyValue{attribute.String("db.system.name", "redis")}
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		attrs = append(attrs, attribute.String("server.address", host))
+		if p, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, attribute.Int("server.port", p))
+		}
+	}
+	return redisTracing{attrs: attrs}
+}
+
+func (h redisTracing) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h redisTracing) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		op := strings.ToUpper(cmd.Name())
+		ctx, span := tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(h.attrs...),
+			trace.WithAttributes(attribute.String("db.operation.name", op)))
+		defer span.End()
+		err := next(ctx, cmd)
+		h.finish(span, err)
+		return err
+	}
+}
+
+func (h redisTracing) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ops := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			ops[i] = strings.ToUpper(cmd.Name())
+		}
+		ctx, span := tracer.Start(ctx, "PIPELINE", trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(h.attrs...),
+			trace.WithAttributes(
+				attribute.String("db.operation.name", "PIPELINE"),
+				attribute.Int("db.operation.batch.size", len(cmds)),
+				attribute.StringSlice("db.redis.commands", ops),
+			))
+		defer span.End()
+		err := next(ctx, cmds)
+		h.finish(span, err)
+		return err
+	}
+}
+
+// finish marks the span failed; a missing key (redis.Nil) is no failure.
+func (h redisTracing) finish(span trace.Span, err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
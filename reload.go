@@ -0,0 +1,217 @@
+// reload.go — runtime-tunable settings with SIGHUP hot reload:
+//   • startup values from the config (LOG_LEVEL, SAMPLING_RATIO), overridden
+//     by the YAML file at RUNTIME_CONFIG when present
+//   • an optional `chaos:` block replaces the fault-injection rules
+//   • `rate_limit` and `tenant_rate_limit` retune the limiters serve
+//     mounted; reloads can't mount or remove one
+//   • `kill -HUP <pid>` re-reads it and the --config file, applies the
+//     changes without a restart and records them in a config.reload span
+//     plus a diff log line
+//   • GET/PATCH /admin/runtime reads and changes log_level and
+//     sampling_ratio directly, until the next reload
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gopkg.in/yaml.v3"
+)
+
+type runtimeSettings struct {
+	LogLevel        string       `yaml:"log_level"`
+	SamplingRatio   float64      `yaml:"sampling_ratio"`
+	RateLimit       rateLimits   `yaml:"rate_limit"`
+	TenantRateLimit rateLimits   `yaml:"tenant_rate_limit"`
+	Chaos           *chaosConfig `yaml:"chaos"` // nil leaves admin API changes alone
+}
+
+var (
+	logLevel = new(slog.LevelVar)
+	settings atomic.Pointer[runtimeSettings]
+)
+
+/* -------------------------------------------------------------------------- */
+/* Loading & applying                                                         */
+/* -------------------------------------------------------------------------- */
+
+func loadRuntimeSettings() (*runtimeSettings, error) {
+	s := &runtimeSettings{
+		LogLevel:      envString("LOG_LEVEL", "info"),
+		SamplingRatio: envFloat("SAMPLING_RATIO", 1),
+		RateLimit: rateLimits{
+			RPS:   envFloat("RATE_LIMIT_RPS", 50),
+			Burst: envInt("RATE_LIMIT_BURST", 0),
+		},
+		TenantRateLimit: rateLimits{
+			RPS:   envFloat("TENANT_RATE_LIMIT_RPS", 0),
+			Burst: envInt("TENANT_RATE_LIMIT_BURST", 0),
+		},
+	}
+	if cfg, ok, err := errorInjectionFromEnv(); err != nil {
+		return nil, err
+	} else if ok {
+		s.Chaos = &cfg
+	}
+	path := envString("RUNTIME_CONFIG", "")
+	if path == "" {
+		return s, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(raw, s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return s, s.validate()
+}
+
+func (s *runtimeSettings) validate() error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(s.LogLevel)); err != nil {
+		return fmt.Errorf("log_level: %w", err)
+	}
+	if s.SamplingRatio < 0 || s.SamplingRatio > 1 {
+		return fmt.Errorf("sampling_ratio %v outside [0,1]", s.SamplingRatio)
+	}
+	if clientLimiter != nil && s.RateLimit.RPS <= 0 {
+		return fmt.Errorf("rate_limit.rps must be positive")
+	}
+	if tenantLimiter != nil && s.TenantRateLimit.RPS <= 0 {
+		return fmt.Errorf("tenant_rate_limit.rps must be positive while the limit is on")
+	}
+	if s.Chaos != nil {
+		return s.Chaos.validate()
+	}
+	return nil
+}
+
+// apply pushes s into the live subsystems and makes it current.
+func (s *runtimeSettings) apply() {
+	var lvl slog.Level
+	_ = lvl.UnmarshalText([]byte(s.LogLevel))
+	logLevel.Set(lvl)
+	sampler.setRatio(s.SamplingRatio)
+	if clientLimiter != nil {
+		clientLimiter.setRate(s.RateLimit.RPS, s.RateLimit.burst())
+	}
+	if tenantLimiter != nil {
+		tenantLimiter.setRate(s.TenantRateLimit.RPS, s.TenantRateLimit.burst())
+	}
+	if s.Chaos != nil {
+		chaos.set(*s.Chaos)
+	}
+	settings.Store(s)
+}
+
+// diffSettings lists changed fields as "key: old → new", keyed by yaml name.
+func diffSettings(old, cur *runtimeSettings) []string {
+	var out []string
+	ov, cv := reflect.ValueOf(old).Elem(), reflect.ValueOf(cur).Elem()
+	for i := 0; i < ov.NumField(); i++ {
+		a, b := ov.Field(i).Interface(), cv.Field(i).Interface()
+		if !reflect.DeepEqual(a, b) {
+			key, _, _ := strings.Cut(ov.Type().Field(i).Tag.Get("yaml"), ",")
+			out = append(out, fmt.Sprintf("%s: %v → %v", key, a, b))
+		}
+	}
+	return out
+}
+
+/* -------------------------------------------------------------------------- */
+/* SIGHUP watcher                                                             */
+/* -------------------------------------------------------------------------- */
+
+func watchReload(l *slog.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloadSettings(l)
+		}
+	}()
+}
+
+func reloadSettings(l *slog.Logger) {
+	ctx, span := tracer.Start(context.Background(), "config.reload")
+	defer span.End()
+
+	err := config.reload()
+	next, err2 := loadRuntimeSettings()
+	if err = errors.Join(err, err2); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "reload failed")
+		l.ErrorContext(ctx, "config reload failed; keeping current settings", "err", err)
+		return
+	}
+
+	changes := diffSettings(settings.Load(), next)
+	next.apply()
+	span.SetAttributes(
+		attribute.Int("config.changes", len(changes)),
+		attribute.StringSlice("config.diff", changes),
+	)
+	// never let a raised log_level swallow the record of its own change
+	l.Log(ctx, max(slog.LevelInfo, logLevel.Level()), "config reloaded",
+		"changes", len(changes),
+		"diff", strings.Join(changes, "; "),
+		"trace_id", span.SpanContext().TraceID().String(),
+	)
+}
+
+/* -------------------------------------------------------------------------- */
+/* Admin API                                                                  */
+/* -------------------------------------------------------------------------- */
+
+func registerRuntimeAdmin(r gin.IRouter) {
+	status := func(c *gin.Context) {
+		cur := settings.Load()
+		c.JSON(http.StatusOK, gin.H{"log_level": cur.LogLevel, "sampling_ratio": cur.SamplingRatio})
+	}
+	r.GET("/admin/runtime", status)
+
+	// {"log_level":"debug"} and/or {"sampling_ratio":0.1}; omitted fields stay
+	r.PATCH("/admin/runtime", func(c *gin.Context) {
+		var in struct {
+			LogLevel      *string  `json:"log_level"`
+			SamplingRatio *float64 `json:"sampling_ratio"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil {
+			respondBindError(c, err)
+			return
+		}
+		cur := settings.Load()
+		next := *cur
+		next.Chaos = nil // the chaos admin API owns those rules
+		if in.LogLevel != nil {
+			next.LogLevel = *in.LogLevel
+		}
+		if in.SamplingRatio != nil {
+			next.SamplingRatio = *in.SamplingRatio
+		}
+		if err := next.validate(); err != nil {
+			respondError(c, err, http.StatusBadRequest)
+			return
+		}
+		old := *cur
+		old.Chaos = nil
+		changes := diffSettings(&old, &next)
+		next.apply()
+		traceSpan(c.Request.Context()).SetAttributes(attribute.StringSlice("config.diff", changes))
+		status(c)
+	})
+}
@@ -0,0 +1,197 @@
+// replay.go — replays a recorded request log for reproducible demo data:
+//   • REPLAY_FILE names a JSONL file, one request per line:
+//       {"ts":"2024-05-01T10:00:00.120Z","method":"POST","path":"/items",
+//        "headers":{"Content-Type":"application/json"},"body":{"name":"a"}}
+//     ("offset_ms" may replace "ts"; "body" is raw JSON or a string)
+//   • original pacing is kept, scaled by REPLAY_SPEED; REPLAY_LOOP repeats
+//   • recorded trace headers are dropped so every request starts a fresh
+//     trace under its own replay.request root span
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type recordedRequest struct {
+	TS       time.Time         `json:"ts"`
+	OffsetMS *float64          `json:"offset_ms"`
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers"`
+	Body     json.RawMessage   `json:"body"`
+
+	at time.Duration // offset from the first request
+}
+
+// traceHeaders are never replayed: each request gets a new trace.
+var traceHeaders = []string{"traceparent", "tracestate", "baggage", "x-request-id"}
+
+func loadRecording(path string) ([]recordedRequest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		out   []recordedRequest
+		first time.Time
+	)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 4<<20)
+	for line := 1; sc.Scan(); line++ {
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var r recordedRequest
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if r.Method == "" || !strings.HasPrefix(r.Path, "/") {
+			return nil, fmt.Errorf("%s:%d: method and an absolute path are required", path, line)
+		}
+		switch {
+		case r.OffsetMS != nil:
+			r.at = time.Duration(*r.OffsetMS * float64(time.Millisecond))
+		case !r.TS.IsZero():
+			if first.IsZero() {
+				first = r.TS
+			}
+			r.at = r.TS.Sub(first)
+		case len(out) > 0:
+			r.at = out[len(out)-1].at
+		}
+		out = append(out, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%s: no requests", path)
+	}
+	return out, nil
+}
+
+// body decodes a JSON string body; anything else is sent verbatim.
+func (r recordedRequest) body() []byte {
+	var s string
+	if len(r.Body) > 0 && r.Body[0] == '"' && json.Unmarshal(r.Body, &s) == nil {
+		return []byte(s)
+	}
+	if string(r.Body) == "null" {
+		return nil
+	}
+	return r.Body
+}
+
+/* -------------------------------------------------------------------------- */
+/* Replay                                                                     */
+/* -------------------------------------------------------------------------- */
+
+// startReplay replays REPLAY_FILE in the background when set; the returned
+// func stops it and waits for in-flight requests.
+func startReplay(l *slog.Logger) (func(), error) {
+	path := envString("REPLAY_FILE", "")
+	if path == "" {
+		return func() {}, nil
+	}
+	reqs, err := loadRecording(path)
+	if err != nil {
+		return nil, err
+	}
+	speed := envFloat("REPLAY_SPEED", 1)
+	if speed <= 0 {
+		return nil, fmt.Errorf("REPLAY_SPEED must be positive")
+	}
+	loop := envBool("REPLAY_LOOP", false)
+	target := envString("REPLAY_TARGET", "")
+	if target == "" {
+		if target, err = selfURL(); err != nil {
+			return nil, fmt.Errorf("REPLAY_TARGET: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		for pass := 1; ; pass++ {
+			l.Info("replaying recording", "file", path, "requests", len(reqs), "speed", speed, "pass", pass)
+			start := time.Now()
+			for i, r := range reqs {
+				due := start.Add(time.Duration(float64(r.at) / speed))
+				if sleepCtx(ctx, time.Until(due)) != nil {
+					return
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					replayOne(context.WithoutCancel(ctx), target, i, r)
+				}()
+			}
+			if !loop {
+				l.Info("replay finished", "file", path)
+				return
+			}
+			// a short pause between passes keeps tiny recordings from spinning
+			if sleepCtx(ctx, time.Second) != nil {
+				return
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
+
+func replayOne(ctx context.Context, target string, i int, r recordedRequest) {
+	ctx, span := tracer.Start(ctx, "replay.request", trace.WithNewRoot(), trace.WithAttributes(
+		attribute.Int("replay.index", i),
+		attribute.String("http.request.method", r.Method),
+		attribute.String("url.path", r.Path),
+	))
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, target+r.Path, bytes.NewReader(r.body()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+	for _, h := range traceHeaders {
+		req.Header.Del(h)
+	}
+
+	resp, err := outboundClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+}
@@ -0,0 +1,68 @@
+// requestid.go — X-Request-ID handling:
+//   • accept a sane inbound ID or generate a UUIDv4
+//   • echo it on the response, tag the span & log line with it
+//   • requestIDTransport forwards it on outgoing HTTP calls
+
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const headerRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(headerRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+		c.Header(headerRequestID, id)
+		traceSpan(c.Request.Context()).SetAttributes(attribute.String("http.request.id", id))
+		c.Next()
+	}
+}
+
+// validRequestID accepts up to 128 visible ASCII characters, so client
+// supplied IDs cannot inject into headers or log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDTransport copies the request ID from the outgoing request's
+// context onto its headers.
+type requestIDTransport struct{ base http.RoundTripper }
+
+func (t requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := requestIDFromContext(req.Context()); id != "" && req.Header.Get(headerRequestID) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(headerRequestID, id)
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
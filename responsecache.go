@@ -0,0 +1,308 @@
+// responsecache.go — short-lived cache of GET /items and GET /items/:id
+//   responses, off unless RESPONSE_CACHE_TTL is set:
+//   • in process by default; with REDIS_ADDR set too, shared by all
+//     instances through Redis (rediscache.go)
+//   • keyed by path and query; only 200s are kept, body and the headers the
+//     handler set (X-Total-Count, Link), for the TTL, at most
+//     RESPONSE_CACHE_MAX_ENTRIES (1000) of them in process
+//   • every successful write through the store drops the whole cache (it
+//     is a publisher on the store, like the change feed), so this
+//     instance never serves a stale item after its own write; without
+//     Redis other instances' writes show up within the TTL
+//   • concurrent misses for one key wait for the first one's response
+//     instead of all running the handler (cache.coalesced on their spans)
+//   • cache.hit=true|false on the server span and an X-Cache header;
+//     http.response_cache.lookups by result and backend plus a hit-ratio
+//     gauge
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type cachedResponse struct {
+	Header http.Header `json:"header"` // what the handler set (Content-Type, X-Total-Count, Link, …)
+	Body   []byte      `json:"body"`
+}
+
+// responseBackend holds the cached responses for a responseCache.
+type responseBackend interface {
+	name() string
+	get(ctx context.Context, key string) (cachedResponse, bool)
+	put(ctx context.Context, key string, r cachedResponse)
+	clear(ctx context.Context)
+	// fill is called on a miss before the handler runs. It either finds
+	// the response another instance computed meanwhile (release is nil)
+	// or lets this one compute it and returns the func to call after.
+	fill(ctx context.Context, key string) (r cachedResponse, release func())
+	close() error
+}
+
+type responseCache struct {
+	backend responseBackend
+
+	mu      sync.Mutex
+	gen     int // bumped by every invalidation
+	flights map[string]*responseFlight
+
+	hits, misses atomic.Int64
+}
+
+// uncachedHeaders describe how this one response went out: the recorded
+// body is the handler's, from before compression, which encodes each hit
+// afresh for its own Accept-Encoding.
+var uncachedHeaders = []string{"Content-Encoding", "Content-Length", "Vary"}
+
+// responseFlight is a handler run the other misses for its key wait for.
+type responseFlight struct {
+	done chan struct{}
+	r    cachedResponse
+	ok   bool // r is a 200 to serve
+}
+
+var responseCacheLookups, _ = meter.Int64Counter("http.response_cache.lookups",
+	metric.WithDescription("Response cache lookups by result (hit|miss) and backend"))
+
+// newResponseCache returns nil when RESPONSE_CACHE_TTL is unset or 0.
+func newResponseCache(l *slog.Logger) *responseCache {
+	ttl := envDuration("RESPONSE_CACHE_TTL", 0)
+	if ttl <= 0 {
+		return nil
+	}
+	var backend responseBackend
+	if addr := envString("REDIS_ADDR", ""); addr != "" {
+		backend = newRedisResponses(addr, ttl)
+	} else {
+		backend = newMemoryResponses(ttl, envInt("RESPONSE_CACHE_MAX_ENTRIES", 1000))
+	}
+	rc := &responseCache{backend: backend, flights: map[string]*responseFlight{}}
+	_, _ = meter.Float64ObservableGauge("http.response_cache.hit_ratio",
+		metric.WithDescription("Share of response cache lookups served from the cache since start"),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			h, m := rc.hits.Load(), rc.misses.Load()
+			if h+m > 0 {
+				o.Observe(float64(h) / float64(h+m))
+			}
+			return nil
+		}))
+	l.Info("response cache on", "backend", backend.name(), "ttl", ttl)
+	return rc
+}
+
+// handler serves from the cache or records the handler's 200 into it; a
+// nil cache passes everything through.
+func (rc *responseCache) handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rc == nil {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		key := c.Request.URL.RequestURI()
+		if r, ok := rc.backend.get(ctx, key); ok {
+			rc.serve(c, r)
+			return
+		}
+
+		// a miss: join the run already under way for key, or start one
+		rc.mu.Lock()
+		gen := rc.gen
+		if f, ok := rc.flights[key]; ok {
+			rc.mu.Unlock()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				c.AbortWithStatus(contextErrorStatus(ctx.Err()))
+				return
+			}
+			if f.ok {
+				traceSpan(ctx).SetAttributes(attribute.Bool("cache.coalesced", true))
+				rc.serve(c, f.r)
+				return
+			}
+			// that run failed; ours may not
+			rc.miss(c)
+			c.Next()
+			return
+		}
+		f := &responseFlight{done: make(chan struct{})}
+		rc.flights[key] = f
+		rc.mu.Unlock()
+		defer func() {
+			rc.mu.Lock()
+			delete(rc.flights, key)
+			rc.mu.Unlock()
+			close(f.done)
+		}()
+
+		r, release := rc.backend.fill(ctx, key)
+		if release == nil {
+			f.r, f.ok = r, true
+			rc.serve(c, r)
+			return
+		}
+		defer release()
+
+		rc.miss(c)
+		before := c.Writer.Header().Clone()
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		if w.Status() != http.StatusOK {
+			return
+		}
+		header := http.Header{}
+		for k, v := range w.Header() {
+			if _, ok := before[k]; !ok && !slices.Contains(uncachedHeaders, k) {
+				header[k] = slices.Clone(v)
+			}
+		}
+		f.r, f.ok = cachedResponse{Header: header, Body: w.body.Bytes()}, true
+
+		// the response may predate a write made while the handler ran
+		rc.mu.Lock()
+		current := gen == rc.gen
+		rc.mu.Unlock()
+		if current {
+			rc.backend.put(ctx, key, f.r)
+		}
+	}
+}
+
+func (rc *responseCache) serve(c *gin.Context, r cachedResponse) {
+	rc.lookup(c.Request.Context(), "hit")
+	traceSpan(c.Request.Context()).SetAttributes(attribute.Bool("cache.hit", true))
+	c.Header("X-Cache", "HIT")
+	for k, v := range r.Header {
+		c.Writer.Header()[k] = v
+	}
+	c.Data(http.StatusOK, r.Header.Get("Content-Type"), r.Body)
+	c.Abort()
+}
+
+func (rc *responseCache) miss(c *gin.Context) {
+	rc.lookup(c.Request.Context(), "miss")
+	traceSpan(c.Request.Context()).SetAttributes(attribute.Bool("cache.hit", false))
+	c.Header("X-Cache", "MISS")
+}
+
+func (rc *responseCache) lookup(ctx context.Context, result string) {
+	if result == "hit" {
+		rc.hits.Add(1)
+	} else {
+		rc.misses.Add(1)
+	}
+	responseCacheLookups.Add(context.WithoutCancel(ctx), 1, metric.WithAttributes(
+		attribute.String("result", result), attribute.String("cache.backend", rc.backend.name())))
+}
+
+// Publish drops every entry: any change can alter every list page.
+func (rc *responseCache) Publish(ctx context.Context, _ itemEvent) error {
+	rc.mu.Lock()
+	rc.gen++
+	rc.mu.Unlock()
+	rc.backend.clear(ctx)
+	return nil
+}
+
+func (rc *responseCache) Close() error { return rc.backend.close() }
+
+// recordingWriter keeps a copy of the body it writes.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+/* -------------------------------------------------------------------------- */
+/* In-process backend                                                         */
+/* -------------------------------------------------------------------------- */
+
+type memoryResponses struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]memoryResponse
+}
+
+type memoryResponse struct {
+	cachedResponse
+	expires time.Time
+}
+
+func newMemoryResponses(ttl time.Duration, maxEntries int) *memoryResponses {
+	m := &memoryResponses{ttl: ttl, maxEntries: maxEntries, entries: map[string]memoryResponse{}}
+	_, _ = meter.Int64ObservableGauge("http.response_cache.entries",
+		metric.WithDescription("Responses currently cached in process"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			o.Observe(int64(len(m.entries)))
+			return nil
+		}))
+	return m
+}
+
+func (m *memoryResponses) name() string { return "memory" }
+
+func (m *memoryResponses) get(_ context.Context, key string) (cachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.entries[key]
+	if !ok || time.Now().After(r.expires) {
+		return cachedResponse{}, false
+	}
+	return r.cachedResponse, true
+}
+
+func (m *memoryResponses) put(_ context.Context, key string, r cachedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.entries) >= m.maxEntries {
+		now := time.Now()
+		for k, e := range m.entries {
+			if now.After(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+		if len(m.entries) >= m.maxEntries {
+			return
+		}
+	}
+	m.entries[key] = memoryResponse{r, time.Now().Add(m.ttl)}
+}
+
+func (m *memoryResponses) clear(context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.entries)
+}
+
+// fill has no one else to wait for: the flights cover this process.
+func (m *memoryResponses) fill(context.Context, string) (cachedResponse, func()) {
+	return cachedResponse{}, func() {}
+}
+
+func (m *memoryResponses) close() error { return nil }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A hit is compressed for its own request: the cache must keep neither the
+// miss's Content-Encoding nor a body in that encoding.
+func TestResponseCacheUnderCompression(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rc := &responseCache{backend: newMemoryResponses(time.Minute, 10), flights: map[string]*responseFlight{}}
+	body := `[` + strings.Repeat(`{"id":1,"name":"fountain pen"},`, 100) + `{"id":2,"name":"ink"}]`
+	r := gin.New()
+	r.Use(compression(gzip.DefaultCompression, 1024))
+	r.GET("/items", rc.handler(), func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(body))
+	})
+
+	get := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	gunzip := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		t.Helper()
+		zr, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("X-Cache %s: body is not gzip: %v", w.Header().Get("X-Cache"), err)
+		}
+		b, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	for i, want := range []string{"MISS", "HIT"} {
+		w := get("gzip")
+		if got := w.Header().Get("X-Cache"); got != want {
+			t.Fatalf("request %d: X-Cache = %q, want %q", i+1, got, want)
+		}
+		if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("%s: Content-Encoding = %q, want gzip", want, got)
+		}
+		if got := gunzip(t, w); got != body {
+			t.Fatalf("%s: decoded body differs from the handler's", want)
+		}
+	}
+
+	w := get("")
+	if got := w.Header().Get("X-Cache"); got != "HIT" {
+		t.Fatalf("X-Cache = %q, want HIT", got)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("uncompressed hit has Content-Encoding %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "" {
+		t.Fatalf("uncompressed hit has Vary %q", got)
+	}
+	if w.Body.String() != body {
+		t.Fatal("uncompressed hit body differs from the handler's")
+	}
+}
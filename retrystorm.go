@@ -0,0 +1,139 @@
+// retrystorm.go — GET /scenario/retry-storm?callers=5&retries=5&mode=storm
+//   Several concurrent callers hit an always-failing downstream (/fail) and
+//   retry without delay, producing a wall of sibling client spans.
+//   mode=budget shares a retry budget (budget_ratio × first attempts) across
+//   the callers, which is how real clients keep retries from amplifying load.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// retryBudget allows retries up to ratio × first attempts (at least min).
+type retryBudget struct {
+	mu       sync.Mutex
+	ratio    float64
+	min      int
+	attempts int
+	retries  int
+}
+
+func (b *retryBudget) recordAttempt() {
+	b.mu.Lock()
+	b.attempts++
+	b.mu.Unlock()
+}
+
+func (b *retryBudget) allowRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.retries < b.min || float64(b.retries) < b.ratio*float64(b.attempts) {
+		b.retries++
+		return true
+	}
+	return false
+}
+
+func retryStormHandler(c *gin.Context) {
+	callers, err1 := strconv.Atoi(c.DefaultQuery("callers", "5"))
+	retries, err2 := strconv.Atoi(c.DefaultQuery("retries", "5"))
+	ratio, err3 := strconv.ParseFloat(c.DefaultQuery("budget_ratio", "0.2"), 64)
+	mode := c.DefaultQuery("mode", "storm")
+	if err1 != nil || err2 != nil || err3 != nil ||
+		callers < 1 || callers > 50 || retries < 0 || retries > 20 || ratio < 0 ||
+		(mode != "storm" && mode != "budget") {
+		respondError(c, fmt.Errorf("want callers 1-50, retries 0-20, budget_ratio ≥ 0, mode storm|budget"), http.StatusBadRequest)
+		return
+	}
+
+	self, err := selfURL()
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	ctx := c.Request.Context()
+	var budget *retryBudget
+	if mode == "budget" {
+		budget = &retryBudget{ratio: ratio, min: 1}
+	}
+
+	var (
+		wg               sync.WaitGroup
+		mu               sync.Mutex
+		attempts, denied int
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a, d := retryingCall(ctx, self, c.Request, i, retries, budget)
+			mu.Lock()
+			attempts += a
+			denied += d
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	traceSpan(ctx).SetAttributes(
+		attribute.String("scenario.retry.mode", mode),
+		attribute.Int("scenario.retry.attempts", attempts),
+		attribute.Int("scenario.retry.denied", denied),
+		attribute.Float64("scenario.retry.amplification", float64(attempts)/float64(callers)),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"mode":          mode,
+		"callers":       callers,
+		"attempts":      attempts,
+		"denied":        denied,
+		"amplification": float64(attempts) / float64(callers),
+	})
+}
+
+// retryingCall makes one logical call with up to maxRetries immediate
+// retries; it returns total attempts and retries refused by the budget.
+func retryingCall(ctx context.Context, self string, in *http.Request, caller, maxRetries int, budget *retryBudget) (attempts, denied int) {
+	ctx, span := tracer.Start(ctx, "retry.call", trace.WithAttributes(attribute.Int("retry.caller", caller)))
+	defer span.End()
+
+	if budget != nil {
+		budget.recordAttempt()
+	}
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			if budget != nil && !budget.allowRetry() {
+				span.AddEvent("retry.budget_exhausted", trace.WithAttributes(attribute.Int("retry.attempt", attempt)))
+				denied++
+				break
+			}
+			span.AddEvent("retry", trace.WithAttributes(attribute.Int("retry.attempt", attempt)))
+		}
+
+		attempts++
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, self+"/fail", nil)
+		if err != nil {
+			break
+		}
+		forwardAuth(req, in)
+		resp, err := outboundOnce.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 500 {
+				return attempts, denied
+			}
+		}
+	}
+	span.SetStatus(codes.Error, "all attempts failed")
+	span.SetAttributes(attribute.Int("retry.attempts", attempts))
+	return attempts, denied
+}
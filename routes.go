@@ -0,0 +1,151 @@
+// routes.go — GET /debug/routes: the route table as Gin actually built it,
+//   for each router serve mounted ("api", plus "ops" with ADMIN_LISTEN):
+//   • method, path, handler and the middleware chain in the order it runs
+//   • the Gin mode and which optional middlewares (GIN_MODE, COMPRESS, CORS,
+//     RATE_LIMIT) serve mounted
+//   • chains are read from the router itself, not from the code, so a
+//     middleware added with Use after a route was registered (and therefore
+//     not applied to it) shows up missing, as it is
+
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	routersMu sync.Mutex
+	routers   []mountedRouter
+)
+
+type mountedRouter struct {
+	name   string
+	engine *gin.Engine
+}
+
+// mountRouter makes engine's routes visible on /debug/routes.
+func mountRouter(name string, engine *gin.Engine) {
+	routersMu.Lock()
+	defer routersMu.Unlock()
+	routers = append(routers, mountedRouter{name, engine})
+}
+
+// httpMiddleware records the optional API middlewares serve mounted.
+type httpMiddleware struct {
+	Compression bool `json:"compression"`
+	CORS        bool `json:"cors"`
+	RateLimit   bool `json:"rate_limit"`
+}
+
+var mountedMiddleware httpMiddleware
+
+type routeEntry struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware"`
+}
+
+func registerRoutesDebug(r gin.IRouter) {
+	r.GET("/debug/routes", func(c *gin.Context) {
+		routersMu.Lock()
+		mounted := slices.Clone(routers)
+		routersMu.Unlock()
+
+		out := make(map[string][]routeEntry, len(mounted))
+		for _, m := range mounted {
+			out[m.name] = routeTable(m.engine)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"gin_mode":   gin.Mode(),
+			"middleware": mountedMiddleware,
+			"routers":    out,
+		})
+	})
+}
+
+// routeTable lists engine's routes sorted by path, then method.
+func routeTable(engine *gin.Engine) []routeEntry {
+	chains := handlerChains(engine)
+	var out []routeEntry
+	for _, ri := range engine.Routes() {
+		e := routeEntry{Method: ri.Method, Path: ri.Path, Handler: funcName(ri.HandlerFunc), Middleware: []string{}}
+		if chain := chains[ri.Method+" "+ri.Path]; len(chain) > 0 {
+			e.Middleware = chain[:len(chain)-1]
+		}
+		out = append(out, e)
+	}
+	slices.SortFunc(out, func(a, b routeEntry) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return out
+}
+
+// handlerChains walks Gin's (unexported) method trees for the full handler
+// chain of each "METHOD /path". engine.Routes only exposes the last handler;
+// if a Gin upgrade changes the tree layout this yields nothing and the
+// middleware lists are left empty rather than wrong.
+func handlerChains(engine *gin.Engine) map[string][]string {
+	out := map[string][]string{}
+	trees := reflect.ValueOf(engine).Elem().FieldByName("trees")
+	if trees.Kind() != reflect.Slice {
+		return out
+	}
+	var walk func(method string, n reflect.Value)
+	walk = func(method string, n reflect.Value) {
+		if n.Kind() != reflect.Pointer || n.IsNil() {
+			return
+		}
+		n = n.Elem()
+		handlers, fullPath := n.FieldByName("handlers"), n.FieldByName("fullPath")
+		if handlers.Kind() == reflect.Slice && handlers.Len() > 0 && fullPath.Kind() == reflect.String {
+			chain := make([]string, handlers.Len())
+			for i := range chain {
+				chain[i] = pcName(handlers.Index(i).Pointer())
+			}
+			out[method+" "+fullPath.String()] = chain
+		}
+		if children := n.FieldByName("children"); children.Kind() == reflect.Slice {
+			for i := 0; i < children.Len(); i++ {
+				walk(method, children.Index(i))
+			}
+		}
+	}
+	for i := 0; i < trees.Len(); i++ {
+		t := trees.Index(i)
+		if method := t.FieldByName("method"); method.Kind() == reflect.String {
+			walk(method.String(), t.FieldByName("root"))
+		}
+	}
+	return out
+}
+
+func funcName(f gin.HandlerFunc) string { return pcName(reflect.ValueOf(f).Pointer()) }
+
+// closureSuffix matches the ".func1" / ".func2.1" Go appends to closures.
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+(\.\d+)*$`)
+
+// pcName turns a code pointer into "package.Function", dropping the import
+// path and closure suffixes: otelgin.Middleware, main.apiKeyAuth.
+func pcName(pc uintptr) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "?"
+	}
+	name := closureSuffix.ReplaceAllString(fn.Name(), "")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
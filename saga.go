@@ -0,0 +1,121 @@
+// saga.go — GET /scenario/saga?fail_at=charge runs an order saga against
+//   the echo service: reserve → charge → confirm, each a saga.step span
+//   around its call. When a step fails (fail_at, or at random with
+//   fail_rate) the completed steps are undone in reverse order under
+//   saga.compensate spans (release, refund), so one trace shows the whole
+//   compensation path; saga.outcome on the request span says how it ended.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/micro-company/http-trace-example/internal/httpclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sagaStep pairs an action with what undoes it ("" when nothing can).
+type sagaStep struct {
+	name, compensation string
+}
+
+var orderSaga = []sagaStep{
+	{"reserve", "release"},
+	{"charge", "refund"},
+	{"confirm", ""},
+}
+
+func sagaHandler(c *gin.Context) {
+	failAt := c.Query("fail_at")
+	if failAt != "" && !slices.ContainsFunc(orderSaga, func(s sagaStep) bool { return s.name == failAt }) {
+		respondError(c, errors.New("fail_at must be reserve, charge or confirm"), http.StatusBadRequest)
+		return
+	}
+	failRate, err := strconv.ParseFloat(c.DefaultQuery("fail_rate", "0"), 64)
+	if err != nil || failRate < 0 || failRate > 1 {
+		respondError(c, errors.New("fail_rate must be within [0,1]"), http.StatusBadRequest)
+		return
+	}
+	if !echoEnabled() {
+		respondError(c, errors.New("echo service disabled"), http.StatusNotFound)
+		return
+	}
+
+	ctx := c.Request.Context()
+	var done []sagaStep
+	for _, st := range orderSaga {
+		fail := st.name == failAt || (failRate > 0 && rand.Float64() < failRate)
+		if err := sagaCall(ctx, c.Request, "saga.step", st.name, fail, outboundOnce); err != nil {
+			outcome := compensate(ctx, c.Request, done)
+			traceSpan(ctx).SetAttributes(
+				attribute.String("saga.failed_step", st.name),
+				attribute.String("saga.outcome", outcome),
+			)
+			respondError(c, fmt.Errorf("saga %s: step %s failed: %w", outcome, st.name, err), http.StatusBadGateway)
+			return
+		}
+		done = append(done, st)
+	}
+
+	traceSpan(ctx).SetAttributes(attribute.String("saga.outcome", "committed"))
+	c.JSON(http.StatusOK, gin.H{"outcome": "committed", "steps": len(done)})
+}
+
+// compensate undoes done in reverse order. Compensations are retried (the
+// shared outbound client) and all run even if one fails.
+func compensate(ctx context.Context, in *http.Request, done []sagaStep) (outcome string) {
+	outcome = "compensated"
+	for _, st := range slices.Backward(done) {
+		if st.compensation == "" {
+			continue
+		}
+		if err := sagaCall(ctx, in, "saga.compensate", st.compensation, false, outboundClient); err != nil {
+			outcome = "compensation_failed"
+		}
+	}
+	return outcome
+}
+
+// sagaCall runs one action against the echo service in a span called kind;
+// fail asks the echo service for a 503.
+func sagaCall(ctx context.Context, in *http.Request, kind, action string, fail bool, client *httpclient.Client) error {
+	ctx, span := tracer.Start(ctx, kind+" "+action, trace.WithAttributes(
+		attribute.String("saga.action", action),
+		attribute.String("peer.service", "echo"),
+	))
+	defer span.End()
+
+	status := http.StatusOK
+	if fail {
+		status = http.StatusServiceUnavailable
+		span.SetAttributes(attribute.Bool("injected", true))
+	}
+	url := fmt.Sprintf("%s/echo?saga=%s&delay=%dms&status=%d", echoURL(), action, 5+rand.IntN(20), status)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err == nil {
+		forwardAuth(req, in)
+		var resp *http.Response
+		if resp, err = client.Do(req); err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode >= 400 {
+				err = fmt.Errorf("echo returned %d", resp.StatusCode)
+			}
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
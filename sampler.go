@@ -0,0 +1,44 @@
+// sampler.go — parent-based ratio sampler whose ratio can change at runtime;
+//   self-test marker spans are always sampled.
+
+package main
+
+import (
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// selftestMarker on a root span bypasses the ratio.
+var selftestMarker = attribute.Bool("selftest.marker", true)
+
+type dynamicSampler struct {
+	current atomic.Pointer[sdktrace.Sampler]
+}
+
+var sampler = newDynamicSampler(1)
+
+func newDynamicSampler(ratio float64) *dynamicSampler {
+	s := &dynamicSampler{}
+	s.setRatio(ratio)
+	return s
+}
+
+func (s *dynamicSampler) setRatio(ratio float64) {
+	next := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+	s.current.Store(&next)
+}
+
+func (s *dynamicSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, kv := range p.Attributes {
+		if kv.Key == selftestMarker.Key && kv.Value.AsBool() {
+			return sdktrace.AlwaysSample().ShouldSample(p)
+		}
+	}
+	return (*s.current.Load()).ShouldSample(p)
+}
+
+func (s *dynamicSampler) Description() string {
+	return "Dynamic{" + (*s.current.Load()).Description() + "}"
+}
@@ -0,0 +1,167 @@
+// scenario.go — GET /scenario/run?name=checkout runs a scripted multi-step
+//   flow against the app's own API under one trace: every step gets a
+//   scenario.step span wrapping the outbound call, so workshops get deep,
+//   realistic call chains from a single curl.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scenarioStep is one API call; "{id}" in path is replaced with the ID
+// returned by the most recent create, "{name}" in body with a unique name.
+type scenarioStep struct {
+	name   string
+	method string
+	path   string
+	body   string
+}
+
+var scenarios = map[string][]scenarioStep{
+	"checkout": {
+		{"create", http.MethodPost, "/items", `{"name":"{name}"}`},
+		{"get", http.MethodGet, "/items/{id}", ""},
+		{"update", http.MethodPut, "/items/{id}", `{"name":"{name}-paid"}`},
+		{"search", http.MethodGet, "/items", ""},
+		{"delete", http.MethodDelete, "/items/{id}", ""},
+	},
+	"browse": {
+		{"list", http.MethodGet, "/items", ""},
+		{"search", http.MethodGet, "/items", ""},
+		{"render", http.MethodGet, "/slow?ms=120&stages=3", ""},
+	},
+	"flaky-checkout": {
+		{"create", http.MethodPost, "/items", `{"name":"{name}"}`},
+		{"get", http.MethodGet, "/items/{id}", ""},
+		{"payment", http.MethodGet, "/fail", ""},
+		{"delete", http.MethodDelete, "/items/{id}", ""},
+	},
+}
+
+type stepResult struct {
+	Step       string  `json:"step"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+func scenarioRunHandler(c *gin.Context) {
+	name := c.Query("name")
+	steps, ok := scenarios[name]
+	if !ok {
+		names := make([]string, 0, len(scenarios))
+		for n := range scenarios {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		respondError(c, fmt.Errorf("unknown scenario %q; want one of %s", name, strings.Join(names, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	self, err := selfURL()
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	ctx := c.Request.Context()
+	traceSpan(ctx).SetAttributes(attribute.String("scenario.name", name))
+
+	var (
+		results = make([]stepResult, 0, len(steps))
+		itemID  string
+		label   = fmt.Sprintf("%s-%d", name, time.Now().UnixNano())
+	)
+	for i, st := range steps {
+		start := time.Now()
+		res, id := runScenarioStep(ctx, self, i, st, itemID, label, func(req *http.Request) { forwardAuth(req, c.Request) })
+		res.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+		if id != "" {
+			itemID = id
+		}
+		results = append(results, res)
+		if res.Error != "" {
+			traceSpan(ctx).SetAttributes(attribute.String("scenario.failed_step", st.name))
+			respondError(c, fmt.Errorf("scenario %s: step %s: %s", name, st.name, res.Error), http.StatusBadGateway)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"scenario": name, "steps": results})
+}
+
+// runScenarioStep performs one step against self; auth adds credentials.
+// It returns the created item ID when the response carries one.
+func runScenarioStep(ctx context.Context, self string, i int, st scenarioStep, itemID, label string, auth func(*http.Request)) (stepResult, string) {
+	path := strings.ReplaceAll(st.path, "{id}", itemID)
+	body := strings.ReplaceAll(st.body, "{name}", label)
+	res := stepResult{Step: st.name, Method: st.method, Path: path}
+
+	ctx, span := tracer.Start(ctx, "scenario.step "+st.name, trace.WithAttributes(
+		attribute.Int("scenario.step.index", i),
+		attribute.String("scenario.step.name", st.name),
+	))
+	defer span.End()
+
+	fail := func(err error) (stepResult, string) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		res.Error = err.Error()
+		return res, ""
+	}
+
+	req, err := http.NewRequestWithContext(ctx, st.method, self+path, strings.NewReader(body))
+	if err != nil {
+		return fail(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	auth(req)
+
+	resp, err := outboundClient.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	res.Status = resp.StatusCode
+	if resp.StatusCode >= 400 {
+		return fail(fmt.Errorf("%s %s returned %d", st.method, path, resp.StatusCode))
+	}
+
+	if st.name == "search" {
+		// no server-side search yet: filter the listing as a client would
+		var items []Item
+		_ = json.Unmarshal(raw, &items)
+		matches := 0
+		for _, it := range items {
+			if strings.HasPrefix(it.Name, label) {
+				matches++
+			}
+		}
+		span.SetAttributes(attribute.Int("scenario.search.matches", matches))
+	}
+
+	var created struct{ ID *int }
+	if st.method == http.MethodPost && json.Unmarshal(raw, &created) == nil && created.ID != nil {
+		span.SetAttributes(attribute.Int("item.id", *created.ID))
+		return res, strconv.Itoa(*created.ID)
+	}
+	return res, ""
+}
@@ -0,0 +1,108 @@
+// schedule.go — cron-style background tasks:
+//   • SCHEDULE_SWEEPER (@every 1m) forgets expired async jobs
+//   • SCHEDULE_SNAPSHOT (*/5 * * * *) writes SNAPSHOT_FILE, when set
+//   • SCHEDULE_CANARY (off) runs the canary probe on a cron expression, as
+//     an alternative to the fixed CANARY_INTERVAL
+//   • standard 5-field expressions or descriptors (@hourly, @every 30s);
+//     "off" disables a task, and a run still in progress skips the next
+//   • every run is its own root span (scheduled <task>) and its log lines
+//     carry that trace_id; scheduler.runs counts runs by task and result
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scheduledTask runs on the schedule in env, def when unset.
+type scheduledTask struct {
+	name, env, def string
+	run            func(ctx context.Context, l *slog.Logger) error
+}
+
+var scheduledTasks = []scheduledTask{
+	{"sweeper", "SCHEDULE_SWEEPER", "@every 1m", func(ctx context.Context, l *slog.Logger) error {
+		jobsMu.Lock()
+		n := pruneJobs(time.Now())
+		jobsMu.Unlock()
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int("sweeper.jobs_removed", n))
+		if n > 0 {
+			l.InfoContext(ctx, "swept expired jobs", "removed", n)
+		}
+		return nil
+	}},
+	{"snapshot", "SCHEDULE_SNAPSHOT", "*/5 * * * *", func(ctx context.Context, l *slog.Logger) error {
+		path := envString("SNAPSHOT_FILE", "")
+		if path == "" {
+			return nil
+		}
+		n, err := writeSnapshot(ctx, path)
+		if err == nil {
+			l.InfoContext(ctx, "store snapshot written", "file", path, "items", n)
+		}
+		return err
+	}},
+	{"canary", "SCHEDULE_CANARY", "off", func(ctx context.Context, _ *slog.Logger) error {
+		target, err := selfURL()
+		if err != nil {
+			return err
+		}
+		return runCanary(ctx, target, staticAuth(envString("CANARY_API_KEY", ""), envString("CANARY_BEARER_TOKEN", "")))
+	}},
+}
+
+var schedulerRuns, _ = meter.Int64Counter("scheduler.runs",
+	metric.WithDescription("Scheduled task runs by task and result"))
+
+// startScheduler registers the enabled tasks; the returned func stops the
+// schedule and waits for running tasks.
+func startScheduler(l *slog.Logger) (func(), error) {
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	for _, t := range scheduledTasks {
+		spec := envString(t.env, t.def)
+		if strings.EqualFold(spec, "off") {
+			continue
+		}
+		if _, err := c.AddFunc(spec, func() { runScheduled(l, t, spec) }); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.env, err)
+		}
+		l.Info("scheduled task", "task", t.name, "schedule", spec)
+	}
+	c.Start()
+	return func() { <-c.Stop().Done() }, nil
+}
+
+func runScheduled(l *slog.Logger, t scheduledTask, spec string) {
+	ctx, span := tracer.Start(context.Background(), "scheduled "+t.name,
+		trace.WithNewRoot(),
+		trace.WithAttributes(
+			attribute.String("scheduler.task", t.name),
+			attribute.String("scheduler.schedule", spec),
+		))
+	defer span.End()
+	sc := span.SpanContext()
+	l = l.With("task", t.name, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+
+	start := time.Now()
+	err := t.run(ctx, l)
+	result := "success"
+	if err != nil {
+		result = "failure"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		l.WarnContext(ctx, "scheduled task failed", "err", err)
+	}
+	schedulerRuns.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("task", t.name), attribute.String("result", result)))
+	l.DebugContext(ctx, "scheduled task finished", "duration", time.Since(start))
+}
@@ -0,0 +1,76 @@
+// sdnotify.go — systemd integration for Type=notify units:
+//   • READY=1 once the listener is bound and serving, STOPPING=1 when a
+//     shutdown signal arrives, with a STATUS= line for `systemctl status`
+//   • with WatchdogSec=, WATCHDOG=1 every half interval until serve returns,
+//     so a wedged process is restarted but a draining one is not
+//   • EXTEND_TIMEOUT_USEC covers SHUTDOWN_READY_GRACE + SHUTDOWN_TIMEOUT, so a
+//     short TimeoutStopSec= does not cut a drain off
+//   • without NOTIFY_SOCKET (not under systemd) all of this is a no-op
+
+package main
+
+import (
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdNotify sends state to the service manager, if there is one.
+func sdNotify(state string) error {
+	addr := envString("NOTIFY_SOCKET", "")
+	if addr == "" {
+		return nil
+	}
+	// an "@" prefix (abstract namespace) is handled by package net
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// notifySystemd is sdNotify for callers that only log failures.
+func notifySystemd(l *slog.Logger, state string) {
+	if err := sdNotify(state); err != nil {
+		l.Warn("sd_notify", "state", state, "err", err)
+	}
+}
+
+// extendTimeout asks systemd for d more before it gives up on the current
+// start-up or shutdown step.
+func extendTimeout(l *slog.Logger, d time.Duration) {
+	notifySystemd(l, "EXTEND_TIMEOUT_USEC="+strconv.FormatInt(d.Microseconds(), 10))
+}
+
+// startWatchdog heartbeats the systemd watchdog when the unit has one
+// (WATCHDOG_USEC, for this PID); the returned func stops it.
+func startWatchdog(l *slog.Logger) func() {
+	usec, err := strconv.ParseInt(envString("WATCHDOG_USEC", ""), 10, 64)
+	if err != nil || usec <= 0 {
+		return func() {}
+	}
+	if pid := envString("WATCHDOG_PID", ""); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return func() {}
+	}
+	interval := time.Duration(usec) * time.Microsecond / 2
+	l.Info("systemd watchdog", "interval", interval)
+
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				notifySystemd(l, "WATCHDOG=1")
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,127 @@
+// selftest.go — POST /admin/selftest checks the trace pipeline end to end:
+//   emits a selftest.marker root span (always sampled), flushes it and
+//   waits for the exporter's verdict, then — with TEMPO_URL set or
+//   ?tempo=<url> — polls Tempo's trace-by-ID API until the trace shows up.
+//   Reports the export and ingest latency, or where things went missing.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type selftestResult struct {
+	TraceID         string      `json:"trace_id"`
+	Exported        bool        `json:"exported"`
+	ExportLatencyMS float64     `json:"export_latency_ms,omitempty"`
+	ExportError     string      `json:"export_error,omitempty"`
+	Tempo           *tempoCheck `json:"tempo,omitempty"`
+}
+
+type tempoCheck struct {
+	URL       string  `json:"url"`
+	Found     bool    `json:"found"`
+	LatencyMS float64 `json:"latency_ms,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+func registerSelftest(r gin.IRouter) {
+	timeout := envDuration("SELFTEST_TIMEOUT", 15*time.Second)
+	defaultTempo := envString("TEMPO_URL", "")
+
+	r.POST("/admin/selftest", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		res := runSelftest(ctx, strings.TrimRight(c.DefaultQuery("tempo", defaultTempo), "/"))
+		status := http.StatusOK
+		if !res.Exported || (res.Tempo != nil && !res.Tempo.Found) {
+			status = http.StatusServiceUnavailable
+		}
+		traceSpan(c.Request.Context()).SetAttributes(
+			attribute.String("selftest.trace_id", res.TraceID),
+			attribute.Bool("selftest.exported", res.Exported),
+		)
+		c.JSON(status, res)
+	})
+}
+
+func runSelftest(ctx context.Context, tempoURL string) selftestResult {
+	// a fresh root so the marker is its own trace, independent of the caller
+	_, span := tracer.Start(context.WithoutCancel(ctx), "selftest.marker",
+		trace.WithNewRoot(),
+		trace.WithAttributes(selftestMarker, attribute.String("selftest.id", uuid.NewString())))
+	id := span.SpanContext().TraceID()
+	res := selftestResult{TraceID: id.String()}
+
+	result, stop := spanStats.watch(id)
+	defer stop()
+	span.End()
+	ended := time.Now()
+
+	if tp, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); ok {
+		go func() { _ = tp.ForceFlush(ctx) }()
+	}
+	select {
+	case err := <-result:
+		res.Exported = err == nil
+		res.ExportLatencyMS = float64(time.Since(ended).Microseconds()) / 1000
+		if err != nil {
+			res.ExportError = err.Error()
+		}
+	case <-ctx.Done():
+		res.ExportError = "no export attempt before timeout"
+	}
+
+	if tempoURL == "" || !res.Exported {
+		return res
+	}
+	res.Tempo = &tempoCheck{URL: tempoURL}
+	if err := pollTempo(ctx, tempoURL, res.TraceID); err != nil {
+		res.Tempo.Error = err.Error()
+	} else {
+		res.Tempo.Found = true
+		res.Tempo.LatencyMS = float64(time.Since(ended).Microseconds()) / 1000
+	}
+	return res
+}
+
+// pollTempo retries GET /api/traces/<id> until it answers 200. It uses a
+// plain client so the polling itself does not produce spans.
+func pollTempo(ctx context.Context, base, traceID string) error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	lastErr := errors.New("trace not found before timeout")
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/api/traces/"+traceID, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			switch {
+			case resp.StatusCode == http.StatusOK:
+				return nil
+			case resp.StatusCode != http.StatusNotFound:
+				lastErr = fmt.Errorf("tempo returned %d", resp.StatusCode)
+			}
+		} else if ctx.Err() == nil {
+			lastErr = err
+		}
+		if sleepCtx(ctx, 500*time.Millisecond) != nil {
+			return lastErr
+		}
+	}
+}
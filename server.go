@@ -0,0 +1,94 @@
+// server.go — HTTP server construction & lifecycle:
+//   • read-header/read/write/idle timeouts from HTTP_*_TIMEOUT
+//   • serve until SIGINT/SIGTERM; then /readyz fails for
+//     SHUTDOWN_READY_GRACE while requests are still served, before the
+//     listener stops accepting and in-flight requests drain within
+//     SHUTDOWN_TIMEOUT; the tracer provider is flushed afterwards by
+//     main's deferred shutdown, so spans of drained requests are exported
+//   • under systemd, READY=1 / STOPPING=1 bracket this (sdnotify.go)
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// newHTTPServer applies the configured timeouts; zero disables one.
+func newHTTPServer(h http.Handler, tlsCfg *tls.Config) *http.Server {
+	return &http.Server{
+		Handler:           h,
+		TLSConfig:         tlsCfg,
+		ReadHeaderTimeout: envDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       envDuration("HTTP_READ_TIMEOUT", 30*time.Second),
+		WriteTimeout:      envDuration("HTTP_WRITE_TIMEOUT", 60*time.Second),
+		IdleTimeout:       envDuration("HTTP_IDLE_TIMEOUT", 120*time.Second),
+	}
+}
+
+// runServer blocks until srv fails or a termination signal arrives, then
+// gracefully shuts down srv and any auxiliary servers. It returns the serve
+// error, if any; a signal-driven shutdown returns nil.
+func runServer(l *slog.Logger, srv *http.Server, ln net.Listener, aux ...*http.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			l.Info("Listening (TLS) …", "addr", ln.Addr().String(), "client_auth", srv.TLSConfig.ClientAuth.String())
+			errc <- srv.ServeTLS(ln, "", "")
+		} else {
+			l.Info("Listening …", "addr", ln.Addr().String())
+			errc <- srv.Serve(ln)
+		}
+	}()
+	notifySystemd(l, "READY=1\nSTATUS=serving on "+ln.Addr().String())
+
+	var serveErr error
+	select {
+	case err := <-errc:
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr = err
+		}
+	case <-ctx.Done():
+		stop() // a second signal kills the process immediately
+		ready.draining.Store(true)
+		grace := envDuration("SHUTDOWN_READY_GRACE", 0)
+		timeout := envDuration("SHUTDOWN_TIMEOUT", 15*time.Second)
+		notifySystemd(l, "STOPPING=1\nSTATUS=draining")
+		extendTimeout(l, grace+timeout)
+		if grace > 0 {
+			// still serving, but /readyz fails and connections are not
+			// kept alive, so load balancers move traffic elsewhere first
+			l.Info("shutting down, failing readiness before closing the listener", "grace", grace)
+			srv.SetKeepAlivesEnabled(false)
+			time.Sleep(grace)
+		}
+		l.Info("shutting down, draining requests", "timeout", timeout)
+
+		sctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := srv.Shutdown(sctx); err != nil {
+			l.Error("drain incomplete", "err", err)
+		}
+	}
+
+	for _, s := range aux {
+		if s == nil {
+			continue
+		}
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = s.Shutdown(sctx)
+		cancel()
+	}
+	return serveErr
+}
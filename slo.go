@@ -0,0 +1,209 @@
+// slo.go — rolling SLO tracking per route:
+//   • sloRecorder counts requests, 5xx and a latency histogram per
+//     "METHOD route" in 60 rolling buckets spanning SLO_WINDOW (1h)
+//   • GET /admin/slo reports availability, p50/p90/p99 latency and the
+//     remaining error budget against SLO_OBJECTIVE (0.999, or ?objective=)
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sloBuckets = 60
+
+// sloBoundsMS are the latency histogram upper bounds; the last slot
+// collects everything slower.
+var sloBoundsMS = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+type sloBucket struct {
+	epoch   int64 // start of the bucket, in bucket widths since the Unix epoch
+	total   int64
+	errors  int64
+	latency [12]int64 // len(sloBoundsMS)+1
+}
+
+func (b *sloBucket) add(o sloBucket) {
+	b.total += o.total
+	b.errors += o.errors
+	for i, n := range o.latency {
+		b.latency[i] += n
+	}
+}
+
+type sloSeries [sloBuckets]sloBucket
+
+type sloTracker struct {
+	mu     sync.Mutex
+	width  time.Duration
+	routes map[string]*sloSeries
+}
+
+var slo = newSLOTracker() // rebuilt by Config.install
+
+func newSLOTracker() *sloTracker {
+	return &sloTracker{
+		width:  max(time.Second, envDuration("SLO_WINDOW", time.Hour)/sloBuckets),
+		routes: make(map[string]*sloSeries),
+	}
+}
+
+func (t *sloTracker) record(route string, status int, d time.Duration, now time.Time) {
+	epoch := now.UnixNano() / int64(t.width)
+	ms := float64(d.Microseconds()) / 1000
+	slot := len(sloBoundsMS)
+	for i, b := range sloBoundsMS {
+		if ms <= b {
+			slot = i
+			break
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.routes[route]
+	if s == nil {
+		s = new(sloSeries)
+		t.routes[route] = s
+	}
+	b := &s[epoch%sloBuckets]
+	if b.epoch != epoch {
+		*b = sloBucket{epoch: epoch}
+	}
+	b.total++
+	if status >= 500 {
+		b.errors++
+	}
+	b.latency[slot]++
+}
+
+// sum folds the buckets still inside the window into one.
+func (t *sloTracker) sum(s *sloSeries, now time.Time) sloBucket {
+	oldest := now.UnixNano()/int64(t.width) - sloBuckets + 1
+	var out sloBucket
+	for _, b := range s {
+		if b.epoch >= oldest {
+			out.add(b)
+		}
+	}
+	return out
+}
+
+// ctxStreaming marks long-lived requests (streams, long polls) whose
+// duration says nothing about latency; handlers set it.
+const ctxStreaming = "http.streaming"
+
+func sloRecorder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		if c.FullPath() == "" || isOpsPath(c.Request.URL.Path) || c.GetBool(ctxStreaming) {
+			return
+		}
+		slo.record(c.Request.Method+" "+routePath(c.FullPath()), c.Writer.Status(), time.Since(start), time.Now())
+	}
+}
+
+/* -------------------------------------------------------------------------- */
+/* Report                                                                     */
+/* -------------------------------------------------------------------------- */
+
+type sloReport struct {
+	Requests     int64              `json:"requests"`
+	Errors       int64              `json:"errors"`
+	Availability float64            `json:"availability"`
+	LatencyMS    map[string]float64 `json:"latency_ms"`
+	ErrorBudget  struct {
+		AllowedErrors float64 `json:"allowed_errors"`
+		Remaining     float64 `json:"remaining_ratio"` // below 0 once exhausted
+	} `json:"error_budget"`
+}
+
+func newSLOReport(b sloBucket, objective float64) sloReport {
+	r := sloReport{Requests: b.total, Errors: b.errors, Availability: 1}
+	if b.total > 0 {
+		r.Availability = 1 - float64(b.errors)/float64(b.total)
+	}
+	r.LatencyMS = map[string]float64{
+		"p50": percentile(b, 0.50),
+		"p90": percentile(b, 0.90),
+		"p99": percentile(b, 0.99),
+	}
+	r.ErrorBudget.AllowedErrors = (1 - objective) * float64(b.total)
+	r.ErrorBudget.Remaining = 1
+	if r.ErrorBudget.AllowedErrors > 0 {
+		r.ErrorBudget.Remaining = 1 - float64(b.errors)/r.ErrorBudget.AllowedErrors
+	} else if b.errors > 0 {
+		r.ErrorBudget.Remaining = 0
+	}
+	return r
+}
+
+// percentile interpolates linearly inside the histogram slot holding q.
+func percentile(b sloBucket, q float64) float64 {
+	if b.total == 0 {
+		return 0
+	}
+	rank := q * float64(b.total)
+	var cum float64
+	for i, n := range b.latency {
+		if n == 0 {
+			continue
+		}
+		if cum+float64(n) >= rank {
+			if i == len(sloBoundsMS) {
+				return sloBoundsMS[i-1] // open-ended: report the last bound
+			}
+			lo := 0.0
+			if i > 0 {
+				lo = sloBoundsMS[i-1]
+			}
+			return lo + (sloBoundsMS[i]-lo)*(rank-cum)/float64(n)
+		}
+		cum += float64(n)
+	}
+	return sloBoundsMS[len(sloBoundsMS)-1]
+}
+
+func registerSLOAdmin(r gin.IRouter) {
+	defaultObjective := envFloat("SLO_OBJECTIVE", 0.999)
+
+	r.GET("/admin/slo", func(c *gin.Context) {
+		objective := defaultObjective
+		if q := c.Query("objective"); q != "" {
+			v, err := strconv.ParseFloat(q, 64)
+			if err != nil || v <= 0 || v >= 1 {
+				respondError(c, fmt.Errorf("objective must be in (0,1)"), http.StatusBadRequest)
+				return
+			}
+			objective = v
+		}
+
+		now := time.Now()
+		var overall sloBucket
+		routes := make(map[string]sloReport)
+		slo.mu.Lock()
+		for name, s := range slo.routes {
+			b := slo.sum(s, now)
+			if b.total == 0 {
+				continue
+			}
+			routes[name] = newSLOReport(b, objective)
+			overall.add(b)
+		}
+		slo.mu.Unlock()
+
+		c.JSON(http.StatusOK, gin.H{
+			"objective": objective,
+			"window":    (slo.width * sloBuckets).String(),
+			"overall":   newSLOReport(overall, objective),
+			"routes":    routes,
+		})
+	})
+}
@@ -0,0 +1,60 @@
+// slow.go — GET /slow?ms=500&stages=3: spreads the delay over sequential
+//   child spans (db → cache → render → …) so waterfall views have depth.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var slowStageNames = []string{"db", "cache", "render"}
+
+const (
+	maxSlowMillis = 30_000
+	maxSlowStages = 20
+)
+
+func slowHandler(c *gin.Context) {
+	ms, err := strconv.Atoi(c.DefaultQuery("ms", "500"))
+	if err != nil || ms < 0 || ms > maxSlowMillis {
+		respondError(c, fmt.Errorf("ms must be an integer in [0,%d]", maxSlowMillis), http.StatusBadRequest)
+		return
+	}
+	stages, err := strconv.Atoi(c.DefaultQuery("stages", "3"))
+	if err != nil || stages < 1 || stages > maxSlowStages {
+		respondError(c, fmt.Errorf("stages must be an integer in [1,%d]", maxSlowStages), http.StatusBadRequest)
+		return
+	}
+
+	ctx := c.Request.Context()
+	per := time.Duration(ms) * time.Millisecond / time.Duration(stages)
+	for i := 0; i < stages; i++ {
+		name := fmt.Sprintf("stage.%d", i+1)
+		if i < len(slowStageNames) {
+			name = slowStageNames[i]
+		}
+
+		_, span := tracer.Start(ctx, name, trace.WithAttributes(
+			attribute.Int("slow.stage", i+1),
+			attribute.Int64("slow.stage_ms", per.Milliseconds()),
+		))
+		select {
+		case <-time.After(per):
+			span.End()
+		case <-ctx.Done():
+			span.RecordError(ctx.Err())
+			span.End()
+			respondError(c, ctx.Err(), contextErrorStatus(ctx.Err()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ms": ms, "stages": stages})
+}
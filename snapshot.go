@@ -0,0 +1,140 @@
+// snapshot.go — point-in-time copies of the in-memory store:
+//   • SNAPSHOT_FILE names a JSON file holding every item plus the ID
+//     sequence; the scheduler rewrites it periodically (see schedule.go)
+//     and serve once more on a graceful shutdown, after the writers have
+//     stopped; a crash still loses what changed since the last write
+//   • the file is written next to its final path and renamed into place,
+//     so a crash mid-write never leaves a torn snapshot
+//   • at startup an existing file is loaded back, and the ID sequence
+//     continues from the higher of last_id and the highest item ID, so
+//     a clean restart never hands out an ID twice; an unreadable file stops the
+//     server rather than letting the next snapshot overwrite it
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type storeSnapshot struct {
+	TakenAt time.Time `json:"taken_at"`
+	LastID  int64     `json:"last_id"` // highest ID handed out so far
+	Items   []Item    `json:"items"`
+}
+
+func (s *memoryStore) snapshot() storeSnapshot {
+	v, _ := s.snapshotView()
+	items := slices.Clone(v.items)
+	slices.SortFunc(items, func(a, b Item) int { return a.ID - b.ID })
+	return storeSnapshot{TakenAt: time.Now().UTC(), LastID: v.lastID, Items: items}
+}
+
+// writeSnapshot saves memStore to path and returns the number of items.
+func writeSnapshot(ctx context.Context, path string) (n int, err error) {
+	_, span := tracer.Start(ctx, "snapshot.write", trace.WithAttributes(attribute.String("file.path", path)))
+	defer func() {
+		span.SetAttributes(attribute.Int("snapshot.items", n))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	snap := memStore.snapshot()
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return len(snap.Items), nil
+}
+
+// writeFinalSnapshot saves the store at shutdown, when SNAPSHOT_FILE is set.
+func writeFinalSnapshot(l *slog.Logger) {
+	path := envString("SNAPSHOT_FILE", "")
+	if path == "" {
+		return
+	}
+	n, err := writeSnapshot(context.Background(), path)
+	if err != nil {
+		l.Error("writing final store snapshot", "file", path, "err", err)
+		return
+	}
+	l.Info("store snapshot written", "file", path, "items", n)
+}
+
+// restore loads snap into the store and raises the ID sequence past
+// everything in it; it returns the sequence's new value.
+func (s *memoryStore) restore(snap storeSnapshot) (last int64) {
+	s.write(func() {
+		last = snap.LastID
+		for _, item := range snap.Items {
+			s.store(item)
+			last = max(last, int64(item.ID))
+		}
+		for {
+			cur := s.idSeq.Load()
+			if cur >= last || s.idSeq.CompareAndSwap(cur, last) {
+				last = max(cur, last)
+				return
+			}
+		}
+	})
+	return last
+}
+
+// restoreSnapshot loads path into memStore; a missing file is a fresh
+// start (found is false).
+func restoreSnapshot(ctx context.Context, path string) (found bool, n int, err error) {
+	_, span := tracer.Start(ctx, "snapshot.restore", trace.WithAttributes(attribute.String("file.path", path)))
+	defer func() {
+		span.SetAttributes(attribute.Bool("snapshot.found", found), attribute.Int("snapshot.items", n))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	var snap storeSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return true, 0, err
+	}
+	last := memStore.restore(snap)
+	span.SetAttributes(attribute.Int64("snapshot.last_id", last))
+	return true, len(snap.Items), nil
+}
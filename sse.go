@@ -0,0 +1,138 @@
+// sse.go — GET /items/events streams item changes as Server-Sent Events:
+//   • "id: <seq>", "event: <CloudEvent type>", "data: <CloudEvent JSON>";
+//     reconnecting clients resume via Last-Event-ID (or ?since=)
+//   • SSE_SPAN_MODE=event (default) gives every sent event its own span in
+//     the trace of the change that caused it, linked to the stream's
+//     request span; stream keeps one long-lived request span and records
+//     each event on it (span event + link)
+//   • the write timeout is lifted for the stream, a comment heartbeat goes
+//     out every SSE_HEARTBEAT (15s), and streams end when shutdown begins
+//   • sse.connections tracks open streams
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	sseConnections, _ = meter.Int64UpDownCounter("sse.connections",
+		metric.WithDescription("Open Server-Sent Events streams"))
+	sseEventsSent, _ = meter.Int64Counter("sse.events.sent",
+		metric.WithDescription("Item change events written to SSE streams"))
+)
+
+func itemEventsHandler(c *gin.Context) {
+	since, err := strconv.ParseInt(c.DefaultQuery("since", c.GetHeader("Last-Event-ID")), 10, 64)
+	if err != nil {
+		since = 0
+	}
+	perEvent := envString("SSE_SPAN_MODE", "event") != "stream"
+
+	ctx := c.Request.Context()
+	stream := traceSpan(ctx)
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.SetWriteDeadline(time.Time{}) // the stream outlives HTTP_WRITE_TIMEOUT
+
+	sub, backlog, complete, cancel := changes.subscribe(since)
+	defer cancel()
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if !complete {
+		// some changes after since were evicted; tell the client to refetch
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+
+	c.Set(ctxStreaming, true)
+	sseConnections.Add(ctx, 1)
+	defer sseConnections.Add(context.WithoutCancel(ctx), -1)
+	stream.SetAttributes(attribute.Int64("sse.since", since), attribute.Bool("sse.resumed", since > 0))
+
+	send := func(e changeEntry) error {
+		sseEventsSent.Add(ctx, 1)
+		if perEvent {
+			return sendSSEEvent(c, stream.SpanContext(), e)
+		}
+		stream.AddLink(trace.Link{SpanContext: e.producer})
+		stream.AddEvent("sse.event", trace.WithAttributes(
+			attribute.Int64("sse.seq", e.Seq), attribute.String("cloudevents.event_type", e.Event.Type)))
+		return writeSSE(c, e)
+	}
+	for _, e := range backlog {
+		if send(e) != nil {
+			return
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(envDuration("SSE_HEARTBEAT", 15*time.Second))
+	defer heartbeat.Stop()
+	sent := int64(len(backlog))
+	defer func() { stream.SetAttributes(attribute.Int64("sse.events_sent", sent)) }()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.ch:
+			if !ok {
+				stream.AddEvent("sse.closed_by_server")
+				return
+			}
+			if send(e) != nil || rc.Flush() != nil {
+				return
+			}
+			sent++
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendSSEEvent writes e under its own span, a child of the span that made
+// the change and linked to the stream.
+func sendSSEEvent(c *gin.Context, streamSpan trace.SpanContext, e changeEntry) error {
+	parent := trace.ContextWithRemoteSpanContext(context.Background(), e.producer)
+	_, span := tracer.Start(parent, "sse.send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithLinks(trace.Link{SpanContext: streamSpan}),
+		trace.WithAttributes(
+			attribute.Int64("sse.seq", e.Seq),
+			attribute.String("cloudevents.event_type", e.Event.Type),
+			attribute.String("cloudevents.event_id", e.Event.ID),
+		))
+	defer span.End()
+	err := writeSSE(c, e)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func writeSSE(c *gin.Context, e changeEntry) error {
+	data, err := json.Marshal(e.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Event.Type, data)
+	return err
+}
@@ -0,0 +1,111 @@
+// static.go — /static/* serves the embedded ui/static files:
+//   • every file is hashed once at startup; the hash is its ETag, so a
+//     matching If-None-Match gets 304 without a body
+//   • pages link assets as static/<file>?v=<hash> (the asset template func):
+//     a URL with the current hash is immutable and cached for a year, any
+//     other is revalidated every time (no-cache)
+//   • each request runs in a static.serve span with file.path, file.size
+//     and cache.status (hit: the client's copy was current, miss: sent)
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type staticFile struct {
+	data        []byte
+	hash        string // first 16 hex digits of the SHA-256
+	contentType string
+}
+
+// staticFiles is keyed by the path below ui/static.
+var staticFiles = func() map[string]staticFile {
+	files := map[string]staticFile{}
+	err := fs.WalkDir(uiFiles, "ui/static", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := uiFiles.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		ctype := mime.TypeByExtension(path.Ext(p))
+		if ctype == "" {
+			ctype = http.DetectContentType(data)
+		}
+		files[strings.TrimPrefix(p, "ui/static/")] = staticFile{data, hex.EncodeToString(sum[:8]), ctype}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return files
+}()
+
+// assetURL is the cache-busting link to an embedded static file, relative
+// to a page at the base path.
+func assetURL(name string) string {
+	if f, ok := staticFiles[name]; ok {
+		return "static/" + name + "?v=" + f.hash
+	}
+	return "static/" + name
+}
+
+func registerStatic(r gin.IRouter) {
+	g := r.Group("/static")
+	g.GET("/*file", serveStatic)
+	g.HEAD("/*file", serveStatic)
+}
+
+func serveStatic(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("file"), "/")
+	_, span := tracer.Start(c.Request.Context(), "static.serve",
+		trace.WithAttributes(attribute.String("file.path", name)))
+	defer span.End()
+
+	f, ok := staticFiles[name]
+	if !ok {
+		respondError(c, errNotFound, http.StatusNotFound)
+		return
+	}
+	span.SetAttributes(attribute.Int("file.size", len(f.data)))
+
+	etag := `"` + f.hash + `"`
+	c.Header("ETag", etag)
+	if c.Query("v") == f.hash {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		c.Header("Cache-Control", "no-cache")
+	}
+	if etagMatch(c.GetHeader("If-None-Match"), etag) {
+		span.SetAttributes(attribute.String("cache.status", "hit"))
+		c.Status(http.StatusNotModified)
+		return
+	}
+	span.SetAttributes(attribute.String("cache.status", "miss"))
+	c.Data(http.StatusOK, f.contentType, f.data)
+}
+
+// etagMatch reports whether an If-None-Match header lists etag (weak
+// comparison, as RFC 9110 asks for If-None-Match).
+func etagMatch(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == etag || t == "*" {
+			return true
+		}
+	}
+	return false
+}
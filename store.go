@@ -0,0 +1,208 @@
+// store.go — item repository:
+//   • itemStore is what the handlers talk to
+//   • memoryStore keeps items in a sync.Map with an atomic ID sequence,
+//     and an atomic count every insert and delete adjusts, so Count (which
+//     the MAX_ITEMS quota asks on each create) doesn't walk the map
+//   • lists read a coherent view: writes run side by side, but a view
+//     waits for those under way and holds the next ones off while it copies
+//     the map, then serves every list until the next write
+//   • Update is a read-modify-write: the change is applied with a
+//     compare-and-swap and recomputed if another write got there first,
+//     each retry a store.update.conflict event on the caller's span
+//   • handlers use repo, which main wraps in the traced fake-DB layer
+
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errNotFound = errors.New("not found")
+
+type itemStore interface {
+	Get(ctx context.Context, id int) (Item, error) // errNotFound if absent
+	List(ctx context.Context) ([]Item, error)
+	Each(ctx context.Context, fn func(Item) error) error // like List, item by item; stops at fn's first error
+	Create(ctx context.Context, name string) (Item, error)
+	// Update replaces item id with what fn makes of its current value and
+	// stamps UpdatedAt; errNotFound if absent. fn runs again whenever the
+	// item changed under it, so it should only compute the new value; an
+	// error from it ends the update and is returned as is.
+	Update(ctx context.Context, id int, fn func(Item) (Item, error)) (Item, error)
+	Delete(ctx context.Context, id int) error // errNotFound if absent
+	Count(ctx context.Context) (int, error)
+}
+
+var (
+	// memStore holds the data; repo is what handlers use (memStore wrapped
+	// by the traced fake-DB layer once main has run).
+	memStore           = newMemoryStore()
+	repo     itemStore = memStore
+)
+
+/* -------------------------------------------------------------------------- */
+/* In-memory implementation                                                   */
+/* -------------------------------------------------------------------------- */
+
+type memoryStore struct {
+	items sync.Map // int → Item
+	idSeq atomic.Int64
+	count atomic.Int64 // entries in items
+
+	// writers hold mu shared, so only a view excludes them; version counts
+	// the writes, view is the last copy taken
+	mu      sync.RWMutex
+	version atomic.Int64
+	view    atomic.Pointer[storeView]
+}
+
+// storeView is the store as of one version; never modified once taken.
+type storeView struct {
+	version int64
+	lastID  int64 // highest ID handed out
+	items   []Item
+}
+
+func newMemoryStore() *memoryStore { return &memoryStore{} }
+
+// write runs one change so that a view sees all of it or none.
+func (s *memoryStore) write(fn func()) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn()
+	s.version.Add(1)
+}
+
+// snapshotView returns the current view, copying the map only when a write
+// happened since the last one; reused says it didn't have to.
+func (s *memoryStore) snapshotView() (v *storeView, reused bool) {
+	if v := s.view.Load(); v != nil && v.version == s.version.Load() {
+		return v, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	version := s.version.Load()
+	if v := s.view.Load(); v != nil && v.version == version {
+		return v, true // taken while we waited
+	}
+	v = &storeView{version: version, lastID: s.idSeq.Load(), items: make([]Item, 0)}
+	s.items.Range(func(_, item any) bool {
+		v.items = append(v.items, item.(Item))
+		return true
+	})
+	s.view.Store(v)
+	return v, false
+}
+
+// viewFor is snapshotView noting the view on the caller's span.
+func (s *memoryStore) viewFor(ctx context.Context) *storeView {
+	v, reused := s.snapshotView()
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int64("store.view.version", v.version),
+		attribute.Bool("store.view.reused", reused),
+	)
+	return v
+}
+
+func (s *memoryStore) Get(_ context.Context, id int) (Item, error) {
+	v, ok := s.items.Load(id)
+	if !ok {
+		return Item{}, errNotFound
+	}
+	return v.(Item), nil
+}
+
+// List copies the view: callers sort what they get.
+func (s *memoryStore) List(ctx context.Context) ([]Item, error) {
+	return slices.Clone(s.viewFor(ctx).items), nil
+}
+
+func (s *memoryStore) Each(ctx context.Context, fn func(Item) error) error {
+	for _, item := range s.viewFor(ctx).items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stamp is an UpdatedAt for now.
+func stamp() *time.Time {
+	t := time.Now().UTC()
+	return &t
+}
+
+func (s *memoryStore) Create(_ context.Context, name string) (item Item, _ error) {
+	s.write(func() {
+		// never overwrite: an ID can be taken by items loaded from elsewhere
+		for {
+			item = Item{ID: int(s.idSeq.Add(1)), Name: name, UpdatedAt: stamp()}
+			if _, taken := s.items.LoadOrStore(item.ID, item); !taken {
+				s.count.Add(1)
+				return
+			}
+		}
+	})
+	return item, nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, id int, fn func(Item) (Item, error)) (Item, error) {
+	for attempt := 1; ; attempt++ {
+		v, ok := s.items.Load(id)
+		if !ok {
+			return Item{}, errNotFound
+		}
+		item, err := fn(v.(Item))
+		if err != nil {
+			return Item{}, err
+		}
+		item.ID, item.UpdatedAt = id, stamp()
+		swapped := false
+		s.write(func() { swapped = s.items.CompareAndSwap(id, v, item) })
+		if swapped {
+			return item, nil
+		}
+		trace.SpanFromContext(ctx).AddEvent("store.update.conflict",
+			trace.WithAttributes(attribute.Int("store.update.attempt", attempt)))
+		if err := ctx.Err(); err != nil {
+			return Item{}, err
+		}
+	}
+}
+
+// Put stores item as is; it isn't part of itemStore, only rollbacks
+// (outbox.go) write blindly.
+func (s *memoryStore) Put(_ context.Context, item Item) error {
+	s.write(func() { s.store(item) })
+	return nil
+}
+
+// store is a blind write keeping count; callers are inside write.
+func (s *memoryStore) store(item Item) {
+	if _, replaced := s.items.Swap(item.ID, item); !replaced {
+		s.count.Add(1)
+	}
+}
+
+func (s *memoryStore) Delete(_ context.Context, id int) (err error) {
+	s.write(func() {
+		if _, loaded := s.items.LoadAndDelete(id); !loaded {
+			err = errNotFound
+			return
+		}
+		s.count.Add(-1)
+	})
+	return err
+}
+
+func (s *memoryStore) Count(_ context.Context) (int, error) {
+	return int(s.count.Load()), nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMemoryStoreUpdate(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name      string
+		id        int
+		cancelled bool
+		// fn is the update; s is the store, so a case can write under it
+		fn         func(s *memoryStore, call int, cur Item) (Item, error)
+		wantName   string
+		wantErr    error
+		wantCalls  int
+		storedName string
+	}{
+		{
+			name: "applies fn and keeps the ID",
+			id:   1,
+			fn: func(_ *memoryStore, _ int, cur Item) (Item, error) {
+				cur.ID, cur.Name = 7, "renamed"
+				return cur, nil
+			},
+			wantName: "renamed", wantCalls: 1, storedName: "renamed",
+		},
+		{
+			name:      "missing item",
+			id:        99,
+			fn:        func(_ *memoryStore, _ int, cur Item) (Item, error) { return cur, nil },
+			wantErr:   errNotFound,
+			wantCalls: 0, storedName: "original",
+		},
+		{
+			name:      "fn error is returned as is",
+			id:        1,
+			fn:        func(*memoryStore, int, Item) (Item, error) { return Item{}, errBoom },
+			wantErr:   errBoom,
+			wantCalls: 1, storedName: "original",
+		},
+		{
+			name: "recomputes after a write got there first",
+			id:   1,
+			fn: func(s *memoryStore, call int, cur Item) (Item, error) {
+				if call == 1 {
+					_ = s.Put(context.Background(), Item{ID: 1, Name: "concurrent"})
+				}
+				cur.Name += "+1"
+				return cur, nil
+			},
+			wantName: "concurrent+1", wantCalls: 2, storedName: "concurrent+1",
+		},
+		{
+			name:      "stops retrying once the context ends",
+			id:        1,
+			cancelled: true,
+			fn: func(s *memoryStore, call int, cur Item) (Item, error) {
+				_ = s.Put(context.Background(), Item{ID: 1, Name: "concurrent"})
+				cur.Name += "+1"
+				return cur, nil
+			},
+			wantErr:   context.Canceled,
+			wantCalls: 1, storedName: "concurrent",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newMemoryStore()
+			created, _ := s.Create(context.Background(), "original")
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			calls := 0
+			got, err := s.Update(ctx, tt.id, func(cur Item) (Item, error) {
+				calls++
+				return tt.fn(s, calls, cur)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("fn ran %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr == nil {
+				if got.ID != tt.id || got.Name != tt.wantName {
+					t.Errorf("Update = %+v, want ID %d, name %q", got, tt.id, tt.wantName)
+				}
+				if got.UpdatedAt == nil || got.UpdatedAt == created.UpdatedAt {
+					t.Errorf("UpdatedAt not stamped: %v", got.UpdatedAt)
+				}
+			}
+			stored, _ := s.Get(context.Background(), 1)
+			if stored.Name != tt.storedName {
+				t.Errorf("stored name = %q, want %q", stored.Name, tt.storedName)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreCount(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryStore()
+	steps := []struct {
+		name string
+		do   func() error
+		want int
+	}{
+		{"create", func() error { _, err := s.Create(ctx, "a"); return err }, 1},
+		{"put a new ID", func() error { return s.Put(ctx, Item{ID: 2, Name: "b"}) }, 2},
+		{"put over an existing ID", func() error { return s.Put(ctx, Item{ID: 2, Name: "c"}) }, 2},
+		{"create skips a taken ID", func() error {
+			if item, _ := s.Create(ctx, "d"); item.ID != 3 {
+				return fmt.Errorf("got ID %d, want 3", item.ID)
+			}
+			return nil
+		}, 3},
+		{"delete", func() error { return s.Delete(ctx, 2) }, 2},
+		{"delete a missing ID", func() error {
+			if err := s.Delete(ctx, 2); !errors.Is(err, errNotFound) {
+				return fmt.Errorf("err = %v, want errNotFound", err)
+			}
+			return nil
+		}, 2},
+	}
+	for _, st := range steps {
+		if err := st.do(); err != nil {
+			t.Fatalf("%s: %v", st.name, err)
+		}
+		if n, _ := s.Count(ctx); n != st.want {
+			t.Fatalf("after %s: Count = %d, want %d", st.name, n, st.want)
+		}
+		if items, _ := s.List(ctx); len(items) != st.want {
+			t.Fatalf("after %s: List has %d items, want %d", st.name, len(items), st.want)
+		}
+	}
+}
+
+// useRepo points the handlers at s until the test ends.
+func useRepo(t *testing.T, s itemStore) {
+	t.Helper()
+	old := repo
+	repo = s
+	t.Cleanup(func() { repo = old })
+}
@@ -0,0 +1,157 @@
+// tenant.go — who a request acts for (MULTI_TENANT=true):
+//   • tenantContext resolves the tenant once per request, from the
+//     TENANT_CLAIM (tenant_id) claim of a valid JWT or else TENANT_HEADER
+//     (X-Tenant-ID), and puts it in the request context; the store, the
+//     request log line, metrics and spans all read it from there
+//   • a tenant has two forms: its ID as sent, for code that acts on it, and
+//     a bounded label, the tenant.id attribute of spans, logs and metrics
+//   • callers pick the ID, so the label is bounded before it gets anywhere:
+//     TENANT_ALLOWLIST names the tenants kept as they are; without it the
+//     first TENANT_MAX (20) distinct tenants are kept. Everything else is
+//     reported as "other"; a malformed ID is rejected with 400
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	tenantKey   = attribute.Key("tenant.id")
+	tenantOther = "other"
+)
+
+// tenant is resolved by tenantContext; the zero value is "no tenant".
+type tenant struct {
+	ID     string // as sent
+	Label  string // bounded, for telemetry
+	Source string // jwt | header
+}
+
+var errInvalidTenant = errors.New("tenant ID must be 1-64 letters, digits, '-', '_' or '.'")
+
+type tenantCtxKey struct{}
+
+func withTenant(ctx context.Context, t tenant) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, t)
+}
+
+// tenantFromContext returns the request's tenant; ok is false without one.
+func tenantFromContext(ctx context.Context) (t tenant, ok bool) {
+	t, ok = ctx.Value(tenantCtxKey{}).(tenant)
+	return t, ok
+}
+
+// tenantAttrs is the tenant as telemetry attributes, none without one.
+func tenantAttrs(ctx context.Context) []attribute.KeyValue {
+	if t, ok := tenantFromContext(ctx); ok {
+		return []attribute.KeyValue{tenantKey.String(t.Label)}
+	}
+	return nil
+}
+
+func multiTenant() bool { return envBool("MULTI_TENANT", false) }
+
+// tenantLabels maps raw tenant IDs to at most max+1 distinct labels.
+type tenantLabels struct {
+	allow map[string]bool // nil: first come, first kept
+
+	mu   sync.Mutex
+	seen map[string]bool
+	max  int
+	full func() // called once, when the cap is first hit
+}
+
+func newTenantLabels(l *slog.Logger) *tenantLabels {
+	t := &tenantLabels{seen: map[string]bool{}, max: envInt("TENANT_MAX", 20)}
+	if list := envList("TENANT_ALLOWLIST"); len(list) > 0 {
+		t.allow = make(map[string]bool, len(list))
+		for _, id := range list {
+			t.allow[id] = true
+		}
+	}
+	t.full = sync.OnceFunc(func() {
+		l.Warn("tenant label cap reached; further tenants are reported as other", "max", t.max)
+	})
+	return t
+}
+
+func (t *tenantLabels) label(raw string) string {
+	if raw == tenantOther {
+		return tenantOther
+	}
+	if t.allow != nil {
+		if t.allow[raw] {
+			return raw
+		}
+		return tenantOther
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.seen[raw] {
+		return raw
+	}
+	if len(t.seen) >= t.max {
+		t.full()
+		return tenantOther
+	}
+	t.seen[raw] = true
+	return raw
+}
+
+// validTenantID accepts up to 64 letters, digits, '-', '_' and '.'.
+func validTenantID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch b := id[i]; {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9', b == '-', b == '_', b == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ctxTenantClaim is the gin context key holding the JWT's tenant claim.
+const ctxTenantClaim = "tenant.claim"
+
+// tenantContext resolves the request's tenant; it runs after
+// authentication so a token's claim wins over the spoofable header.
+// Requests with neither carry no tenant.
+func tenantContext(labels *tenantLabels) gin.HandlerFunc {
+	header := envString("TENANT_HEADER", "X-Tenant-ID")
+	return func(c *gin.Context) {
+		t := tenant{ID: c.GetString(ctxTenantClaim), Source: "jwt"}
+		if t.ID == "" {
+			t = tenant{ID: c.GetHeader(header), Source: "header"}
+		}
+		if t.ID == "" {
+			c.Next()
+			return
+		}
+		if !validTenantID(t.ID) {
+			respondError(c, errInvalidTenant, http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		t.Label = labels.label(t.ID)
+		c.Request = c.Request.WithContext(withTenant(c.Request.Context(), t))
+		traceSpan(c.Request.Context()).SetAttributes(tenantKey.String(t.Label),
+			attribute.String("tenant.source", t.Source))
+		c.Next()
+	}
+}
+
+// tenantMetricAttributes feeds the tenant to otelgin's http.server.* metrics.
+func tenantMetricAttributes(c *gin.Context) []attribute.KeyValue {
+	return tenantAttrs(c.Request.Context())
+}
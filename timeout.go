@@ -0,0 +1,77 @@
+// timeout.go — per-route deadlines and the /timeout demo endpoint:
+//   • routeTimeout bounds a route's request context
+//   • a handler that outlives its deadline answers 504, with the
+//     cancellation visible as span events and error status
+//   • GET /timeout?ms= works past its TIMEOUT_ROUTE_BUDGET on purpose
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func routeTimeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		traceSpan(ctx).SetAttributes(attribute.Int64("http.route.timeout_ms", d.Milliseconds()))
+		c.Next()
+	}
+}
+
+// contextErrorStatus maps a context error onto the response status:
+// an expired deadline is the server's fault (504), anything else means
+// the caller went away.
+func contextErrorStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusServiceUnavailable
+}
+
+func timeoutHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// by default work twice as long as the route allows
+	work := 2 * envDuration("TIMEOUT_ROUTE_BUDGET", 2*time.Second)
+	if ms, err := strconv.Atoi(c.Query("ms")); err == nil && ms >= 0 && ms <= maxSlowMillis {
+		work = time.Duration(ms) * time.Millisecond
+	}
+
+	_, span := tracer.Start(ctx, "timeout.work",
+		trace.WithAttributes(attribute.Int64("work.planned_ms", work.Milliseconds())))
+	defer span.End()
+
+	select {
+	case <-time.After(work):
+		c.JSON(http.StatusOK, gin.H{"worked_ms": work.Milliseconds()})
+	case <-ctx.Done():
+		err := ctx.Err()
+		span.AddEvent("context.done", trace.WithAttributes(attribute.String("context.error", err.Error())))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		respondError(c, err, contextErrorStatus(err))
+	}
+}
+
+// sleepCtx waits for d or until ctx is done, whichever comes first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,93 @@
+// tls.go — HTTPS serving:
+//   • TLS_CERT_FILE + TLS_KEY_FILE enable TLS with a static certificate
+//   • TLS_AUTOCERT_DOMAINS obtains certificates from Let's Encrypt instead
+//     (tls-alpn-01 on the main port, http-01 on TLS_AUTOCERT_HTTP_ADDR)
+//   • TLS_CLIENT_CA_FILE turns on client-certificate verification (mTLS);
+//     TLS_CLIENT_AUTH=optional accepts callers without a certificate
+//   • the verified client subject is recorded on the server span
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// newTLSConfig returns a nil config when TLS is not configured; the
+// autocert manager is non-nil only in Let's Encrypt mode.
+func newTLSConfig() (*tls.Config, *autocert.Manager, error) {
+	certFile, keyFile := envString("TLS_CERT_FILE", ""), envString("TLS_KEY_FILE", "")
+	domains := envList("TLS_AUTOCERT_DOMAINS")
+
+	var (
+		cfg *tls.Config
+		m   *autocert.Manager
+	)
+	switch {
+	case len(domains) > 0:
+		if certFile != "" || keyFile != "" {
+			return nil, nil, errors.New("TLS_AUTOCERT_DOMAINS cannot be combined with TLS_CERT_FILE/TLS_KEY_FILE")
+		}
+		m = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(envString("TLS_AUTOCERT_CACHE", "autocert-cache")),
+			Email:      envString("TLS_AUTOCERT_EMAIL", ""),
+		}
+		cfg = m.TLSConfig()
+		cfg.MinVersion = tls.VersionTLS12
+	case certFile != "" && keyFile != "":
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading TLS key pair: %w", err)
+		}
+		cfg = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+		}
+	case certFile != "" || keyFile != "":
+		return nil, nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	default:
+		return nil, nil, nil
+	}
+
+	if caFile := envString("TLS_CLIENT_CA_FILE", ""); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		if strings.EqualFold(envString("TLS_CLIENT_AUTH", ""), "optional") {
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	return cfg, m, nil
+}
+
+// tlsClientAttributes copies the peer certificate identity onto the span.
+func tlsClientAttributes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cs := c.Request.TLS; cs != nil && len(cs.PeerCertificates) > 0 {
+			leaf := cs.PeerCertificates[0]
+			traceSpan(c.Request.Context()).SetAttributes(
+				attribute.String("tls.client.subject", leaf.Subject.String()),
+				attribute.String("tls.client.issuer", leaf.Issuer.String()),
+				attribute.String("tls.client.serial_number", leaf.SerialNumber.String()),
+			)
+		}
+		c.Next()
+	}
+}
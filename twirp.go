@@ -0,0 +1,76 @@
+// twirp.go — the same ItemService as Twirp under /twirp/items.v1.ItemService/,
+//   a third RPC style from the one proto: plain HTTP POSTs of JSON or
+//   protobuf, no HTTP/2, no gateway
+//   • served on the main router by grpcItemServer, whose methods already
+//     have Twirp's shape; an interceptor turns its gRPC status errors into
+//     Twirp errors (NOT_FOUND → not_found/404)
+//   • each call is an items.v1.ItemService/<Method> span like otelgrpc's,
+//     with rpc.system=twirp, so the three styles compare side by side
+//   • disabled with TWIRP=false
+
+package main
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	itemsv1 "github.com/micro-company/http-trace-example/proto/items/v1"
+	"github.com/twitchtv/twirp"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// twirpCodes maps the gRPC codes storeErrorCode produces.
+var twirpCodes = map[codes.Code]twirp.ErrorCode{
+	codes.NotFound:          twirp.NotFound,
+	codes.ResourceExhausted: twirp.ResourceExhausted,
+	codes.DeadlineExceeded:  twirp.DeadlineExceeded,
+	codes.Canceled:          twirp.Canceled,
+	codes.InvalidArgument:   twirp.InvalidArgument,
+}
+
+func registerTwirp(r gin.IRoutes) {
+	if !envBool("TWIRP", true) {
+		return
+	}
+	srv := itemsv1.NewItemServiceServer(grpcItemServer{}, twirp.WithServerInterceptors(twirpTracing))
+	r.POST(srv.PathPrefix()+":method", gin.WrapH(unprefixed(srv)))
+}
+
+func twirpTracing(next twirp.Method) twirp.Method {
+	return func(ctx context.Context, req any) (any, error) {
+		service, _ := twirp.ServiceName(ctx)
+		pkg, _ := twirp.PackageName(ctx)
+		method, _ := twirp.MethodName(ctx)
+		full := pkg + "." + service
+		ctx, span := tracer.Start(ctx, full+"/"+method, trace.WithAttributes(
+			attribute.String("rpc.system", "twirp"),
+			attribute.String("rpc.service", full),
+			attribute.String("rpc.method", method),
+		))
+		defer span.End()
+
+		resp, err := next(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		twerr, ok := err.(twirp.Error)
+		if !ok {
+			st, _ := status.FromError(err)
+			code, known := twirpCodes[st.Code()]
+			if !known {
+				code = twirp.Internal
+			}
+			twerr = twirp.NewError(code, st.Message())
+		}
+		span.SetAttributes(attribute.String("rpc.twirp.error_code", string(twerr.Code())))
+		span.SetStatus(otelcodes.Error, twerr.Msg())
+		if twerr.Code() == twirp.Internal {
+			span.RecordError(err)
+		}
+		return nil, twerr
+	}
+}
@@ -0,0 +1,63 @@
+// ui.go — small embedded pages (UI=true) for workshops:
+//   • / lists, creates and deletes items through the REST API
+//   • the page starts each call's trace itself (a sampled traceparent) and
+//     shows its trace ID, linked through TRACE_URL ({trace_id} is replaced;
+//     default: Grafana Explore on localhost:3000), so a click leads from
+//     the call to its spans
+//   • /admin/ is a console for the admin API: fault
+//     injection, maintenance mode, log level and sampling ratio; it sits
+//     behind adminAuth like the rest of /admin
+//   • the pages under ui/ are embedded in the binary, no build step; the
+//     demo page's script and styles are served by static.go
+
+package main
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// defaultTraceURL opens the trace in the docker-compose Grafana.
+const defaultTraceURL = "http://localhost:3000/explore?left=" +
+	`%7B%22datasource%22:%22tempo%22,%22queries%22:%5B%7B%22query%22:%22{trace_id}%22%7D%5D%7D`
+
+var uiPage = template.Must(template.New("index.html").
+	Funcs(template.FuncMap{"asset": assetURL}).
+	ParseFS(uiFiles, "ui/index.html"))
+
+func registerUI(r gin.IRouter) {
+	if !envBool("UI", true) {
+		return
+	}
+	var page bytes.Buffer
+	if err := uiPage.Execute(&page, struct{ BasePath, TraceURL string }{
+		basePath, envString("TRACE_URL", defaultTraceURL),
+	}); err != nil {
+		panic(err)
+	}
+	r.GET("/", func(c *gin.Context) {
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+	})
+	registerStatic(r)
+}
+
+func registerAdminConsole(r gin.IRouter) {
+	if !envBool("UI", true) {
+		return
+	}
+	page, err := uiFiles.ReadFile("ui/admin.html")
+	if err != nil {
+		panic(err)
+	}
+	r.GET("/admin/", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	})
+}
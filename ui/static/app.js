@@ -0,0 +1,70 @@
+// base and traceURL come from the page
+const $ = (id) => document.getElementById(id);
+const hex = (bytes) => Array.from(crypto.getRandomValues(new Uint8Array(bytes)),
+  (b) => b.toString(16).padStart(2, "0")).join("");
+
+// call sends one traced request and logs it with a link to its trace.
+async function call(method, path, body) {
+  const traceID = hex(16);
+  const headers = { traceparent: `00-${traceID}-${hex(8)}-01` };
+  if ($("apikey").value) headers["X-API-Key"] = $("apikey").value;
+  if ($("bearer").value) headers["Authorization"] = "Bearer " + $("bearer").value;
+  if (body !== undefined) headers["Content-Type"] = "application/json";
+
+  const start = performance.now();
+  let status = "network error", data = null;
+  try {
+    const res = await fetch(base + path, { method, headers, body: body && JSON.stringify(body) });
+    status = res.status;
+    if (res.status !== 204) data = await res.json().catch(() => null);
+  } finally {
+    logCall(`${method} ${path}`, status, performance.now() - start, traceID);
+  }
+  if (typeof status !== "number" || status >= 400) {
+    throw new Error((data && (data.detail || data.error)) || `HTTP ${status}`);
+  }
+  return data;
+}
+
+function logCall(what, status, ms, traceID) {
+  const tr = document.createElement("tr");
+  const link = traceURL
+    ? `<a href="${traceURL.replace("{trace_id}", traceID)}" target="_blank" rel="noopener"><code>${traceID}</code></a>`
+    : `<code>${traceID}</code>`;
+  tr.innerHTML = `<td><code></code></td><td${status >= 400 || typeof status !== "number" ? ' class="err"' : ""}></td>` +
+    `<td>${ms.toFixed(1)} ms</td><td>${link}</td>`;
+  tr.children[0].firstChild.textContent = what;
+  tr.children[1].textContent = status;
+  $("calls").prepend(tr);
+}
+
+async function refresh() {
+  let items;
+  try {
+    items = await call("GET", "/items");
+  } catch (e) {
+    return;
+  }
+  const rows = (Array.isArray(items) ? items : items.items || []).sort((a, b) => a.id - b.id);
+  $("items").replaceChildren(...rows.map((item) => {
+    const tr = document.createElement("tr");
+    tr.innerHTML = "<td></td><td></td><td><button>Delete</button></td>";
+    tr.children[0].textContent = item.id;
+    tr.children[1].textContent = item.name;
+    tr.querySelector("button").onclick = () => call("DELETE", `/items/${item.id}`).then(refresh, refresh);
+    return tr;
+  }));
+}
+
+$("create").onsubmit = async (e) => {
+  e.preventDefault();
+  try {
+    await call("POST", "/items", { name: $("name").value });
+    $("name").value = "";
+  } catch (e) {
+    // the failed call is in the log
+  }
+  refresh();
+};
+$("refresh").onclick = refresh;
+refresh();
@@ -0,0 +1,106 @@
+// version.go — build metadata for GET /version, `app version` and the OTel
+//   resource (service.version, vcs.ref.head.revision), so a trace can be
+//   matched to the binary that produced it:
+//   • release builds stamp it with ldflags:
+//       go build -ldflags "-X main.version=v1.4.0 -X main.commit=$(git rev-parse HEAD) \
+//         -X main.buildDate=$(date -u +%FT%TZ)"
+//   • otherwise the module version and the VCS stamp Go embeds are used
+//   • /version also lists the features this process has switched on
+
+package main
+
+import (
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// set with -ldflags "-X main.version=…"
+var (
+	version   string
+	commit    string
+	buildDate string
+)
+
+type buildMetadata struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a dirty tree
+	GoVersion string `json:"go_version"`
+	JSONCodec string `json:"json_codec"` // see jsoncodec.go
+}
+
+var buildInfo = sync.OnceValue(func() buildMetadata {
+	b := buildMetadata{Version: version, Commit: commit, BuildDate: buildDate, JSONCodec: jsonCodec}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	b.GoVersion = bi.GoVersion
+	if b.Version == "" {
+		b.Version = bi.Main.Version // "(devel)" outside a module download
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if b.Commit == "" {
+				b.Commit = s.Value
+			}
+		case "vcs.time":
+			if b.BuildDate == "" {
+				b.BuildDate = s.Value
+			}
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		}
+	}
+	return b
+})
+
+// enabledFeatures names the optional subsystems the current settings turn on.
+func enabledFeatures() []string {
+	var out []string
+	on := func(name string, enabled bool) {
+		if enabled {
+			out = append(out, name)
+		}
+	}
+	on("grpc", grpcAddr() != "")
+	on("twirp", envBool("TWIRP", true))
+	on("echo-service", envString("ECHO_LISTEN", "127.0.0.1:8081") != "off")
+	on("admin-listener", envString("ADMIN_LISTEN", "") != "")
+	on("tls", envString("TLS_CERT_FILE", "") != "" || envString("TLS_AUTOCERT_DOMAINS", "") != "")
+	on("api-keys", envString("API_KEYS", "") != "" || envString("API_KEYS_FILE", "") != "")
+	on("jwt", envString("JWT_HMAC_SECRET", "") != "" || envString("JWT_JWKS_URL", "") != "" || envString("OIDC_ISSUER_URL", "") != "")
+	on("kafka", envString("KAFKA_BROKERS", "") != "")
+	on("amqp", envString("AMQP_URL", "") != "")
+	on("outbox", envBool("OUTBOX", false))
+	on("redis-cache", envString("REDIS_ADDR", "") != "")
+	on("flagd", envString("FEATURE_FLAGS_PROVIDER", "env") == "flagd")
+	on("loadgen", envString("LOADGEN_PROFILE", "") != "")
+	on("canary", envDuration("CANARY_INTERVAL", 0) > 0)
+	on("pprof", envBool("PPROF_ENABLED", true))
+	on("profiling", profilingEnabled())
+	on("cors", mountedMiddleware.CORS)
+	on("rate-limit", mountedMiddleware.RateLimit)
+	on("maintenance", maintenance.on.Load())
+	on("multi-tenant", multiTenant())
+	on("ui", envBool("UI", true))
+	on("response-cache", envDuration("RESPONSE_CACHE_TTL", 0) > 0)
+	slices.Sort(out)
+	return out
+}
+
+func registerVersion(r gin.IRouter) {
+	r.GET("/version", func(c *gin.Context) {
+		c.JSON(http.StatusOK, struct {
+			buildMetadata
+			Service  string   `json:"service"`
+			Features []string `json:"features"`
+		}{buildInfo(), "otel-crud-example", enabledFeatures()})
+	})
+}
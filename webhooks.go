@@ -0,0 +1,388 @@
+// webhooks.go — outbound webhooks for item changes:
+//   • POST /webhooks {"url":…, "events":["created",…]} registers a target
+//     (all changes when events is empty); GET lists, DELETE removes
+//   • every matching item event is POSTed as a structured CloudEvent, in
+//     the background, under a webhook.deliver span in the request's trace;
+//     the outbound client adds traceparent and one client span per attempt
+//   • transient failures are retried (WEBHOOK_MAX_ATTEMPTS, backoff as for
+//     other outbound calls); every attempt carries the delivery ID as
+//     X-Webhook-Delivery and Idempotency-Key, for receivers to drop repeats
+//   • GET /webhooks/:id/deliveries shows the last deliveries and their status;
+//     deliveries that still fail are dead-lettered (deadletter.go)
+//   • WEBHOOK_MAX (100) caps the registrations; unless
+//     WEBHOOK_ALLOW_PRIVATE, targets on loopback, private or link-local
+//     addresses are refused at registration and again at dial time, where
+//     the name has been resolved, so DNS can't point a hook inward
+//   • URLs can carry secrets: spans and GET /webhooks show them without
+//     password or query
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/micro-company/http-trace-example/internal/httpclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// webhookHistory is how many deliveries are kept per webhook.
+const webhookHistory = 50
+
+type webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events,omitempty"` // created|updated|deleted
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// redacted is w as the API shows it.
+func (w webhook) redacted() webhook {
+	w.URL = redactURL(w.URL)
+	return w
+}
+
+// redactURL drops the password and the query, where signing secrets and
+// tokens tend to live.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	u.RawQuery, u.ForceQuery, u.Fragment = "", false, ""
+	return u.Redacted()
+}
+
+// wants reports whether the webhook subscribed to events of type typ.
+func (w webhook) wants(typ string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, strings.TrimPrefix(typ, "com.example.item."))
+}
+
+type webhookDelivery struct {
+	ID          string     `json:"id"`
+	EventID     string     `json:"event_id"`
+	EventType   string     `json:"event_type"`
+	Status      string     `json:"status"` // pending|delivered|failed
+	HTTPStatus  int        `json:"http_status,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	TraceID     string     `json:"trace_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+var webhookDeliveries, _ = meter.Int64Counter("webhook.deliveries",
+	metric.WithDescription("Webhook deliveries by result (delivered|failed)"))
+
+var errPrivateTarget = errors.New("webhook target is a loopback, private or link-local address")
+
+// webhookRegistry is an eventPublisher that fans events out to the
+// registered webhooks.
+type webhookRegistry struct {
+	client       *httpclient.Client
+	maxAttempts  int
+	maxHooks     int // 0: no cap
+	allowPrivate bool
+
+	mu         sync.Mutex
+	hooks      map[string]webhook
+	deliveries map[string][]*webhookDelivery // newest last
+	inflight   sync.WaitGroup
+}
+
+var webhooks = newWebhookRegistry()
+
+func newWebhookRegistry() *webhookRegistry {
+	attempts := envInt("WEBHOOK_MAX_ATTEMPTS", 5)
+	allowPrivate := envBool("WEBHOOK_ALLOW_PRIVATE", false)
+	return &webhookRegistry{
+		client:       newOutboundClientOver(webhookTransport(allowPrivate), envDuration("WEBHOOK_TIMEOUT", 10*time.Second)).WithMaxAttempts(attempts),
+		maxAttempts:  attempts,
+		maxHooks:     envInt("WEBHOOK_MAX", 100),
+		allowPrivate: allowPrivate,
+		hooks:        map[string]webhook{},
+		deliveries:   map[string][]*webhookDelivery{},
+	}
+}
+
+// webhookTransport is http.DefaultTransport, refusing to connect to
+// non-public addresses unless allowPrivate.
+func webhookTransport(allowPrivate bool) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if allowPrivate {
+		return t
+	}
+	d := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddr(ap.Addr()) {
+				return errPrivateTarget
+			}
+			return nil
+		},
+	}
+	t.DialContext = d.DialContext
+	return t
+}
+
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// checkTarget refuses a host that is, or resolves to, a non-public
+// address. A name that doesn't resolve yet is left to the dial-time check.
+func (r *webhookRegistry) checkTarget(ctx context.Context, host string) error {
+	if r.allowPrivate {
+		return nil
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(ip) {
+			return errPrivateTarget
+		}
+		return nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if !publicAddr(ip) {
+			return errPrivateTarget
+		}
+	}
+	return nil
+}
+
+// Publish starts a delivery to each interested webhook and returns at once.
+func (r *webhookRegistry) Publish(ctx context.Context, ev itemEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.hooks {
+		if !w.wants(ev.Type) {
+			continue
+		}
+		d := r.newDelivery(ctx, w.ID, ev)
+		r.inflight.Add(1)
+		go func() {
+			defer r.inflight.Done()
+			ctx := context.WithoutCancel(ctx)
+			if err := r.deliver(ctx, w, d, ev); err != nil {
+				r.deadLetter(ctx, w.ID, ev, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// newDelivery records a pending delivery; callers hold r.mu.
+func (r *webhookRegistry) newDelivery(ctx context.Context, webhookID string, ev itemEvent) *webhookDelivery {
+	d := &webhookDelivery{
+		ID:        uuid.NewString(),
+		EventID:   ev.ID,
+		EventType: ev.Type,
+		Status:    "pending",
+		TraceID:   traceSpan(ctx).SpanContext().TraceID().String(),
+		CreatedAt: time.Now().UTC(),
+	}
+	r.deliveries[webhookID] = append(r.deliveries[webhookID], d)
+	if n := len(r.deliveries[webhookID]); n > webhookHistory {
+		r.deliveries[webhookID] = r.deliveries[webhookID][n-webhookHistory:]
+	}
+	return d
+}
+
+// deadLetter parks a delivery that used up its attempts; replaying it is a
+// fresh delivery to the webhook, if it is still registered.
+func (r *webhookRegistry) deadLetter(ctx context.Context, webhookID string, ev itemEvent, cause error) {
+	payload, _ := json.Marshal(gin.H{"webhook_id": webhookID, "event": ev})
+	addDeadLetter(ctx, "webhook", cause, r.maxAttempts, payload, func(ctx context.Context) error {
+		r.mu.Lock()
+		w, ok := r.hooks[webhookID]
+		var d *webhookDelivery
+		if ok {
+			d = r.newDelivery(ctx, webhookID, ev)
+		}
+		r.mu.Unlock()
+		if !ok {
+			return errors.New("webhook no longer registered")
+		}
+		return r.deliver(ctx, w, d, ev)
+	})
+}
+
+// Close waits for deliveries in progress.
+func (r *webhookRegistry) Close() error {
+	r.inflight.Wait()
+	return nil
+}
+
+func (r *webhookRegistry) deliver(ctx context.Context, w webhook, d *webhookDelivery, ev itemEvent) error {
+	ctx, span := tracer.Start(ctx, "webhook.deliver", trace.WithAttributes(
+		attribute.String("webhook.id", w.ID),
+		attribute.String("webhook.delivery.id", d.ID),
+		attribute.String("url.full", redactURL(w.URL)),
+		attribute.String("cloudevents.event_id", ev.ID),
+		attribute.String("cloudevents.event_type", ev.Type),
+	))
+	defer span.End()
+
+	status, err := r.post(ctx, w.URL, d.ID, ev)
+	result := "delivered"
+	if err != nil {
+		result = "failed"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.SetAttributes(attribute.String("webhook.delivery.status", result))
+	webhookDeliveries.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+
+	now := time.Now().UTC()
+	r.mu.Lock()
+	d.Status, d.HTTPStatus, d.CompletedAt = result, status, &now
+	if err != nil {
+		d.Error = err.Error()
+	}
+	r.mu.Unlock()
+	return err
+}
+
+// post sends ev through the retrying client; a final non-2xx answer is a
+// failure.
+func (r *webhookRegistry) post(ctx context.Context, target, deliveryID string, ev itemEvent) (int, error) {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", cloudEventsJSON)
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	req.Header.Set("Idempotency-Key", deliveryID) // lets the client retry the POST
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/* HTTP API                                                                   */
+/* -------------------------------------------------------------------------- */
+
+func registerWebhookRoutes(r gin.IRoutes) {
+	r.POST("/webhooks", webhooks.createHandler)
+	r.GET("/webhooks", webhooks.listHandler)
+	r.DELETE("/webhooks/:id", webhooks.deleteHandler)
+	r.GET("/webhooks/:id/deliveries", webhooks.deliveriesHandler)
+}
+
+func (r *webhookRegistry) createHandler(c *gin.Context) {
+	var in struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		respondError(c, errors.New("url must be an absolute http(s) URL"), http.StatusBadRequest)
+		return
+	}
+	if err := r.checkTarget(c.Request.Context(), u.Hostname()); err != nil {
+		respondError(c, err, http.StatusBadRequest)
+		return
+	}
+	for _, e := range in.Events {
+		if e != "created" && e != "updated" && e != "deleted" {
+			respondError(c, fmt.Errorf("unknown event %q (want created|updated|deleted)", e), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w := webhook{ID: uuid.NewString(), URL: in.URL, Events: in.Events, CreatedAt: time.Now().UTC()}
+	r.mu.Lock()
+	full := r.maxHooks > 0 && len(r.hooks) >= r.maxHooks
+	if !full {
+		r.hooks[w.ID] = w
+	}
+	r.mu.Unlock()
+	if full {
+		respondError(c, fmt.Errorf("webhook limit of %d reached", r.maxHooks), http.StatusForbidden)
+		return
+	}
+	traceSpan(c.Request.Context()).SetAttributes(attribute.String("webhook.id", w.ID))
+	c.JSON(http.StatusCreated, w.redacted())
+}
+
+func (r *webhookRegistry) listHandler(c *gin.Context) {
+	r.mu.Lock()
+	out := make([]webhook, 0, len(r.hooks))
+	for _, w := range r.hooks {
+		out = append(out, w.redacted())
+	}
+	r.mu.Unlock()
+	slices.SortFunc(out, func(a, b webhook) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	c.JSON(http.StatusOK, out)
+}
+
+func (r *webhookRegistry) deleteHandler(c *gin.Context) {
+	id := c.Param("id")
+	r.mu.Lock()
+	_, ok := r.hooks[id]
+	delete(r.hooks, id)
+	delete(r.deliveries, id)
+	r.mu.Unlock()
+	if !ok {
+		respondError(c, errNotFound, http.StatusNotFound)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// deliveriesHandler lists the webhook's recent deliveries, newest first.
+func (r *webhookRegistry) deliveriesHandler(c *gin.Context) {
+	id := c.Param("id")
+	r.mu.Lock()
+	_, ok := r.hooks[id]
+	ds := r.deliveries[id]
+	out := make([]webhookDelivery, len(ds))
+	for i, d := range ds {
+		out[len(ds)-1-i] = *d
+	}
+	r.mu.Unlock()
+	if !ok {
+		respondError(c, errNotFound, http.StatusNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,124 @@
+// workerpool.go — bounded background execution for async endpoints:
+//   • JOB_WORKERS (4) goroutines take tasks from a queue of JOB_QUEUE_SIZE
+//     (100); a full queue is refused (503) rather than grown
+//   • jobs.queue.wait (enqueue → start) and jobs.queue.depth metrics
+//   • on shutdown the queue stops accepting and what is queued or running
+//     finishes, bounded by JOB_DRAIN_TIMEOUT (30s)
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	errQueueFull  = errors.New("job queue full")
+	errPoolClosed = errors.New("job queue closed")
+)
+
+type poolTask struct {
+	kind     string
+	enqueued time.Time
+	run      func(wait time.Duration)
+}
+
+type workerPool struct {
+	queue   chan poolTask
+	workers sync.WaitGroup
+
+	mu     sync.RWMutex // guards closed against sends on a closed queue
+	closed bool
+
+	wait metric.Float64Histogram
+}
+
+// jobPool runs async jobs; main starts it.
+var jobPool *workerPool
+
+func newWorkerPool(workers, size int) *workerPool {
+	p := &workerPool{queue: make(chan poolTask, max(size, 0))}
+	p.wait, _ = meter.Float64Histogram("jobs.queue.wait",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Time a job spent queued before a worker picked it up"))
+	_, _ = meter.Int64ObservableGauge("jobs.queue.depth",
+		metric.WithDescription("Jobs waiting for a worker"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(len(p.queue)))
+			return nil
+		}))
+
+	for range max(workers, 1) {
+		p.workers.Add(1)
+		go func() {
+			defer p.workers.Done()
+			for t := range p.queue {
+				wait := time.Since(t.enqueued)
+				p.wait.Record(context.Background(), float64(wait.Microseconds())/1000,
+					metric.WithAttributes(attribute.String("job.kind", t.kind)))
+				t.run(wait)
+			}
+		}()
+	}
+	return p
+}
+
+// Submit queues run without blocking; run receives the time spent queued.
+func (p *workerPool) Submit(kind string, run func(wait time.Duration)) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return errPoolClosed
+	}
+	select {
+	case p.queue <- poolTask{kind: kind, enqueued: time.Now(), run: run}:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// drain refuses new tasks and waits for the queued and running ones.
+func (p *workerPool) drain(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startWorkerPool creates jobPool; the returned func drains it.
+func startWorkerPool(l *slog.Logger) func() {
+	workers, size := envInt("JOB_WORKERS", 4), envInt("JOB_QUEUE_SIZE", 100)
+	jobPool = newWorkerPool(workers, size)
+	l.Info("job workers started", "workers", workers, "queue_size", size)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), envDuration("JOB_DRAIN_TIMEOUT", 30*time.Second))
+		defer cancel()
+		if err := jobPool.drain(ctx); err != nil {
+			l.Warn("job queue not drained", "pending", len(jobPool.queue), "err", err)
+			return
+		}
+		l.Info("job queue drained")
+	}
+}
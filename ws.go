@@ -0,0 +1,279 @@
+// ws.go — /ws speaks JSON over WebSocket for bidirectional item updates:
+//   • requests {"id":"1","op":"create|get|list|update|delete|subscribe|
+//     unsubscribe","item":{…},"traceparent":"…"} get a reply with the same id;
+//     after subscribe the server also pushes {"type":"event",…} changes
+//   • the upgrade request's span covers the connection (ws.open/ws.close
+//     events); each message is a ws.message <op> span, a child of the
+//     message's own traceparent if it has one, otherwise a new trace linked
+//     to the connection; pushes are ws.send spans in the changing trace
+//   • ws.connections and ws.messages (by direction and op) on /metrics
+//   • cross-origin upgrades are refused unless WS_ALLOWED_ORIGINS lists them;
+//     connections close with 1001 when shutdown begins
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const wsMaxMessage = 64 << 10
+
+type wsRequest struct {
+	ID          string `json:"id"`
+	Op          string `json:"op"`
+	Item        *Item  `json:"item,omitempty"`
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+}
+
+type wsReply struct {
+	Type    string     `json:"type"` // reply|event
+	ID      string     `json:"id,omitempty"`
+	OK      bool       `json:"ok"`
+	Item    *Item      `json:"item,omitempty"`
+	Items   []Item     `json:"items,omitempty"`
+	Error   string     `json:"error,omitempty"`
+	TraceID string     `json:"trace_id,omitempty"`
+	Seq     int64      `json:"seq,omitempty"`
+	Event   *itemEvent `json:"event,omitempty"`
+}
+
+var (
+	wsConnections, _ = meter.Int64UpDownCounter("ws.connections",
+		metric.WithDescription("Open WebSocket connections"))
+	wsMessages, _ = meter.Int64Counter("ws.messages",
+		metric.WithDescription("WebSocket messages by direction (in|out) and op"))
+)
+
+var wsUpgrader = websocket.Upgrader{CheckOrigin: wsCheckOrigin}
+
+// wsCheckOrigin allows same-host and WS_ALLOWED_ORIGINS origins (and
+// clients that send none, like curl or server-side SDKs).
+func wsCheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if slices.Contains(envList("WS_ALLOWED_ORIGINS"), origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// wsConn serialises writes; gorilla allows one writer at a time.
+type wsConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *wsConn) send(ctx context.Context, op string, v wsReply) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	wsMessages.Add(ctx, 1, metric.WithAttributes(attribute.String("direction", "out"), attribute.String("op", op)))
+	return c.WriteJSON(v)
+}
+
+func wsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	connSpan := traceSpan(ctx)
+	raw, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader has already answered with an HTTP error
+		connSpan.RecordError(err)
+		return
+	}
+	c.Set(ctxStreaming, true)
+	conn := &wsConn{Conn: raw}
+	defer conn.Close()
+	conn.SetReadLimit(wsMaxMessage)
+
+	sub, _, _, cancel := changes.subscribe(0)
+	defer cancel()
+
+	wsConnections.Add(ctx, 1)
+	defer wsConnections.Add(context.WithoutCancel(ctx), -1)
+	connSpan.AddEvent("ws.open")
+	start := time.Now()
+
+	var (
+		subscribed atomic.Bool // pushes enabled
+		received   atomic.Int64
+		readErr    = make(chan error, 1)
+	)
+	go func() {
+		for {
+			var req wsRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				readErr <- err
+				return
+			}
+			received.Add(1)
+			reply := handleWSMessage(connSpan.SpanContext(), req, &subscribed)
+			if err := conn.send(ctx, req.Op, reply); err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	closeCode := websocket.CloseNormalClosure
+	defer func() {
+		connSpan.AddEvent("ws.close", trace.WithAttributes(attribute.Int("ws.close_code", closeCode)))
+		connSpan.SetAttributes(
+			attribute.Int64("ws.messages_received", received.Load()),
+			attribute.Int64("ws.duration_ms", time.Since(start).Milliseconds()),
+		)
+	}()
+	for {
+		select {
+		case err := <-readErr:
+			var ce *websocket.CloseError
+			if errors.As(err, &ce) {
+				closeCode = ce.Code
+			} else {
+				closeCode = websocket.CloseAbnormalClosure
+			}
+			return
+		case e, ok := <-sub.ch:
+			if !ok {
+				// shutting down (or fell behind): tell the client to reconnect
+				closeCode = websocket.CloseGoingAway
+				conn.mu.Lock()
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(closeCode, "server going away"), time.Now().Add(time.Second))
+				conn.mu.Unlock()
+				return
+			}
+			if subscribed.Load() {
+				if err := wsPush(conn, connSpan.SpanContext(), e); err != nil {
+					closeCode = websocket.CloseAbnormalClosure
+					return
+				}
+			}
+		}
+	}
+}
+
+// handleWSMessage runs one request in its own span and builds the reply.
+func handleWSMessage(conn trace.SpanContext, req wsRequest, subscribed *atomic.Bool) wsReply {
+	parent := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier{
+		"traceparent": req.TraceParent,
+		"tracestate":  req.TraceState,
+	})
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithLinks(trace.Link{SpanContext: conn}),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "websocket"),
+			attribute.String("ws.op", req.Op),
+			attribute.String("ws.message_id", req.ID),
+		),
+	}
+	if !trace.SpanContextFromContext(parent).IsValid() {
+		opts = append(opts, trace.WithNewRoot())
+	}
+	ctx, span := tracer.Start(parent, "ws.message "+req.Op, opts...)
+	defer span.End()
+	wsMessages.Add(ctx, 1, metric.WithAttributes(attribute.String("direction", "in"), attribute.String("op", req.Op)))
+
+	reply := wsReply{Type: "reply", ID: req.ID, TraceID: span.SpanContext().TraceID().String()}
+	err := func() error {
+		switch req.Op {
+		case "subscribe":
+			subscribed.Store(true)
+		case "unsubscribe":
+			subscribed.Store(false)
+		case "list":
+			items, err := repo.List(ctx)
+			reply.Items = items
+			return err
+		case "create", "get", "update", "delete":
+			if req.Item == nil {
+				return errors.New(`"item" is required`)
+			}
+			span.SetAttributes(attribute.Int("item.id", req.Item.ID))
+			return wsItemOp(ctx, req.Op, *req.Item, &reply)
+		default:
+			return errors.New("unknown op " + req.Op)
+		}
+		return nil
+	}()
+	reply.OK = err == nil
+	if err != nil {
+		reply.Error = err.Error()
+		if !errors.Is(err, errNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	return reply
+}
+
+func wsItemOp(ctx context.Context, op string, in Item, reply *wsReply) error {
+	switch op {
+	case "create":
+		item, err := repo.Create(ctx, in.Name)
+		if err == nil {
+			reply.Item = &item
+		}
+		return err
+	case "get":
+		item, err := repo.Get(ctx, in.ID)
+		if err == nil {
+			reply.Item = &item
+		}
+		return err
+	case "update":
+		item, err := repo.Update(ctx, in.ID, func(item Item) (Item, error) {
+			item.Name = in.Name
+			return item, nil
+		})
+		if err == nil {
+			reply.Item = &item
+		}
+		return err
+	default: // delete
+		return repo.Delete(ctx, in.ID)
+	}
+}
+
+// wsPush sends a change under a ws.send span in the trace that made it.
+func wsPush(conn *wsConn, connSpan trace.SpanContext, e changeEntry) error {
+	parent := trace.ContextWithRemoteSpanContext(context.Background(), e.producer)
+	ctx, span := tracer.Start(parent, "ws.send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithLinks(trace.Link{SpanContext: connSpan}),
+		trace.WithAttributes(
+			attribute.Int64("ws.seq", e.Seq),
+			attribute.String("cloudevents.event_type", e.Event.Type),
+		))
+	defer span.End()
+
+	ev := e.Event
+	msg := wsReply{Type: "event", OK: true, Seq: e.Seq, Event: &ev, TraceID: e.producer.TraceID().String()}
+	if err := conn.send(ctx, "event", msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}